@@ -1,40 +1,643 @@
 /*
-Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+Copyright © 2023 Shield
 
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
 */
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Configuration sources, from highest to lowest precedence
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceProject = "project file"
+	sourceGlobal  = "global file"
+	sourceDefault = "default"
+)
+
+// Configuration scopes accepted by `config path` and `config edit`
+const (
+	scopeGlobal   = "global"
+	scopeLocalenv = "localenv"
 )
 
+// localenvKeyPrefix selects the project localenv.yaml in configuration keys
+const localenvKeyPrefix = "localenv."
+
+// customComponentsKey is the localenv.yaml path of the custom components
+const customComponentsKey = "components.custom"
+
+// globalEnvPrefix is the prefix of environment variables read by initConfig
+const globalEnvPrefix = "DEVHELPER_"
+
+// localenvDefaults holds the values components use for settings missing
+// from localenv.yaml
+var localenvDefaults = map[string]interface{}{
+	"components.dapr.dashboardPort":       8080,
+	"components.dapr.zipkinPort":          9411,
+	"components.temporal.namespace":       "default",
+	"components.temporal.uiPort":          8233,
+	"components.temporal.grpcPort":        7233,
+	"components.openSearch.version":       defaultOpenSearchVersion,
+	"components.openSearch.port":          9200,
+	"components.openSearch.dashboardPort": 5601,
+}
+
+// configValue is an effective configuration value and where it came from
+type configValue struct {
+	Key    string      `json:"key" yaml:"key"`
+	Value  interface{} `json:"value" yaml:"value"`
+	Source string      `json:"source" yaml:"source"`
+}
+
 // configCmd represents the config command
 var configCmd = &cobra.Command{
 	Use:   "config",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+	Short: "View and change devhelper-cli configuration",
+	Long: `View and change the global devhelper-cli configuration and the project
+local environment configuration.
+
+Global settings live in $HOME/.devhelper-cli.yaml (or the file given with
+--config) and can be overridden with DEVHELPER_* environment variables and
+flags. Keys starting with "localenv." refer to settings in the project
+localenv.yaml, e.g. localenv.components.temporal.uiPort.
+
+Examples:
+  devhelper-cli config view                                   # Show every value and its source
+  devhelper-cli config get localenv.components.dapr.dashboardPort
+  devhelper-cli config set localenv.components.temporal.uiPort 8234
+  devhelper-cli config unset verbose
+  devhelper-cli config path                                   # Show which files are used
+  devhelper-cli config edit localenv                          # Open localenv.yaml in $EDITOR`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+// configViewCmd shows the effective configuration
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the effective configuration and where each value came from",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("config called")
+		output, _ := cmd.Flags().GetString("output")
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+
+		if output != "text" && output != "json" && output != "yaml" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use text, json or yaml)\n", output)
+			os.Exit(1)
+		}
+
+		localenvValues, err := localenvConfigValues(localenvPath)
+		if err != nil {
+			printConfigError(localenvPath, err)
+			os.Exit(1)
+		}
+		values := append(globalConfigValues(), localenvValues...)
+
+		switch output {
+		case "json":
+			data, err := json.MarshalIndent(values, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to encode configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		case "yaml":
+			data, err := yamlv3.Marshal(values)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to encode configuration: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(string(data))
+		default:
+			fmt.Printf("Global configuration: %s\n", describeConfigFile(globalConfigPath()))
+			fmt.Printf("Project configuration: %s\n\n", describeConfigFile(localenvPath))
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "KEY\tVALUE\tSOURCE")
+			for _, value := range values {
+				fmt.Fprintf(writer, "%s\t%v\t%s\n", value.Key, value.Value, value.Source)
+			}
+			writer.Flush()
+		}
 	},
 }
 
+// configGetCmd prints a single effective value
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a configuration key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		showSource, _ := cmd.Flags().GetBool("show-source")
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+
+		value, err := lookupConfigValue(args[0], localenvPath)
+		if err != nil {
+			printConfigError(localenvPath, err)
+			os.Exit(1)
+		}
+
+		if showSource {
+			fmt.Printf("%v (%s)\n", value.Value, value.Source)
+		} else {
+			fmt.Printf("%v\n", value.Value)
+		}
+	},
+}
+
+// configSetCmd writes a value to the global or project configuration file
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the global or project configuration file",
+	Long: `Set a value in the global configuration file, or in localenv.yaml for keys
+starting with "localenv.". With --profile, localenv keys are set in that profile.
+The project file is validated before it is written.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+
+		path, err := updateConfigFile(args[0], localenvPath, func(root *yamlv3.Node, key string) error {
+			return setConfigNode(root, key, configScalarNode(args[1]))
+		})
+		if err != nil {
+			printConfigError(path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Set %s in %s\n", args[0], path)
+	},
+}
+
+// configUnsetCmd removes a value from the global or project configuration file
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a value from the global or project configuration file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+
+		path, err := updateConfigFile(args[0], localenvPath, func(root *yamlv3.Node, key string) error {
+			if !removeConfigNode(root, key) {
+				return fmt.Errorf("%s is not set", args[0])
+			}
+			return nil
+		})
+		if err != nil {
+			printConfigError(path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Removed %s from %s\n", args[0], path)
+	},
+}
+
+// configPathCmd shows which configuration files are used
+var configPathCmd = &cobra.Command{
+	Use:       "path [global|localenv]",
+	Short:     "Show the configuration files in use",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{scopeGlobal, scopeLocalenv},
+	Run: func(cmd *cobra.Command, args []string) {
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+
+		if len(args) == 1 {
+			fmt.Println(configScopePath(args[0], localenvPath))
+			return
+		}
+		fmt.Printf("global:   %s\n", describeConfigFile(globalConfigPath()))
+		fmt.Printf("localenv: %s\n", describeConfigFile(localenvPath))
+	},
+}
+
+// configEditCmd opens a configuration file in the user's editor
+var configEditCmd = &cobra.Command{
+	Use:       "edit <global|localenv>",
+	Short:     "Open a configuration file in $EDITOR",
+	Long:      `Open the global configuration file or localenv.yaml in $VISUAL or $EDITOR (default vi). localenv.yaml is validated after editing.`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{scopeGlobal, scopeLocalenv},
+	Run: func(cmd *cobra.Command, args []string) {
+		localenvPath, _ := cmd.Flags().GetString("localenv-file")
+		path := configScopePath(args[0], localenvPath)
+
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+		}
+
+		editorArgs := append(strings.Fields(editor), path)
+		editCmd := exec.Command(editorArgs[0], editorArgs[1:]...)
+		editCmd.Stdin = os.Stdin
+		editCmd.Stdout = os.Stdout
+		editCmd.Stderr = os.Stderr
+		if err := editCmd.Run(); err != nil {
+			fmt.Printf("❌ Failed to run editor %s: %v\n", editor, err)
+			os.Exit(1)
+		}
+
+		if args[0] != scopeLocalenv {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		} else if err != nil {
+			fmt.Printf("❌ Failed to read configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := validateLocalEnvConfigFile(path, data); err != nil {
+			printConfigError(path, err)
+			fmt.Println("   Run 'devhelper-cli config edit localenv' to fix it")
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %s is valid\n", path)
+	},
+}
+
+// globalConfigPath returns the global configuration file initConfig read,
+// or the file it would read if it existed
+func globalConfigPath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".devhelper-cli.yaml"
+	}
+	return filepath.Join(home, ".devhelper-cli.yaml")
+}
+
+// configScopePath returns the file of a configuration scope
+func configScopePath(scope, localenvPath string) string {
+	if scope == scopeLocalenv {
+		return localenvPath
+	}
+	return globalConfigPath()
+}
+
+// describeConfigFile returns the path of a configuration file and whether it exists
+func describeConfigFile(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	if _, err := os.Stat(path); err != nil {
+		return path + " (not found)"
+	}
+	return path
+}
+
+// globalEnvName returns the environment variable overriding a global key,
+// e.g. DEVHELPER_LOGS_MAXSIZE for logs.maxSize
+func globalEnvName(key string) string {
+	return globalEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// globalConfigSource reports where viper takes the value of key from
+func globalConfigSource(key string) string {
+	if flag := rootCmd.PersistentFlags().Lookup(key); flag != nil && flag.Changed {
+		return sourceFlag
+	}
+	if _, ok := os.LookupEnv(globalEnvName(key)); ok {
+		return sourceEnv
+	}
+	if viper.InConfig(key) {
+		return sourceGlobal
+	}
+	return sourceDefault
+}
+
+// globalConfigValues returns the effective global settings, including
+// those only set through DEVHELPER_* environment variables
+func globalConfigValues() []configValue {
+	keys := map[string]bool{}
+	envNames := map[string]bool{}
+	for _, key := range viper.AllKeys() {
+		keys[key] = true
+		envNames[globalEnvName(key)] = true
+	}
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if strings.HasPrefix(name, globalEnvPrefix) && !envNames[name] {
+			keys[strings.ToLower(strings.TrimPrefix(name, globalEnvPrefix))] = true
+		}
+	}
+
+	sorted := []string{}
+	for key := range keys {
+		if !strings.HasPrefix(key, localenvKeyPrefix) {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	values := []configValue{}
+	for _, key := range sorted {
+		values = append(values, configValue{Key: key, Value: viper.Get(key), Source: globalConfigSource(key)})
+	}
+	return values
+}
+
+// localenvConfigValues returns every localenv.yaml setting with the active
+// profile applied, prefixed with "localenv.". Settings missing from the file
+// show the value components fall back to.
+func localenvConfigValues(configPath string) ([]configValue, error) {
+	config := LocalEnvConfig{}
+	var root, overlay *yamlv3.Node
+
+	data, err := os.ReadFile(configPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if localenvProfile != "" {
+			return nil, fmt.Errorf("profile %q requires a configuration file, %s not found", localenvProfile, configPath)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	default:
+		if config, err = parseLocalEnvConfig(configPath, data, localenvProfile); err != nil {
+			return nil, err
+		}
+		if root, err = parseConfigNode(configPath, data); err != nil {
+			return nil, err
+		}
+		if localenvProfile != "" {
+			node := config.Profiles[localenvProfile]
+			overlay = &node
+		}
+	}
+
+	values := []configValue{}
+	visitConfigFields(reflect.ValueOf(config), "", func(path string, value interface{}) {
+		source := sourceDefault
+		switch {
+		case findConfigNode(overlay, path) != nil:
+			source = fmt.Sprintf("%s (profile %s)", sourceProject, localenvProfile)
+		case findConfigNode(root, path) != nil:
+			source = sourceProject
+		default:
+			if fallback, ok := localenvDefaults[path]; ok {
+				value = fallback
+			}
+		}
+		values = append(values, configValue{Key: localenvKeyPrefix + path, Value: value, Source: source})
+	})
+	return values, nil
+}
+
+// visitConfigFields calls visit with the dotted path and value of every
+// setting in a configuration struct, in declaration order. Maps of structs,
+// such as components.custom, are expanded by key. Profiles are skipped.
+func visitConfigFields(v reflect.Value, path string, visit func(path string, value interface{})) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		field := v.Field(i)
+		switch {
+		case name == "profiles":
+		case field.Kind() == reflect.Struct:
+			visitConfigFields(field, joinPath(path, name), visit)
+		case field.Kind() == reflect.Map && field.Type().Elem().Kind() == reflect.Struct:
+			keys := []string{}
+			for _, key := range field.MapKeys() {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				visitConfigFields(field.MapIndex(reflect.ValueOf(key)), joinPath(joinPath(path, name), key), visit)
+			}
+		default:
+			visit(joinPath(path, name), field.Interface())
+		}
+	}
+}
+
+// canonicalLocalenvKey returns the localenv.yaml path matching key, ignoring case
+func canonicalLocalenvKey(key string) (string, error) {
+	paths := []string{}
+	visitConfigFields(reflect.ValueOf(LocalEnvConfig{}), "", func(path string, value interface{}) {
+		paths = append(paths, path)
+	})
+
+	for _, path := range paths {
+		if strings.EqualFold(path, key) {
+			return path, nil
+		}
+	}
+	// Custom components are named by the user, the file validation checks their settings
+	if strings.HasPrefix(key, customComponentsKey+".") {
+		return key, nil
+	}
+	message := fmt.Sprintf("unknown key %q", localenvKeyPrefix+key)
+	if suggestion := closestName(key, paths); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", localenvKeyPrefix+suggestion)
+	}
+	return "", errors.New(message)
+}
+
+// lookupConfigValue returns the effective value of a global or localenv key
+func lookupConfigValue(key, localenvPath string) (configValue, error) {
+	if !strings.HasPrefix(key, localenvKeyPrefix) {
+		key = strings.ToLower(key)
+		if !viper.IsSet(key) {
+			return configValue{}, fmt.Errorf("%s is not set", key)
+		}
+		return configValue{Key: key, Value: viper.Get(key), Source: globalConfigSource(key)}, nil
+	}
+
+	path, err := canonicalLocalenvKey(strings.TrimPrefix(key, localenvKeyPrefix))
+	if err != nil {
+		return configValue{}, err
+	}
+	values, err := localenvConfigValues(localenvPath)
+	if err != nil {
+		return configValue{}, err
+	}
+	for _, value := range values {
+		if value.Key == localenvKeyPrefix+path {
+			return value, nil
+		}
+	}
+	return configValue{}, fmt.Errorf("unknown key %q", key)
+}
+
+// updateConfigFile applies update to the file holding key and writes it
+// back. localenv.yaml is validated before it is written. It returns the
+// path of the file.
+func updateConfigFile(key, localenvPath string, update func(root *yamlv3.Node, path string) error) (string, error) {
+	configPath := globalConfigPath()
+	path := strings.ToLower(key)
+	isLocalenv := strings.HasPrefix(key, localenvKeyPrefix)
+	if isLocalenv {
+		configPath = localenvPath
+		canonical, err := canonicalLocalenvKey(strings.TrimPrefix(key, localenvKeyPrefix))
+		if err != nil {
+			return configPath, err
+		}
+		path = canonical
+		if localenvProfile != "" {
+			path = joinPath("profiles."+localenvProfile, path)
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return configPath, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	root, err := parseConfigNode(configPath, data)
+	if err != nil {
+		return configPath, err
+	}
+	if root == nil {
+		root = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	}
+
+	if err := update(root, path); err != nil {
+		return configPath, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return configPath, fmt.Errorf("failed to generate configuration: %w", err)
+	}
+
+	if isLocalenv {
+		if _, err := validateLocalEnvConfigFile(configPath, buf.Bytes()); err != nil {
+			return configPath, err
+		}
+	}
+
+	if err := os.WriteFile(configPath, buf.Bytes(), 0644); err != nil {
+		return configPath, fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return configPath, nil
+}
+
+// configScalarNode converts a command line value to a YAML scalar, so that
+// numbers and booleans keep their type
+func configScalarNode(value string) *yamlv3.Node {
+	document := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal([]byte(value), document); err == nil && len(document.Content) == 1 &&
+		document.Content[0].Kind == yamlv3.ScalarNode {
+		node := document.Content[0]
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: node.ShortTag(), Value: node.Value}
+	}
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// setConfigNode sets the value at a dotted path in a mapping, creating
+// missing mappings along the way
+func setConfigNode(root *yamlv3.Node, path string, value *yamlv3.Node) error {
+	node := root
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!null" {
+			*node = yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		}
+		if node.Kind != yamlv3.MappingNode {
+			return fmt.Errorf("%s is not a mapping", strings.Join(keys[:i], "."))
+		}
+
+		last := i == len(keys)-1
+		var next *yamlv3.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if strings.EqualFold(node.Content[j].Value, key) {
+				if last {
+					node.Content[j+1] = value
+					return nil
+				}
+				next = node.Content[j+1]
+				break
+			}
+		}
+
+		if next == nil {
+			next = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+			if last {
+				next = value
+			}
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, next)
+		}
+		node = next
+	}
+	return nil
+}
+
+// removeConfigNode deletes the value at a dotted path in a mapping and
+// reports whether it was there. Mappings left empty are removed as well.
+func removeConfigNode(node *yamlv3.Node, path string) bool {
+	if node.Kind != yamlv3.MappingNode {
+		return false
+	}
+
+	key, rest, nested := strings.Cut(path, ".")
+	for j := 0; j+1 < len(node.Content); j += 2 {
+		if !strings.EqualFold(node.Content[j].Value, key) {
+			continue
+		}
+		if nested {
+			child := node.Content[j+1]
+			if !removeConfigNode(child, rest) {
+				return false
+			}
+			if len(child.Content) > 0 {
+				return true
+			}
+		}
+		node.Content = append(node.Content[:j], node.Content[j+2:]...)
+		return true
+	}
+	return false
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configEditCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// configCmd.PersistentFlags().String("foo", "", "A help for foo")
+	configCmd.PersistentFlags().String("localenv-file", "localenv.yaml", "Path to the project local environment configuration file")
+	configCmd.PersistentFlags().StringVar(&localenvProfile, "profile", "", "Profile from localenv.yaml to use for localenv keys (default: the top-level configuration)")
+	configCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return validateProfileName(localenvProfile)
+	}
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// configCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	configViewCmd.Flags().StringP("output", "o", "text", "Output format: text, json or yaml")
+	configGetCmd.Flags().Bool("show-source", false, "Also print where the value came from")
 }
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// TestConfigCommand tests the config command and its helpers
+func TestConfigCommand(t *testing.T) {
+	defer func() { localenvProfile = "" }()
+
+	t.Run("Config subcommands should be registered", func(t *testing.T) {
+		names := []string{}
+		for _, cmd := range configCmd.Commands() {
+			names = append(names, cmd.Name())
+		}
+		assert.ElementsMatch(t, []string{"view", "get", "set", "unset", "path", "edit"}, names)
+	})
+
+	t.Run("Scalar values should keep their type", func(t *testing.T) {
+		assert.Equal(t, "!!int", configScalarNode("8080").ShortTag())
+		assert.Equal(t, "!!bool", configScalarNode("true").ShortTag())
+		assert.Equal(t, "!!str", configScalarNode("2.17.1").ShortTag())
+		assert.Equal(t, "!!str", configScalarNode("[unbalanced").ShortTag())
+	})
+
+	t.Run("Nodes should be set and removed by path", func(t *testing.T) {
+		root := &yamlv3.Node{}
+		assert.NoError(t, yamlv3.Unmarshal([]byte("components:\n  dapr:\n"), root))
+		root = root.Content[0]
+
+		assert.NoError(t, setConfigNode(root, "components.dapr.dashboardPort", configScalarNode("8081")))
+		assert.NoError(t, setConfigNode(root, "components.temporal.uiPort", configScalarNode("8234")))
+		assert.Equal(t, "8081", findConfigNode(root, "components.dapr.dashboardPort").Value)
+		assert.Equal(t, "8234", findConfigNode(root, "components.temporal.uiPort").Value)
+		assert.Error(t, setConfigNode(root, "components.temporal.uiPort.nested", configScalarNode("1")))
+
+		assert.True(t, removeConfigNode(root, "components.temporal.uiPort"))
+		assert.Nil(t, findConfigNode(root, "components.temporal"), "empty mappings should be removed")
+		assert.False(t, removeConfigNode(root, "components.temporal.uiPort"))
+	})
+
+	t.Run("Localenv keys should be matched ignoring case", func(t *testing.T) {
+		key, err := canonicalLocalenvKey("components.temporal.uiport")
+		assert.NoError(t, err)
+		assert.Equal(t, "components.temporal.uiPort", key)
+
+		_, err = canonicalLocalenvKey("components.temporal.uiPrt")
+		assert.ErrorContains(t, err, "did you mean")
+	})
+
+	t.Run("Localenv values should report their source", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "localenv.yaml")
+		os.WriteFile(configPath, []byte(profileConfig), 0644)
+
+		localenvProfile = "search-heavy"
+		values, err := localenvConfigValues(configPath)
+		assert.NoError(t, err)
+
+		sources := map[string]configValue{}
+		for _, value := range values {
+			sources[value.Key] = value
+		}
+		assert.Equal(t, configValue{"localenv.components.openSearch.port", 9201, "project file (profile search-heavy)"}, sources["localenv.components.openSearch.port"])
+		assert.Equal(t, configValue{"localenv.components.openSearch.dashboardPort", 5601, sourceProject}, sources["localenv.components.openSearch.dashboardPort"])
+		assert.Equal(t, configValue{"localenv.components.temporal.grpcPort", 7233, sourceDefault}, sources["localenv.components.temporal.grpcPort"])
+	})
+
+	t.Run("Setting a localenv key should validate the file", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "localenv.yaml")
+		os.WriteFile(configPath, []byte(profileConfig), 0644)
+		localenvProfile = ""
+
+		set := func(value string) error {
+			_, err := updateConfigFile("localenv.components.temporal.uiPort", configPath, func(root *yamlv3.Node, key string) error {
+				return setConfigNode(root, key, configScalarNode(value))
+			})
+			return err
+		}
+
+		assert.NoError(t, set("8234"))
+		config, _, err := loadLocalEnvConfig(configPath)
+		assert.NoError(t, err)
+		assert.Equal(t, 8234, config.Components.Temporal.UIPort)
+
+		assert.Error(t, set("not-a-port"))
+		config, _, err = loadLocalEnvConfig(configPath)
+		assert.NoError(t, err, "an invalid value should not be written")
+		assert.Equal(t, 8234, config.Components.Temporal.UIPort)
+	})
+
+	t.Run("Global values should report env overrides", func(t *testing.T) {
+		t.Setenv("DEVHELPER_REGISTRY", "example.com")
+		viper.SetEnvPrefix("DEVHELPER")
+		viper.AutomaticEnv()
+
+		value, err := lookupConfigValue("registry", "localenv.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, configValue{"registry", "example.com", sourceEnv}, value)
+
+		_, err = lookupConfigValue("not-set-anywhere", "localenv.yaml")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,589 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// bundleRedactKey is the global configuration key holding extra redaction patterns
+const bundleRedactKey = "bundle.redact"
+
+// redactedValue replaces secrets in support bundles
+const redactedValue = "[REDACTED]"
+
+// defaultRedactPatterns match the values of settings, environment variables
+// and headers that commonly hold secrets
+var defaultRedactPatterns = []string{
+	`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[\w.-]*["']?\s*[:=]\s*["']?([^\s"',}]+)`,
+	`(?i)authorization["']?\s*[:=]\s*["']?(?:bearer|basic)\s+([^\s"',}]+)`,
+	`://[^/\s:@]+:([^/\s@]+)@`,
+}
+
+// secretKeyPattern matches configuration keys whose values are redacted
+// from the effective configuration
+var secretKeyPattern = regexp.MustCompile(`(?i)password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential`)
+
+// bundleOptions controls what a support bundle contains
+type bundleOptions struct {
+	// LogBytes is how much of the end of each log is included
+	LogBytes int64
+	// Redact holds the secret patterns. Patterns with a capture group only
+	// redact the group.
+	Redact []*regexp.Regexp
+	// KeepEnv keeps the values of container environment variables, which
+	// are otherwise replaced because no pattern can tell which are secrets
+	KeepEnv bool
+}
+
+// supportBundle writes redacted files below a directory of a tar archive
+type supportBundle struct {
+	tar     *tar.Writer
+	root    string
+	redact  []*regexp.Regexp
+	created time.Time
+	files   []string
+	notes   []string
+}
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Export a support bundle with logs, configuration and diagnostics",
+	Long: `Collect what is needed to troubleshoot the local environment into a
+tar.gz archive that can be attached to a bug report or shared in chat:
+
+- localenv.yaml, the effective configuration, the config cache and state files
+- The end of every component and app log
+- '<runtime> ps -a' and 'inspect' output of the managed containers
+- Tool versions, 'localenv status' as JSON, 'localenv doctor' results and OS information
+
+Values that look like passwords, tokens, keys and credentials in URLs are
+replaced with [REDACTED], and so are the values of every container environment
+variable unless --keep-env is set. Add patterns with --redact or the bundle.redact list
+of the global configuration; a pattern with a capture group only redacts the
+group. Review the archive before sharing it.
+
+Examples:
+  devhelper-cli localenv bundle                          # Write devhelper-bundle-<time>.tar.gz
+  devhelper-cli localenv bundle -o /tmp/bundle.tar.gz --log-size 20
+  devhelper-cli localenv bundle --redact 'CUSTOMER_ID=(\S+)'
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		configPath, _ := cmd.Flags().GetString("config")
+		output, _ := cmd.Flags().GetString("output")
+		logSize, _ := cmd.Flags().GetInt("log-size")
+		redact, _ := cmd.Flags().GetStringArray("redact")
+		keepEnv, _ := cmd.Flags().GetBool("keep-env")
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+		created := time.Now()
+		if output == "" {
+			output = fmt.Sprintf("devhelper-bundle-%s.tar.gz", created.Format("20060102-150405"))
+		}
+
+		patterns, err := compileRedactPatterns(append(viper.GetStringSlice(bundleRedactKey), redact...))
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// A broken config is still worth bundling, the error is recorded by doctor
+		config, configLoaded, _ := loadLocalEnvConfig(configPath)
+		containerRuntime = selectContainerRuntime(config)
+		env := &LocalEnv{
+			Config:       config,
+			ConfigPath:   configPath,
+			ConfigLoaded: configLoaded,
+			Verbose:      verbose,
+			Skip:         map[string]bool{},
+			State:        loadLocalEnvState(),
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("❌ Failed to create %s: %v\n", output, err)
+			os.Exit(1)
+		}
+
+		fmt.Println("📦 Collecting support bundle...")
+		opts := bundleOptions{LogBytes: int64(logSize) * 1024 * 1024, Redact: patterns, KeepEnv: keepEnv}
+		bundle, err := writeSupportBundle(env, file, opts, created)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(output)
+			fmt.Printf("❌ Failed to write support bundle: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, note := range bundle.notes {
+			fmt.Printf("⚠️ %s\n", note)
+		}
+		fmt.Printf("✅ Support bundle written to %s (%d files)\n", output, len(bundle.files))
+		fmt.Println("   Secrets matching the redaction patterns were replaced, review the archive before sharing it.")
+	},
+}
+
+// compileRedactPatterns compiles the default and the given redaction patterns
+func compileRedactPatterns(extra []string) ([]*regexp.Regexp, error) {
+	patterns := []*regexp.Regexp{}
+	for _, pattern := range append(append([]string{}, defaultRedactPatterns...), extra...) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q is not a valid regular expression: %w", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// redactSecrets replaces what patterns match in data with redactedValue
+func redactSecrets(data []byte, patterns []*regexp.Regexp) []byte {
+	for _, pattern := range patterns {
+		matches := pattern.FindAllSubmatchIndex(data, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var redacted bytes.Buffer
+		last := 0
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if len(match) >= 4 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			redacted.Write(data[last:start])
+			redacted.WriteString(redactedValue)
+			last = end
+		}
+		redacted.Write(data[last:])
+		data = redacted.Bytes()
+	}
+	return data
+}
+
+// add writes a redacted file to the bundle
+func (b *supportBundle) add(name string, data []byte) error {
+	data = redactSecrets(data, b.redact)
+	header := &tar.Header{
+		Name:    b.root + "/" + name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: b.created,
+	}
+	if err := b.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := b.tar.Write(data); err != nil {
+		return err
+	}
+	b.files = append(b.files, name)
+	return nil
+}
+
+// note records something that couldn't be collected
+func (b *supportBundle) note(format string, args ...interface{}) {
+	b.notes = append(b.notes, fmt.Sprintf(format, args...))
+}
+
+// addFile adds a file from disk, noting when it can't be read
+func (b *supportBundle) addFile(name, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		b.note("%s: %v", path, err)
+		return nil
+	}
+	return b.add(name, data)
+}
+
+// addYAML adds a value encoded as YAML
+func (b *supportBundle) addYAML(name string, value interface{}) error {
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		b.note("%s: %v", name, err)
+		return nil
+	}
+	return b.add(name, buf.Bytes())
+}
+
+// writeSupportBundle writes the support bundle of env to out as a gzipped tar
+func writeSupportBundle(env *LocalEnv, out io.Writer, opts bundleOptions, created time.Time) (*supportBundle, error) {
+	gz := gzip.NewWriter(out)
+	bundle := &supportBundle{
+		tar:     tar.NewWriter(gz),
+		root:    "devhelper-bundle-" + created.Format("20060102-150405"),
+		redact:  opts.Redact,
+		created: created,
+	}
+
+	steps := []func(*LocalEnv, *supportBundle, bundleOptions) error{
+		bundleConfig,
+		bundleLogs,
+		bundleContainers,
+		bundleDiagnostics,
+	}
+	for _, step := range steps {
+		if err := step(env, bundle, opts); err != nil {
+			return bundle, err
+		}
+	}
+
+	// The summary lists what couldn't be collected
+	summary := fmt.Sprintf("devhelper-cli %s (commit %s)\nCreated: %s\nProfile: %s\nConfiguration: %s\n",
+		Version, Commit, created.Format(time.RFC3339), profileLabel(), env.ConfigPath)
+	if len(bundle.notes) > 0 {
+		summary += "\nNot collected:\n- " + strings.Join(bundle.notes, "\n- ") + "\n"
+	}
+	if err := bundle.add("README.txt", []byte(summary)); err != nil {
+		return bundle, err
+	}
+
+	if err := bundle.tar.Close(); err != nil {
+		return bundle, err
+	}
+	return bundle, gz.Close()
+}
+
+// bundleConfig adds localenv.yaml, the effective configuration and the cache and state files
+func bundleConfig(env *LocalEnv, bundle *supportBundle, opts bundleOptions) error {
+	if err := bundle.addFile("config/localenv.yaml", env.ConfigPath); err != nil {
+		return err
+	}
+
+	values := globalConfigValues()
+	localenvValues, err := localenvConfigValues(env.ConfigPath)
+	if err != nil {
+		bundle.note("effective localenv configuration: %v", err)
+	}
+	values = append(values, localenvValues...)
+	for i, value := range values {
+		if secretKeyPattern.MatchString(value.Key) {
+			values[i].Value = redactedValue
+		}
+	}
+	if err := bundle.addYAML("config/effective.yaml", values); err != nil {
+		return err
+	}
+
+	if err := bundle.addFile("config/config-cache.yaml", configCachePath()); err != nil {
+		return err
+	}
+	return bundle.addFile("config/state.yaml", stateFilePath())
+}
+
+// bundleLogs adds the end of the log files and of the managed containers' logs
+func bundleLogs(env *LocalEnv, bundle *supportBundle, opts bundleOptions) error {
+	logFiles, _ := filepath.Glob(filepath.Join(logsDir(), "*.log"))
+	sort.Strings(logFiles)
+	for _, logFile := range logFiles {
+		data, err := readLogTail(logFile, opts.LogBytes)
+		if err != nil {
+			bundle.note("%s: %v", logFile, err)
+			continue
+		}
+		if err := bundle.add("logs/"+filepath.Base(logFile), data); err != nil {
+			return err
+		}
+	}
+
+	for _, container := range bundleContainerNames(env) {
+		var output bytes.Buffer
+		if err := containerRuntime.Logs(env.ctx(), container, LogOptions{Timestamps: true}, &output, &output); err != nil {
+			bundle.note("logs of container %s: %v", container, err)
+			continue
+		}
+		data := output.Bytes()
+		if opts.LogBytes > 0 && int64(len(data)) > opts.LogBytes {
+			data = trimToLine(data[int64(len(data))-opts.LogBytes:])
+		}
+		if err := bundle.add("logs/"+container+".log", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLogTail returns the last maxBytes of a log file starting at a line,
+// or all of it when maxBytes is 0
+func readLogTail(path string, maxBytes int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 || info.Size() <= maxBytes {
+		return io.ReadAll(file)
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, info.Size()-maxBytes, maxBytes))
+	if err != nil {
+		return nil, err
+	}
+	return trimToLine(data), nil
+}
+
+// trimToLine drops the partial line at the start of data
+func trimToLine(data []byte) []byte {
+	if index := bytes.IndexByte(data, '\n'); index >= 0 {
+		return data[index+1:]
+	}
+	return data
+}
+
+// bundleContainerNames returns the existing containers of the enabled components
+func bundleContainerNames(env *LocalEnv) []string {
+	names := []string{}
+	for _, driver := range environmentComponents(env.Config) {
+		if !driver.Enabled(env) {
+			continue
+		}
+		for _, source := range driver.LogSources(env) {
+			if source.Container != "" && containerExists(env.ctx(), source.Container) {
+				names = append(names, source.Container)
+			}
+		}
+	}
+	return names
+}
+
+// bundleContainers adds the container list and the inspect output of the
+// managed containers, from the runtime CLI when it is installed
+func bundleContainers(env *LocalEnv, bundle *supportBundle, opts bundleOptions) error {
+	names := bundleContainerNames(env)
+	command := containerRuntime.Name()
+
+	if isCommandAvailable(command) {
+		output, err := exec.Command(command, "ps", "-a").CombinedOutput()
+		if err != nil {
+			bundle.note("%s ps -a: %v", command, err)
+		} else if err := bundle.add("containers/ps.txt", output); err != nil {
+			return err
+		}
+		for _, name := range names {
+			output, err := exec.Command(command, "inspect", name).CombinedOutput()
+			if err != nil {
+				bundle.note("%s inspect %s: %v", command, name, err)
+				continue
+			}
+			if !opts.KeepEnv {
+				if output, err = redactInspectEnv(output); err != nil {
+					bundle.note("%s inspect %s left out, its environment could not be redacted: %v", command, name, err)
+					continue
+				}
+			}
+			if err := bundle.add("containers/"+name+".json", output); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Without the CLI the runtime API still describes the containers
+	containers, err := containerRuntime.List(env.ctx(), "", true)
+	if err != nil {
+		bundle.note("%s containers: %v", containerRuntime.Label(), err)
+		return nil
+	}
+	if !opts.KeepEnv {
+		for i := range containers {
+			containers[i].Env = redactContainerEnv(containers[i].Env)
+		}
+	}
+	if err := bundleJSON(bundle, "containers/ps.json", containers); err != nil {
+		return err
+	}
+	for _, name := range names {
+		container, err := containerRuntime.Inspect(env.ctx(), name)
+		if err != nil {
+			bundle.note("inspect %s: %v", name, err)
+			continue
+		}
+		if !opts.KeepEnv {
+			container.Env = redactContainerEnv(container.Env)
+		}
+		if err := bundleJSON(bundle, "containers/"+name+".json", container); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactContainerEnv keeps only the names of environment variables
+func redactContainerEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	redacted := make([]string, len(env))
+	for i, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		redacted[i] = name + "=" + redactedValue
+	}
+	return redacted
+}
+
+// redactInspectEnv redacts the Config.Env values of '<runtime> inspect' output
+func redactInspectEnv(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var containers []map[string]interface{}
+	if err := decoder.Decode(&containers); err != nil {
+		return nil, err
+	}
+
+	for _, container := range containers {
+		config, ok := container["Config"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		variables, ok := config["Env"].([]interface{})
+		if !ok {
+			continue
+		}
+		env := make([]string, 0, len(variables))
+		for _, variable := range variables {
+			text, _ := variable.(string)
+			env = append(env, text)
+		}
+		config["Env"] = redactContainerEnv(env)
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(containers); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// bundleJSON adds a value encoded as indented JSON
+func bundleJSON(bundle *supportBundle, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		bundle.note("%s: %v", name, err)
+		return nil
+	}
+	return bundle.add(name, append(data, '\n'))
+}
+
+// bundleTool is a tool version recorded in a support bundle
+type bundleTool struct {
+	Name       string `yaml:"name"`
+	Path       string `yaml:"path,omitempty"`
+	Version    string `yaml:"version,omitempty"`
+	MinVersion string `yaml:"minVersion"`
+	Error      string `yaml:"error,omitempty"`
+}
+
+// bundleDiagnostics adds tool versions, the status, the doctor results and OS information
+func bundleDiagnostics(env *LocalEnv, bundle *supportBundle, opts bundleOptions) error {
+	commands := []string{}
+	for command := range requiredVersions {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	// Versions are detected without the prompts of validateToolWithVersionDetection
+	tools := []bundleTool{}
+	for _, command := range commands {
+		info := requiredVersions[command]
+		tool := bundleTool{Name: info.Name, MinVersion: info.MinVersion}
+		if !isCommandAvailable(command) {
+			tool.Error = "not found in PATH"
+		} else if path, err := exec.LookPath(command); err != nil {
+			tool.Error = err.Error()
+		} else {
+			tool.Path = path
+			if tool.Version, err = detectToolVersion(path, command, "--version", false); err != nil {
+				tool.Error = err.Error()
+			}
+		}
+		tools = append(tools, tool)
+	}
+	if err := bundle.addYAML("tools.yaml", tools); err != nil {
+		return err
+	}
+
+	if err := bundleJSON(bundle, "status.json", collectEnvironmentStatus(env, env.ConfigPath)); err != nil {
+		return err
+	}
+
+	var doctor strings.Builder
+	levels := map[checkLevel]string{checkPass: "PASS", checkSkip: "SKIP", checkWarn: "WARN", checkFail: "FAIL"}
+	for _, check := range doctorChecks {
+		result := check.Run(env)
+		fmt.Fprintf(&doctor, "%s %s: %s\n", levels[result.Level], check.Name, result.Message)
+		if result.Hint != "" && result.Level >= checkWarn {
+			fmt.Fprintf(&doctor, "     %s\n", result.Hint)
+		}
+	}
+	if err := bundle.add("doctor.txt", []byte(doctor.String())); err != nil {
+		return err
+	}
+
+	system := fmt.Sprintf("OS: %s\nArchitecture: %s\nCPUs: %d\nGo: %s\nContainer runtime: %s\n",
+		runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.Version(), containerRuntime.Label())
+	if runtime.GOOS != "windows" {
+		if output, err := exec.Command("uname", "-a").Output(); err == nil {
+			system += "Kernel: " + string(output)
+		}
+	}
+	return bundle.add("system.txt", []byte(system))
+}
+
+func init() {
+	localenvCmd.AddCommand(bundleCmd)
+
+	bundleCmd.Flags().StringP("config", "c", "", "Path to localenv configuration file")
+	bundleCmd.Flags().StringP("output", "o", "", "Path of the archive (default: devhelper-bundle-<time>.tar.gz)")
+	bundleCmd.Flags().Int("log-size", 5, "Megabytes from the end of each log to include (0 for all)")
+	bundleCmd.Flags().StringArray("redact", nil, "Additional regular expression of secrets to redact (repeatable)")
+	bundleCmd.Flags().Bool("keep-env", false, "Keep the values of container environment variables (redaction patterns still apply)")
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/test"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// readBundle returns the files of a support bundle by their path below its root
+func readBundle(t *testing.T, data []byte) map[string]string {
+	files := map[string]string{}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return files
+	}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+		content, _ := io.ReadAll(reader)
+		files[strings.SplitN(header.Name, "/", 2)[1]] = string(content)
+	}
+	return files
+}
+
+// TestSupportBundle tests collecting and redacting a support bundle
+func TestSupportBundle(t *testing.T) {
+	origCommandCheck := isCommandAvailable
+	origRuntime := containerRuntime
+	defer func() {
+		isCommandAvailable = origCommandCheck
+		containerRuntime = origRuntime
+	}()
+	isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+
+	t.Run("Secrets should be redacted", func(t *testing.T) {
+		patterns, err := compileRedactPatterns([]string{`CUSTOMER_ID=(\S+)`, `acme-[0-9]+`})
+		if !assert.NoError(t, err) {
+			return
+		}
+		input := strings.Join([]string{
+			"POSTGRES_PASSWORD=hunter2",
+			`{"apiKey": "abc123", "user": "bob"}`,
+			"    GITHUB_TOKEN: ghp_secret",
+			"Authorization: Bearer eyJhbGciOi",
+			"url: postgres://app:s3cret@db:5432/orders",
+			"CUSTOMER_ID=42 account acme-1234",
+			"level=info msg=started",
+		}, "\n")
+
+		redacted := string(redactSecrets([]byte(input), patterns))
+		for _, secret := range []string{"hunter2", "abc123", "ghp_secret", "eyJhbGciOi", "s3cret", "42", "acme-1234"} {
+			assert.NotContains(t, redacted, secret)
+		}
+		assert.Contains(t, redacted, "POSTGRES_PASSWORD=[REDACTED]")
+		assert.Contains(t, redacted, `"user": "bob"`)
+		assert.Contains(t, redacted, "postgres://app:[REDACTED]@db:5432/orders")
+		assert.Contains(t, redacted, "account [REDACTED]")
+		assert.Contains(t, redacted, "level=info msg=started")
+
+		_, err = compileRedactPatterns([]string{"[unclosed"})
+		assert.Error(t, err)
+	})
+
+	t.Run("The bundle should hold config, logs, containers and diagnostics", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		fake := newFakeRuntime()
+		containerRuntime = fake
+		fake.Run(context.Background(), ContainerSpec{Name: customContainerName("db"), Image: "postgres:16", Env: []string{"POSTGRES_PASSWORD=hunter2", "DATABASE_URL=postgres://u:p@orders-db:5432/orders"}})
+
+		configPath := filepath.Join(t.TempDir(), "localenv.yaml")
+		os.WriteFile(configPath, []byte(`components:
+  dapr:
+    enabled: false
+  temporal:
+    enabled: false
+  openSearch:
+    enabled: false
+  custom:
+    db:
+      enabled: true
+      image: postgres:16
+      env:
+        POSTGRES_PASSWORD: hunter2
+`), 0644)
+		config, loaded, err := loadLocalEnvConfig(configPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		os.MkdirAll(logsDir(), 0755)
+		var log strings.Builder
+		for i := 0; i < 1000; i++ {
+			log.WriteString("temporal log line\n")
+		}
+		os.WriteFile(filepath.Join(logsDir(), "temporal-server.log"), []byte(log.String()), 0644)
+
+		viper.Set("registry.token", "ghp_secret")
+		defer viper.Set("registry.token", nil)
+
+		env := &LocalEnv{Config: config, ConfigPath: configPath, ConfigLoaded: loaded, Skip: map[string]bool{}, State: loadLocalEnvState()}
+		patterns, _ := compileRedactPatterns(nil)
+		var out bytes.Buffer
+		created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		bundle, err := writeSupportBundle(env, &out, bundleOptions{LogBytes: 100, Redact: patterns}, created)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		files := readBundle(t, out.Bytes())
+		assert.ElementsMatch(t, bundle.files, keys(files))
+		for _, name := range []string{
+			"README.txt", "config/localenv.yaml", "config/effective.yaml", "logs/temporal-server.log",
+			"containers/ps.json", "containers/" + customContainerName("db") + ".json", "logs/" + customContainerName("db") + ".log", "tools.yaml", "status.json", "doctor.txt", "system.txt",
+		} {
+			assert.Contains(t, files, name)
+		}
+		for name, content := range files {
+			assert.NotContains(t, content, "hunter2", "secret in %s", name)
+			assert.NotContains(t, content, "ghp_secret", "secret in %s", name)
+		}
+
+		assert.Contains(t, files["config/effective.yaml"], "POSTGRES_PASSWORD: [REDACTED]")
+		for _, name := range []string{"containers/ps.json", "containers/" + customContainerName("db") + ".json"} {
+			assert.Contains(t, files[name], "DATABASE_URL=[REDACTED]", "every environment value should be redacted in %s", name)
+			assert.NotContains(t, files[name], "orders-db", "environment values should be redacted in %s", name)
+		}
+		assert.Contains(t, files["config/effective.yaml"], "key: registry.token\n  value: '[REDACTED]'")
+		assert.LessOrEqual(t, len(files["logs/temporal-server.log"]), 100)
+		assert.True(t, strings.HasPrefix(files["logs/temporal-server.log"], "temporal log line\n"))
+		assert.Contains(t, files["status.json"], `"schemaVersion": 1`)
+		assert.Contains(t, files["tools.yaml"], "not found in PATH")
+	})
+}
+
+// TestRedactInspectEnv tests redacting the environment of container inspect output
+func TestRedactInspectEnv(t *testing.T) {
+	t.Run("Every environment value should be redacted", func(t *testing.T) {
+		inspect := `[{"Id": "abc", "Config": {"Env": ["DATABASE_URL=postgres://u:p@orders-db/orders", "PATH=/usr/bin", "EMPTY="], "Image": "app<1>"}, "Size": 12345678901234567}]`
+
+		redacted, err := redactInspectEnv([]byte(inspect))
+		assert.NoError(t, err)
+		assert.Contains(t, string(redacted), `"DATABASE_URL=[REDACTED]"`)
+		assert.Contains(t, string(redacted), `"PATH=[REDACTED]"`)
+		assert.Contains(t, string(redacted), `"EMPTY=[REDACTED]"`)
+		assert.NotContains(t, string(redacted), "orders-db")
+		assert.Contains(t, string(redacted), `"Image": "app<1>"`, "other fields should be kept as they are")
+		assert.Contains(t, string(redacted), "12345678901234567", "numbers should not lose precision")
+	})
+
+	t.Run("Output that isn't inspect JSON should be rejected", func(t *testing.T) {
+		_, err := redactInspectEnv([]byte("Error: no such container"))
+		assert.Error(t, err)
+	})
+}
+
+// keys returns the keys of files
+func keys(files map[string]string) []string {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	return names
+}
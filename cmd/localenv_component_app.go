@@ -0,0 +1,395 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
+)
+
+// defaultAppStartTimeout is how long run waits for an app's sidecar to register
+const defaultAppStartTimeout = 30 * time.Second
+
+// appProtocols are the protocols dapr run can use to call an app
+var appProtocols = []string{"http", "grpc", "https", "grpcs", "h2c"}
+
+// AppConfig declares an application run under `dapr run` in the apps
+// section of localenv.yaml
+type AppConfig struct {
+	// AppID is the Dapr app ID (default: the app name)
+	AppID   string   `yaml:"appId,omitempty"`
+	Command []string `yaml:"command"`
+	// Dir is the working directory, relative to localenv.yaml
+	Dir         string            `yaml:"dir,omitempty"`
+	AppPort     int               `yaml:"appPort,omitempty"`
+	AppProtocol string            `yaml:"appProtocol,omitempty"` // See appProtocols
+	Env         map[string]string `yaml:"env,omitempty"`
+	Dapr        AppDaprConfig     `yaml:"dapr,omitempty"`
+	// Build is run in the app directory before the app is started and on
+	// every change in watch mode
+	Build []string       `yaml:"build,omitempty"`
+	Watch AppWatchConfig `yaml:"watch,omitempty"`
+	// DependsOn names components that must be running and apps started first
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// AppDaprConfig configures the Dapr sidecar of an app
+type AppDaprConfig struct {
+	HTTPPort int `yaml:"httpPort,omitempty"`
+	GRPCPort int `yaml:"grpcPort,omitempty"`
+	// Config is a Dapr configuration file, relative to localenv.yaml
+	Config   string `yaml:"config,omitempty"`
+	LogLevel string `yaml:"logLevel,omitempty"`
+}
+
+// appDriver manages an application declared in the apps section. Apps are
+// started by `localenv run` rather than `localenv start`, but stop, status
+// and logs handle them like components.
+type appDriver struct {
+	name   string
+	config AppConfig
+	// deps are the dependencies that are enabled, see environmentApps
+	deps []string
+}
+
+// environmentApps returns the drivers of the apps declared in config, sorted by name
+func environmentApps(config LocalEnvConfig) []ComponentDriver {
+	names := []string{}
+	for name := range config.Apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	drivers := []ComponentDriver{}
+	for _, name := range names {
+		app := config.Apps[name]
+		deps := []string{}
+		for _, dependency := range app.DependsOn {
+			if custom, ok := config.Components.Custom[dependency]; ok && !custom.Enabled {
+				continue
+			}
+			deps = append(deps, dependency)
+		}
+		drivers = append(drivers, appDriver{name: name, config: app, deps: deps})
+	}
+	return drivers
+}
+
+// appLogFile returns the log file of an app
+func appLogFile(name string) string {
+	return filepath.Join(logsDir(), "app-"+name+".log")
+}
+
+// resolveConfigPath resolves a path relative to the directory of localenv.yaml
+func resolveConfigPath(configPath, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	resolved := filepath.Join(filepath.Dir(configPath), path)
+	if abs, err := filepath.Abs(resolved); err == nil {
+		return abs
+	}
+	return resolved
+}
+
+// appID returns the Dapr app ID of the app
+func (d appDriver) appID() string {
+	if d.config.AppID != "" {
+		return d.config.AppID
+	}
+	return d.name
+}
+
+// runArgs returns the dapr arguments running the app with its sidecar
+func (d appDriver) runArgs(env *LocalEnv) []string {
+	args := []string{"run", "--app-id", d.appID()}
+	if d.config.AppPort != 0 {
+		args = append(args, "--app-port", strconv.Itoa(d.config.AppPort))
+	}
+	if d.config.AppProtocol != "" {
+		args = append(args, "--app-protocol", d.config.AppProtocol)
+	}
+	if d.config.Dapr.HTTPPort != 0 {
+		args = append(args, "--dapr-http-port", strconv.Itoa(d.config.Dapr.HTTPPort))
+	}
+	if d.config.Dapr.GRPCPort != 0 {
+		args = append(args, "--dapr-grpc-port", strconv.Itoa(d.config.Dapr.GRPCPort))
+	}
+	if d.config.Dapr.Config != "" {
+		args = append(args, "--config", resolveConfigPath(env.ConfigPath, d.config.Dapr.Config))
+	}
+	if d.config.Dapr.LogLevel != "" {
+		args = append(args, "--log-level", d.config.Dapr.LogLevel)
+	}
+
+	// Load the components installed by start, see installDaprComponents
+	if resources := daprResourcesDir(env.Config, env.ConfigPath); len(installedDaprComponents(resources)) > 0 {
+		args = append(args, "--resources-path", resources)
+	}
+	return append(append(args, "--"), d.config.Command...)
+}
+
+// dir returns the working directory of the app
+func (d appDriver) dir(env *LocalEnv) string {
+	if d.config.Dir == "" {
+		return resolveConfigPath(env.ConfigPath, ".")
+	}
+	return resolveConfigPath(env.ConfigPath, d.config.Dir)
+}
+
+// build runs the build command of the app, if any. Its output is shown and
+// appended to the app log.
+func (d appDriver) build(env *LocalEnv) error {
+	if len(d.config.Build) == 0 {
+		return nil
+	}
+	logFile, err := openRotatingWriter(appLogFile(d.Name()), globalLogRotation())
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	fmt.Printf("🔨 Building %s: %s\n", d.Name(), strings.Join(d.config.Build, " "))
+	buildCmd := exec.CommandContext(env.ctx(), d.config.Build[0], d.config.Build[1:]...)
+	buildCmd.Dir = d.dir(env)
+	buildCmd.Env = d.environ()
+	buildCmd.Stdout = io.MultiWriter(os.Stdout, logFile)
+	buildCmd.Stderr = io.MultiWriter(os.Stdout, logFile)
+	if err := buildCmd.Run(); err != nil {
+		return fmt.Errorf("build of %s failed: %w", d.Name(), err)
+	}
+	return nil
+}
+
+// environ returns the environment of the app process
+func (d appDriver) environ() []string {
+	environ := os.Environ()
+	keys := []string{}
+	for key := range d.config.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		environ = append(environ, key+"="+d.config.Env[key])
+	}
+	return environ
+}
+
+// sidecar returns the app as listed by `dapr list`
+func (d appDriver) sidecar(ctx context.Context) (daprApp, bool) {
+	apps, err := listDaprApps(ctx)
+	if err != nil {
+		return daprApp{}, false
+	}
+	index := slices.IndexFunc(apps, func(app daprApp) bool { return app.AppID == d.appID() })
+	if index < 0 {
+		return daprApp{}, false
+	}
+	return apps[index], true
+}
+
+func (d appDriver) Name() string { return d.name }
+
+func (d appDriver) Dependencies() []string { return d.deps }
+
+func (appDriver) Installed() bool { return isCommandAvailable("dapr") }
+
+func (appDriver) Enabled(env *LocalEnv) bool { return true }
+
+func (d appDriver) Health(env *LocalEnv) error {
+	if _, ok := d.sidecar(env.ctx()); !ok {
+		return fmt.Errorf("app %s is not listed by dapr list", d.appID())
+	}
+	return nil
+}
+
+// Readiness waits for the sidecar to be listed by dapr list and, if the app
+// declares its port, for the app to listen on it
+func (d appDriver) Readiness(env *LocalEnv) Readiness {
+	checks := []probe.Probe{probe.Func("dapr list", func(context.Context) error { return d.Health(env) })}
+	if d.config.AppPort != 0 {
+		checks = append(checks, probe.TCP{Address: fmt.Sprintf("localhost:%d", d.config.AppPort)})
+	}
+	return Readiness{
+		Probe:   probe.All(checks...),
+		Options: probe.Options{Timeout: defaultAppStartTimeout, Interval: time.Second, MaxInterval: 2 * time.Second},
+	}
+}
+
+func (d appDriver) Start(env *LocalEnv) error {
+	if len(env.State.runningPIDs(d.Name())) > 0 && d.Health(env) == nil {
+		fmt.Printf("✅ %s is already running, skipping startup.\n", d.Name())
+		return nil
+	}
+	// Stop what is left of a previous run, such as a sidecar whose app exited
+	if err := stopTrackedComponent(env, d.Name()); err != nil && !errors.Is(err, errComponentNotRunning) {
+		return err
+	}
+
+	logFilePath := appLogFile(d.Name())
+	logFile, err := openComponentLog(logFilePath)
+	if err != nil {
+		return err
+	}
+	// The child process keeps its own descriptor, so ours can be closed once started
+	defer logFile.Close()
+
+	args := d.runArgs(env)
+	if env.Verbose {
+		fmt.Printf("Running: dapr %s\n", strings.Join(args, " "))
+	}
+	// Not bound to env.ctx(): the app keeps running after start returns
+	runCmd := exec.Command("dapr", args...)
+	runCmd.Dir = d.dir(env)
+	runCmd.Env = d.environ()
+	runCmd.Stdout = logFile
+	runCmd.Stderr = logFile
+
+	// Run the app in its own process group so stop terminates the sidecar and the app together
+	detachProcess(runCmd)
+
+	if err := runCmd.Start(); err != nil {
+		fmt.Printf("❌ Failed to start %s: %v\n", d.Name(), err)
+		return err
+	}
+	pid := runCmd.Process.Pid
+	env.State.forget(d.Name())
+	if err := env.State.recordProcess(d.Name(), pid, processGroupID(pid)); err != nil {
+		fmt.Printf("⚠️ Warning: Could not record %s in state file: %v\n", d.Name(), err)
+	}
+
+	// Reap the process so an early exit can be told apart from a slow start
+	exited := make(chan error, 1)
+	go func() { exited <- runCmd.Wait() }()
+
+	fmt.Printf("⏳ Waiting for %s to register with Dapr...\n", d.Name())
+	err = waitReady(env, d, processRunning(d.Name(), exited))
+	switch {
+	case err == nil:
+		fmt.Printf("✅ %s started with app ID %s. Logs are written to %s\n", d.Name(), d.appID(), logFilePath)
+		return nil
+	case errors.Is(err, errExitedDuringStartup):
+		fmt.Printf("❌ %s exited during startup\n", d.Name())
+		fmt.Printf("   Check the logs at %s for details.\n", logFilePath)
+		if env.Verbose {
+			fmt.Printf("   %v\n", err)
+		}
+		env.State.forget(d.Name())
+		return fmt.Errorf("%s exited during startup", d.Name())
+	}
+
+	fmt.Printf("⚠️ %s is running but its sidecar hasn't registered yet. Check the logs at %s\n", d.Name(), logFilePath)
+	return nil
+}
+
+func (d appDriver) Stop(env *LocalEnv) error {
+	err := stopTrackedComponent(env, d.Name())
+	switch {
+	case errors.Is(err, errComponentNotRunning) && env.Aggressive:
+		if _, ok := d.sidecar(env.ctx()); !ok {
+			return errComponentNotRunning
+		}
+		if _, err := daprCLI(env.ctx(), "stop", "--app-id", d.appID()); err != nil {
+			fmt.Printf("❌ Failed to stop %s: %v\n", d.Name(), err)
+			return err
+		}
+	case errors.Is(err, errComponentNotRunning):
+		// Apps are only running after `localenv run`, so there is nothing to report
+		if env.Verbose {
+			fmt.Printf("ℹ️ %s is not running\n", d.Name())
+		}
+		return err
+	case err != nil:
+		fmt.Printf("❌ Failed to stop %s: %v\n", d.Name(), err)
+		return err
+	}
+	fmt.Printf("✅ %s stopped successfully.\n", d.Name())
+
+	if env.CleanLogs {
+		if err := removeLogFile(appLogFile(d.Name())); err == nil {
+			fmt.Printf("✅ Removed %s log file: %s\n", d.Name(), appLogFile(d.Name()))
+		}
+	}
+	return nil
+}
+
+func (d appDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: d.Name(), Enabled: true, Message: "Not running"}
+	status.PIDs = env.State.runningPIDs(d.Name())
+
+	sidecar, registered := d.sidecar(env.ctx())
+	if len(status.PIDs) == 0 && !registered {
+		return status
+	}
+
+	status.Running = true
+	status.Healthy = registered
+	status.Message = "Running"
+	if !registered {
+		status.Message = "Running, sidecar not registered"
+	}
+	if d.config.AppPort != 0 {
+		status.Ports = append(status.Ports, d.config.AppPort)
+	}
+	if registered {
+		status.Ports = append(status.Ports, sidecar.HTTPPort, sidecar.GRPCPort)
+		status.Endpoints = append(status.Endpoints, ComponentEndpoint{
+			Name:       "Dapr HTTP",
+			URL:        fmt.Sprintf("http://localhost:%d", sidecar.HTTPPort),
+			Accessible: true,
+		})
+	}
+	status.Details = append(status.Details, fmt.Sprintf("App ID: %s", d.appID()), "Logs: "+appLogFile(d.Name()))
+	return status
+}
+
+func (d appDriver) LogSources(env *LocalEnv) []LogSource {
+	return []LogSource{{Name: d.Name(), File: appLogFile(d.Name())}}
+}
+
+// daprApp is a running Dapr sidecar as listed by `dapr list -o json`
+type daprApp struct {
+	AppID    string `json:"appId"`
+	HTTPPort int    `json:"httpPort"`
+	GRPCPort int    `json:"grpcPort"`
+	AppPort  int    `json:"appPort"`
+}
+
+// listDaprApps returns the running Dapr sidecars
+func listDaprApps(ctx context.Context) ([]daprApp, error) {
+	output, err := daprCLI(ctx, "list", "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	apps := []daprApp{}
+	if err := json.Unmarshal(output, &apps); err != nil {
+		return nil, fmt.Errorf("failed to parse dapr list: %w", err)
+	}
+	return apps, nil
+}
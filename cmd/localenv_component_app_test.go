@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const appsConfig = `components:
+  temporal:
+    enabled: true
+  custom:
+    postgres:
+      enabled: true
+      image: postgres:16
+      ports: ["5432:5432"]
+apps:
+  orders:
+    command: [go, run, ./cmd/orders]
+    dir: services/orders
+    appPort: 8081
+    env:
+      LOG_LEVEL: debug
+    dapr:
+      httpPort: 3501
+      config: dapr/config.yaml
+    dependsOn: [temporal, postgres, payments]
+  payments:
+    appId: payments-svc
+    command: [./payments]
+    appPort: 8082
+    appProtocol: grpc
+profiles:
+  ci:
+    apps:
+      orders:
+        appPort: 18081
+`
+
+// TestApps tests apps declared in localenv.yaml and started by localenv run
+func TestApps(t *testing.T) {
+	defer func() { localenvProfile = "" }()
+	origCLI := daprCLI
+	defer func() { daprCLI = origCLI }()
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "localenv.yaml")
+	os.WriteFile(configPath, []byte(appsConfig), 0644)
+
+	localenvProfile = ""
+	config, _, err := loadLocalEnvConfig(configPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Apps should be started after the apps they depend on", func(t *testing.T) {
+		names, err := selectApps(config, []string{"orders"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"orders", "payments"}, names)
+		assert.Equal(t, []string{"postgres", "temporal"}, appComponentDependencies(config, names))
+
+		_, err = selectApps(config, []string{"billing"})
+		assert.EqualError(t, err, `unknown app "billing" (available: orders, payments)`)
+	})
+
+	t.Run("Apps should be stopped before the components they depend on", func(t *testing.T) {
+		sorted, err := sortComponents(append(environmentComponents(config), environmentApps(config)...))
+		assert.NoError(t, err)
+		names := driverNames(sorted)
+		assert.Less(t, indexOf(names, "postgres"), indexOf(names, "orders"))
+		assert.Less(t, indexOf(names, "payments"), indexOf(names, "orders"))
+
+		driver, ok := findEnvironmentComponent(config, "Orders")
+		assert.True(t, ok, "apps should be found like components")
+		assert.Equal(t, "orders", driver.Name())
+		assert.Contains(t, componentNames(config), "payments")
+	})
+
+	t.Run("Apps should run under dapr run", func(t *testing.T) {
+		env := &LocalEnv{Config: config, ConfigPath: configPath}
+		orders := appDriver{name: "orders", config: config.Apps["orders"]}
+		assert.Equal(t, []string{
+			"run", "--app-id", "orders", "--app-port", "8081", "--dapr-http-port", "3501",
+			"--config", filepath.Join(dir, "dapr", "config.yaml"),
+			"--", "go", "run", "./cmd/orders",
+		}, orders.runArgs(env))
+		assert.Contains(t, orders.environ(), "LOG_LEVEL=debug")
+
+		payments := appDriver{name: "payments", config: config.Apps["payments"]}
+		assert.Equal(t, []string{
+			"run", "--app-id", "payments-svc", "--app-port", "8082", "--app-protocol", "grpc", "--", "./payments",
+		}, payments.runArgs(env))
+	})
+
+	t.Run("App status should come from dapr list", func(t *testing.T) {
+		daprCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+			return []byte(`[{"appId":"payments-svc","httpPort":3502,"grpcPort":50002,"appPort":8082}]`), nil
+		}
+		env := &LocalEnv{Config: config, ConfigPath: configPath, State: &LocalEnvState{Components: map[string]*ComponentState{}}}
+
+		status := appDriver{name: "payments", config: config.Apps["payments"]}.Status(env)
+		assert.True(t, status.Running)
+		assert.Equal(t, []int{8082, 3502, 50002}, status.Ports)
+		assert.Equal(t, "http://localhost:3502", status.Endpoints[0].URL)
+		assert.Contains(t, status.Details, "Logs: "+appLogFile("payments"))
+
+		status = appDriver{name: "orders", config: config.Apps["orders"]}.Status(env)
+		assert.False(t, status.Running)
+		assert.Equal(t, "Not running", status.Message)
+	})
+
+	t.Run("Profiles should merge app settings", func(t *testing.T) {
+		localenvProfile = "ci"
+		config, _, err := loadLocalEnvConfig(configPath)
+		localenvProfile = ""
+		assert.NoError(t, err)
+		assert.Equal(t, 18081, config.Apps["orders"].AppPort)
+		assert.Equal(t, []string{"go", "run", "./cmd/orders"}, config.Apps["orders"].Command)
+	})
+
+	t.Run("Invalid apps should be reported", func(t *testing.T) {
+		data := "components:\n  temporal:\n    enabled: true\napps:\n  temporal:\n    command: [./worker]\n  orders:\n    appPort: 8233\n    appProtocol: tcp\n    dependsOn: [kafka]\n"
+		_, err := parseLocalEnvConfig(configPath, []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 5) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "apps.orders.command is required")
+		assert.Contains(t, errs[1].Message, `app "temporal" has the name of a component`)
+		assert.Contains(t, errs[2].Message, "port 8233 of apps.orders.appPort is already used by components.temporal.uiPort")
+		assert.Contains(t, errs[3].Message, `apps.orders.appProtocol "tcp" is not one of http, grpc, https, grpcs, h2c`)
+		assert.Contains(t, errs[4].Message, `apps.orders depends on unknown component or app "kafka"`)
+	})
+}
@@ -0,0 +1,396 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
+)
+
+const (
+	// customContainerPrefix prefixes the container names of custom components
+	customContainerPrefix = "localenv-"
+	// customNetwork is the network custom components join unless they set one
+	customNetwork = "localenv-network"
+	// defaultCustomHealthTimeout is how long start waits for a health check to pass
+	defaultCustomHealthTimeout = 60
+	// customSpecLabel labels custom containers with a hash of their spec, so
+	// start can tell whether a running container is up to date
+	customSpecLabel = "devhelper-cli.spec"
+)
+
+// CustomComponentConfig declares a container-backed service in the
+// components.custom section of localenv.yaml
+type CustomComponentConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Image   string `yaml:"image"`
+	// Ports are published as "hostPort:containerPort", or "port" for the same port on both sides
+	Ports []string          `yaml:"ports,omitempty"`
+	Env   map[string]string `yaml:"env,omitempty"`
+	// Volumes are "volume:/path" or "./dir:/path"; relative paths are resolved against localenv.yaml
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	Network     string            `yaml:"network,omitempty"`
+	Command     []string          `yaml:"command,omitempty"`
+	HealthCheck CustomHealthCheck `yaml:"healthCheck,omitempty"`
+	// DependsOn names built-in or custom components that must be started first
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+}
+
+// CustomHealthCheck decides when a custom component is ready. At most one
+// of HTTP, TCP and Command may be set; without any, a running container is healthy.
+type CustomHealthCheck struct {
+	HTTP    string `yaml:"http,omitempty"`    // URL that must answer with a status below 400
+	TCP     string `yaml:"tcp,omitempty"`     // "host:port" or "port" on localhost that must accept connections
+	Command string `yaml:"command,omitempty"` // Shell command run in the container that must exit with 0
+	Timeout int    `yaml:"timeout,omitempty"` // Seconds start waits for the check to pass (default 60)
+}
+
+// customComponentDriver manages a container declared in components.custom
+type customComponentDriver struct {
+	name   string
+	config CustomComponentConfig
+	// deps are the dependencies that are enabled or built in, see environmentComponents
+	deps []string
+}
+
+// environmentComponents returns the registered drivers followed by the
+// custom components declared in config, sorted by name
+func environmentComponents(config LocalEnvConfig) []ComponentDriver {
+	drivers := registeredComponents()
+
+	names := []string{}
+	for name := range config.Components.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		custom := config.Components.Custom[name]
+
+		// Disabled custom dependencies are ignored like disabled built-in
+		// ones, so only drop them here rather than failing the sort
+		deps := []string{}
+		for _, dependency := range custom.DependsOn {
+			if other, ok := config.Components.Custom[dependency]; ok && !other.Enabled {
+				continue
+			}
+			deps = append(deps, dependency)
+		}
+		drivers = append(drivers, customComponentDriver{name: name, config: custom, deps: deps})
+	}
+	return drivers
+}
+
+// findEnvironmentComponent looks up a built-in or custom component or an app by name
+func findEnvironmentComponent(config LocalEnvConfig, name string) (ComponentDriver, bool) {
+	if driver, ok := findComponent(name); ok {
+		return driver, true
+	}
+	for _, driver := range append(environmentComponents(config), environmentApps(config)...) {
+		if strings.EqualFold(driver.Name(), name) {
+			return driver, true
+		}
+	}
+	return nil, false
+}
+
+// parsePortMapping splits a "hostPort:containerPort" or "port" mapping
+func parsePortMapping(mapping string) (int, int, error) {
+	hostPart, containerPart, found := strings.Cut(mapping, ":")
+	if !found {
+		containerPart = hostPart
+	}
+
+	hostPort, err := strconv.Atoi(hostPart)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port mapping %q: use hostPort:containerPort", mapping)
+	}
+	containerPort, err := strconv.Atoi(strings.TrimSuffix(containerPart, "/tcp"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port mapping %q: use hostPort:containerPort", mapping)
+	}
+	return hostPort, containerPort, nil
+}
+
+// customContainerName returns the container name of a custom component in the active profile
+func customContainerName(name string) string {
+	return profileResourceName(customContainerPrefix + name)
+}
+
+func (d customComponentDriver) containerName() string { return customContainerName(d.name) }
+
+// networkName returns the network the container joins in the active profile
+func (d customComponentDriver) networkName() string {
+	if d.config.Network != "" {
+		return profileResourceName(d.config.Network)
+	}
+	return profileResourceName(customNetwork)
+}
+
+// hostPorts returns the published host ports
+func (d customComponentDriver) hostPorts() []int {
+	ports := []int{}
+	for _, mapping := range d.config.Ports {
+		if hostPort, _, err := parsePortMapping(mapping); err == nil {
+			ports = append(ports, hostPort)
+		}
+	}
+	return ports
+}
+
+func (d customComponentDriver) Name() string { return d.name }
+
+func (d customComponentDriver) Dependencies() []string { return d.deps }
+
+func (customComponentDriver) Installed() bool { return containerRuntime.Available() }
+
+func (d customComponentDriver) Enabled(env *LocalEnv) bool { return d.config.Enabled }
+
+// containerSpec returns the container to run
+func (d customComponentDriver) containerSpec(env *LocalEnv) ContainerSpec {
+	spec := ContainerSpec{
+		Name:           d.containerName(),
+		Image:          d.config.Image,
+		Command:        d.config.Command,
+		Network:        d.networkName(),
+		NetworkAliases: []string{d.name},
+		Restart:        "unless-stopped",
+	}
+
+	for _, mapping := range d.config.Ports {
+		hostPort, containerPort, err := parsePortMapping(mapping)
+		if err == nil {
+			spec.Ports = append(spec.Ports, fmt.Sprintf("%d:%d", hostPort, containerPort))
+		}
+	}
+
+	keys := []string{}
+	for key := range d.config.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		spec.Env = append(spec.Env, key+"="+d.config.Env[key])
+	}
+
+	for _, volume := range d.config.Volumes {
+		source, target, found := strings.Cut(volume, ":")
+		if found && (strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")) {
+			source = filepath.Join(filepath.Dir(env.ConfigPath), source)
+			if abs, err := filepath.Abs(source); err == nil {
+				source = abs
+			}
+			volume = source + ":" + target
+		}
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+	spec.Labels = map[string]string{customSpecLabel: specHash(spec)}
+	return spec
+}
+
+// specHash returns a hash identifying everything a container is created with
+func specHash(spec ContainerSpec) string {
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// upToDate reports whether the container recorded for the component is
+// running, was created from spec and passes its health checks, so start can
+// leave it running with its state
+func (d customComponentDriver) upToDate(env *LocalEnv, spec ContainerSpec) bool {
+	recorded, ok := env.State.component(d.Name())
+	if !ok || env.ForceRestart {
+		return false
+	}
+	container, err := containerRuntime.Inspect(env.ctx(), d.containerName())
+	if err != nil || !container.Running || !slices.Contains(recorded.Containers, container.ID) ||
+		container.Labels[customSpecLabel] != spec.Labels[customSpecLabel] {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(env.ctx(), 5*time.Second)
+	defer cancel()
+	err = d.Readiness(env).Probe.Check(ctx)
+	if err != nil && env.Verbose {
+		fmt.Printf("%s is running but not healthy: %v\n", d.name, err)
+	}
+	return err == nil
+}
+
+// healthProbe returns the probe of the configured health check, or nil without one
+func (d customComponentDriver) healthProbe() probe.Probe {
+	check := d.config.HealthCheck
+	switch {
+	case check.HTTP != "":
+		return probe.HTTP{URL: check.HTTP}
+	case check.TCP != "":
+		address := check.TCP
+		if !strings.Contains(address, ":") {
+			address = "localhost:" + address
+		}
+		return probe.TCP{Address: address}
+	case check.Command != "":
+		return probe.Func(check.Command, func(ctx context.Context) error {
+			output, err := containerRuntime.Exec(ctx, d.containerName(), "sh", "-c", check.Command)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// checkHealth runs the configured health check once
+func (d customComponentDriver) checkHealth(ctx context.Context) error {
+	check := d.healthProbe()
+	if check == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return check.Check(ctx)
+}
+
+// Readiness waits for the container to run, and to be healthy if its image
+// declares a health check, then for the configured health check to pass
+func (d customComponentDriver) Readiness(env *LocalEnv) Readiness {
+	timeout := d.config.HealthCheck.Timeout
+	if timeout <= 0 {
+		timeout = defaultCustomHealthTimeout
+	}
+	readiness := Readiness{
+		Probe:   containerProbe(d.containerName()),
+		Options: probe.Options{Timeout: time.Duration(timeout) * time.Second},
+	}
+	if check := d.healthProbe(); check != nil {
+		readiness.Probe = probe.All(readiness.Probe, check)
+	}
+	return readiness
+}
+
+func (d customComponentDriver) Health(env *LocalEnv) error {
+	if !isContainerRunning(env.ctx(), d.containerName()) {
+		return errComponentNotRunning
+	}
+	return d.checkHealth(env.ctx())
+}
+
+func (d customComponentDriver) Start(env *LocalEnv) error {
+	if err := containerRuntime.CreateNetwork(env.ctx(), d.networkName()); err != nil {
+		fmt.Printf("❌ Failed to create %s: %v\n", d.networkName(), err)
+		return err
+	}
+
+	spec := d.containerSpec(env)
+	if d.upToDate(env, spec) {
+		fmt.Printf("✅ %s is already running with current configuration, skipping startup.\n", d.name)
+		return nil
+	}
+
+	if err := removeExistingContainer(env.ctx(), d.containerName(), d.name, env.Verbose); err != nil {
+		return err
+	}
+
+	// Fail before creating the container instead of leaving one that can't start
+	for _, port := range d.hostPorts() {
+		if isPortInUse(port) {
+			fmt.Printf("❌ %s port %d is already in use by another process\n", d.name, port)
+			fmt.Printf("   Run 'lsof -i :%d' to see which process is using it\n", port)
+			fmt.Println("   Update its ports in localenv.yaml to a different value and try again.")
+			return fmt.Errorf("port %d is already in use", port)
+		}
+	}
+
+	containerID, err := runContainer(env.ctx(), d.name, spec, env.Verbose)
+	if err != nil {
+		return err
+	}
+	env.State.forget(d.Name())
+	if err := env.State.recordContainer(d.Name(), containerID); err != nil {
+		fmt.Printf("⚠️ Warning: Could not record %s in state file: %v\n", d.name, err)
+	}
+
+	fmt.Printf("⏳ Waiting for %s container to start...\n", d.name)
+	if !waitForContainer(env.ctx(), d.containerName(), d.name) {
+		fmt.Printf("❌ %s container failed to start\n", d.name)
+		if env.Verbose {
+			printContainerLogs(env.ctx(), d.containerName(), d.name)
+		}
+		return fmt.Errorf("%s container failed to start", d.name)
+	}
+
+	fmt.Printf("⏳ Waiting for %s to be ready...\n", d.name)
+	err = waitReady(env, d)
+	if err == nil {
+		fmt.Printf("✅ %s is running and ready\n", d.name)
+		return nil
+	}
+
+	fmt.Printf("❌ %s did not become ready: %v\n", d.name, err)
+	if env.Verbose {
+		printContainerLogs(env.ctx(), d.containerName(), d.name)
+	}
+	return errors.New(d.name + " did not become ready")
+}
+
+func (d customComponentDriver) Stop(env *LocalEnv) error {
+	fmt.Printf("Stopping %s...\n", d.name)
+	return stopContainerComponent(env, d.Name(), d.containerName(), d.name)
+}
+
+func (d customComponentDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: d.Name(), Enabled: d.Enabled(env)}
+
+	if !isContainerRunning(env.ctx(), d.containerName()) {
+		status.Message = "Not running"
+		status.Details = append(status.Details, fmt.Sprintf("Run 'devhelper-cli localenv start' to start %s", d.name))
+		return status
+	}
+
+	status.Running = true
+	status.Message = "Running"
+	status.Containers = append(status.Containers, d.containerName())
+	status.Ports = append(status.Ports, d.hostPorts()...)
+	status.Details = append(status.Details, "Image: "+d.config.Image)
+
+	err := d.checkHealth(env.ctx())
+	status.Healthy = err == nil
+	if err != nil {
+		status.Details = append(status.Details, fmt.Sprintf("Health check failed: %v", err))
+	}
+	if d.config.HealthCheck.HTTP != "" {
+		status.Endpoints = append(status.Endpoints, ComponentEndpoint{Name: "Health", URL: d.config.HealthCheck.HTTP, Accessible: status.Healthy})
+	}
+	return status
+}
+
+func (d customComponentDriver) LogSources(env *LocalEnv) []LogSource {
+	return []LogSource{{Name: d.name, Container: d.containerName()}}
+}
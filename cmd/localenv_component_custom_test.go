@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lirtsman/devhelper-cli/internal/procnet"
+)
+
+const customConfig = `components:
+  custom:
+    postgres:
+      enabled: true
+      image: postgres:16
+      ports: ["5432:5432"]
+      env:
+        POSTGRES_PASSWORD: secret
+      volumes: ["pgdata:/var/lib/postgresql/data", "./init:/docker-entrypoint-initdb.d"]
+      healthCheck:
+        command: pg_isready -U postgres
+    keycloak:
+      enabled: true
+      image: quay.io/keycloak/keycloak:25.0
+      ports: ["8180:8080"]
+      command: ["start-dev"]
+      healthCheck:
+        http: http://localhost:8180/health/ready
+      dependsOn: [postgres, redis]
+    redis:
+      enabled: false
+      image: redis:7
+profiles:
+  ci:
+    components:
+      custom:
+        postgres:
+          ports: ["15432:5432"]
+`
+
+// TestCustomComponents tests container-backed components declared in localenv.yaml
+func TestCustomComponents(t *testing.T) {
+	defer func() { localenvProfile = "" }()
+	configPath := filepath.Join(t.TempDir(), "localenv.yaml")
+	os.WriteFile(configPath, []byte(customConfig), 0644)
+
+	localenvProfile = ""
+	config, _, err := loadLocalEnvConfig(configPath)
+	assert.NoError(t, err)
+
+	t.Run("Custom components should follow the built-in components", func(t *testing.T) {
+		names := driverNames(environmentComponents(config))
+		assert.Equal(t, []string{"Dapr", "DaprDashboard", "Temporal", "OpenSearch", "OpenSearchDashboard", "keycloak", "postgres", "redis"}, names)
+
+		driver, ok := findEnvironmentComponent(config, "Postgres")
+		assert.True(t, ok, "custom components should be found ignoring case")
+		assert.Equal(t, "postgres", driver.Name())
+	})
+
+	t.Run("Disabled custom dependencies should be ignored", func(t *testing.T) {
+		driver, _ := findEnvironmentComponent(config, "keycloak")
+		assert.Equal(t, []string{"postgres"}, driver.Dependencies())
+
+		env := &LocalEnv{Config: config}
+		enabled := []ComponentDriver{}
+		for _, driver := range environmentComponents(config)[5:] {
+			if driver.Enabled(env) {
+				enabled = append(enabled, driver)
+			}
+		}
+		sorted, err := sortComponents(enabled)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"postgres", "keycloak"}, driverNames(sorted))
+	})
+
+	t.Run("Container should be built from the configuration", func(t *testing.T) {
+		driver, _ := findEnvironmentComponent(config, "postgres")
+		env := &LocalEnv{Config: config, ConfigPath: configPath}
+		spec := driver.(customComponentDriver).containerSpec(env)
+
+		assert.Equal(t, "localenv-postgres", spec.Name)
+		assert.Equal(t, "postgres:16", spec.Image)
+		assert.Equal(t, []string{"5432:5432"}, spec.Ports)
+		assert.Equal(t, []string{"POSTGRES_PASSWORD=secret"}, spec.Env)
+		assert.Equal(t, []string{"pgdata:/var/lib/postgresql/data", filepath.Join(filepath.Dir(configPath), "init") + ":/docker-entrypoint-initdb.d"}, spec.Volumes)
+		assert.Equal(t, []string{"postgres"}, spec.NetworkAliases)
+
+		keycloak, _ := findEnvironmentComponent(config, "keycloak")
+		spec = keycloak.(customComponentDriver).containerSpec(env)
+		assert.Equal(t, []string{"start-dev"}, spec.Command)
+	})
+
+	t.Run("Profiles should merge custom components", func(t *testing.T) {
+		localenvProfile = "ci"
+		defer func() { localenvProfile = "" }()
+
+		ciConfig, _, err := loadLocalEnvConfig(configPath)
+		assert.NoError(t, err)
+		postgres := ciConfig.Components.Custom["postgres"]
+		assert.Equal(t, []string{"15432:5432"}, postgres.Ports)
+		assert.Equal(t, "postgres:16", postgres.Image, "settings the profile doesn't mention should be kept")
+		assert.Equal(t, "localenv-postgres-ci", customContainerName("postgres"))
+	})
+
+	t.Run("Invalid custom components should be reported", func(t *testing.T) {
+		data := `components:
+  openSearch:
+    enabled: true
+    version: "2.17.1"
+    port: 9200
+  custom:
+    Bad_Name:
+      enabled: true
+    cache:
+      enabled: true
+      image: redis:7
+      ports: ["9200:6379", "x:1"]
+      dependsOn: [missing]
+      healthCheck:
+        tcp: "6379"
+        http: ftp://localhost
+`
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		messages := []string{}
+		for _, configErr := range errs {
+			messages = append(messages, configErr.Message)
+		}
+		assert.Len(t, messages, 7)
+		assert.Contains(t, messages, `invalid custom component name "Bad_Name": use lowercase letters, digits and dashes`)
+		assert.Contains(t, messages, "components.custom.Bad_Name.image is required when the component is enabled")
+		assert.Contains(t, messages, `components.custom.cache.ports.1: invalid port mapping "x:1": use hostPort:containerPort`)
+		assert.Contains(t, messages, `components.custom.cache depends on unknown component "missing"`)
+		assert.Contains(t, messages, "port 9200 of components.custom.cache.ports.0 is already used by components.openSearch.port")
+		assert.Contains(t, messages, "components.custom.cache.healthCheck must set only one of http, tcp and command")
+		assert.Contains(t, messages, `components.custom.cache.healthCheck.http "ftp://localhost" is not an http(s) URL`)
+	})
+
+	t.Run("TCP health check should connect to the port", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+
+		port := listener.Addr().(*net.TCPAddr).Port
+		driver := customComponentDriver{name: "svc", config: CustomComponentConfig{HealthCheck: CustomHealthCheck{TCP: "127.0.0.1:" + strconv.Itoa(port)}}}
+		assert.NoError(t, driver.checkHealth(context.Background()))
+
+		listener.Close()
+		assert.Error(t, driver.checkHealth(context.Background()))
+	})
+
+	t.Run("Start should keep an up-to-date container and check ports first", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		origRuntime, origProber := containerRuntime, prober
+		defer func() { containerRuntime, prober = origRuntime, origProber }()
+		fake := newFakeRuntime()
+		containerRuntime = fake
+		prober = &procnet.Fake{}
+
+		driver := customComponentDriver{name: "cache", config: CustomComponentConfig{Enabled: true, Image: "redis:7", Ports: []string{"16379:6379"}}}
+		env := &LocalEnv{Config: config, ConfigPath: configPath, State: loadLocalEnvState(), Skip: map[string]bool{}}
+
+		assert.NoError(t, driver.Start(env))
+		assert.Len(t, fake.specs, 1)
+		assert.NotEmpty(t, fake.specs[0].Labels[customSpecLabel], "the container should record the spec it was created from")
+
+		assert.NoError(t, driver.Start(env))
+		assert.Len(t, fake.specs, 1, "a running container with the same spec should be kept")
+
+		driver.config.Env = map[string]string{"DEBUG": "1"}
+		assert.NoError(t, driver.Start(env))
+		assert.Len(t, fake.specs, 2, "a changed spec should recreate the container")
+
+		env.ForceRestart = true
+		assert.NoError(t, driver.Start(env))
+		assert.Len(t, fake.specs, 3, "a forced restart should recreate the container")
+
+		prober = &procnet.Fake{Listening: map[int][]int{16379: {7}}}
+		err := driver.Start(env)
+		assert.ErrorContains(t, err, "port 16379 is already in use")
+		assert.Len(t, fake.specs, 3, "no container should be created when a port is taken")
+	})
+}
@@ -0,0 +1,546 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
+)
+
+// daprDashboardLogFile returns the Dapr Dashboard log file of the active profile
+func daprDashboardLogFile() string {
+	return filepath.Join(logsDir(), "dapr-dashboard.log")
+}
+
+// daprDriver manages the Dapr runtime initialized with `dapr init`
+type daprDriver struct{}
+
+// daprDashboardDriver manages the Dapr Dashboard process
+type daprDashboardDriver struct{}
+
+func (daprDriver) Name() string { return "Dapr" }
+
+func (daprDriver) Dependencies() []string { return nil }
+
+func (daprDriver) Installed() bool { return isCommandAvailable("dapr") }
+
+func (daprDriver) Enabled(env *LocalEnv) bool {
+	// Skip flags take precedence over the configuration file
+	return !env.Skip["dapr"] && getDaprRequirement(env.ConfigLoaded, env.Config.Components.Dapr.Enabled, env.Skip["dapr"])
+}
+
+func (d daprDriver) Health(env *LocalEnv) error {
+	// For self-hosted mode, we just check if `dapr list` works
+	// The `dapr status` command requires -k which is for Kubernetes
+	listCmd := exec.CommandContext(env.ctx(), "dapr", "list")
+	if output, err := listCmd.CombinedOutput(); err != nil {
+		if env.Verbose && len(output) > 0 {
+			fmt.Printf("Dapr check output: %s\n", strings.TrimSpace(string(output)))
+		}
+		return fmt.Errorf("dapr list failed: %w", err)
+	}
+
+	// Also verify the Dapr binaries are installed
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".dapr", "bin", "daprd")); err != nil {
+		return fmt.Errorf("dapr binaries not found: %w", err)
+	}
+
+	// If the command succeeds and binaries exist, we consider Dapr initialized
+	return nil
+}
+
+// installComponents installs the Dapr components declared in localenv.yaml
+func (daprDriver) installComponents(env *LocalEnv) error {
+	if !env.ConfigLoaded {
+		return nil
+	}
+	dir, err := installDaprComponents(env.Config, env.ConfigPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to install Dapr components: %v\n", err)
+		return err
+	}
+	if len(env.Config.Components.Dapr.Components) > 0 {
+		fmt.Printf("✅ Installed %d Dapr component(s) to %s\n", len(env.Config.Components.Dapr.Components), dir)
+		fmt.Printf("💡 Run apps with 'dapr run --resources-path %s' to load them\n", dir)
+	}
+	return nil
+}
+
+func (d daprDriver) Start(env *LocalEnv) error {
+	if err := d.installComponents(env); err != nil {
+		return err
+	}
+
+	// First check if Dapr is already running
+	if err := d.Health(env); err == nil {
+		fmt.Println("✅ Dapr is already running, skipping initialization.")
+
+		// The runtime is shared with other profiles; record that this
+		// profile uses it too so stopping one profile doesn't remove it
+		if len(otherProfilesUsing(d.Name())) > 0 {
+			d.recordContainers(env)
+		}
+		return nil
+	} else if env.Verbose {
+		fmt.Printf("Dapr check failed: %v\n", err)
+	}
+
+	// Dapr starts its Redis, Zipkin, placement and scheduler containers with our runtime
+	initCmd := exec.CommandContext(env.ctx(), "dapr", "init", "--container-runtime", containerRuntime.Name())
+	initOutput, err := initCmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize Dapr: %v\n", err)
+		if env.Verbose {
+			fmt.Printf("Output: %s\n", string(initOutput))
+		}
+		return err
+	}
+
+	if env.Verbose {
+		fmt.Printf("Dapr initialization output: %s\n", string(initOutput))
+	}
+
+	// Record the containers created by dapr init
+	d.recordContainers(env)
+
+	if err := waitReady(env, d); err == nil {
+		fmt.Println("✅ Dapr started successfully.")
+	} else {
+		// Consider it running anyway, the runtime can take a moment to settle
+		fmt.Println("⚠️ Dapr initialization completed, but the runtime may not be fully ready.")
+		if env.Verbose {
+			fmt.Printf("Dapr readiness check failed: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// Readiness waits for the placement service started by dapr init and for
+// the CLI to answer
+func (d daprDriver) Readiness(env *LocalEnv) Readiness {
+	return Readiness{
+		Probe: probe.All(
+			containerProbe("dapr_placement"),
+			probe.Command{Name: "dapr", Args: []string{"list"}},
+		),
+		Options: probe.Options{Timeout: 15 * time.Second},
+	}
+}
+
+// recordContainers records the Dapr runtime containers in the state
+func (d daprDriver) recordContainers(env *LocalEnv) {
+	env.State.forget(d.Name())
+	for _, containerID := range listContainerIDs(env.ctx(), "dapr_") {
+		if err := env.State.recordContainer(d.Name(), containerID); err != nil && env.Verbose {
+			fmt.Printf("⚠️ Failed to update state file: %v\n", err)
+		}
+	}
+}
+
+func (d daprDriver) Stop(env *LocalEnv) error {
+	fmt.Println("Stopping Dapr...")
+
+	// Leave a Dapr installed outside of localenv alone
+	if _, ok := env.State.component(d.Name()); !ok && !env.Aggressive {
+		printUntrackedHint("Dapr")
+		return errComponentNotRunning
+	}
+
+	// The Dapr runtime is machine-wide, so leave it to the last profile using it
+	if users := otherProfilesUsing(d.Name()); len(users) > 0 {
+		env.State.forget(d.Name())
+		fmt.Printf("ℹ️ Dapr is still used by profile(s) %s, leaving it running.\n", strings.Join(users, ", "))
+		return nil
+	}
+
+	// Check if any Dapr apps are running
+	listCmd := exec.CommandContext(env.ctx(), "dapr", "list")
+	listOutput, _ := listCmd.Output()
+
+	if len(listOutput) > 0 && !strings.Contains(string(listOutput), "No Dapr instances found") && env.Verbose {
+		// Uninstalling stops every running app, so we only report them here
+		fmt.Println("Stopping running Dapr applications...")
+		fmt.Println(string(listOutput))
+	}
+
+	// Run the dapr uninstall command
+	uninstallCmd := exec.CommandContext(env.ctx(), "dapr", "uninstall", "--all", "--container-runtime", containerRuntime.Name())
+	uninstallOutput, err := uninstallCmd.CombinedOutput()
+	outputStr := string(uninstallOutput)
+
+	// Check for success despite Docker-related errors
+	usingPodman := containerRuntime.Name() == "podman"
+	success := err == nil ||
+		(strings.Contains(outputStr, "Error removing Dapr") &&
+			strings.Contains(outputStr, "docker") &&
+			usingPodman)
+
+	if !success {
+		fmt.Printf("❌ Failed to stop Dapr: %v\n", err)
+		if env.Verbose {
+			fmt.Printf("Output: %s\n", outputStr)
+		}
+		return err
+	}
+
+	fmt.Println("✅ Dapr stopped successfully.")
+	env.State.forget(d.Name())
+
+	// If there were Docker-related warnings but we're using Podman, add a clarification
+	if strings.Contains(outputStr, "docker") && usingPodman {
+		fmt.Println("   (Docker-related warnings can be ignored when using Podman)")
+	}
+
+	if env.Verbose {
+		fmt.Printf("Output: %s\n", outputStr)
+	}
+	return nil
+}
+
+func (d daprDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: d.Name(), Enabled: d.Enabled(env)}
+
+	// Check if Dapr binaries exist
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".dapr", "bin", "daprd")); err != nil {
+		status.Message = "Not initialized"
+		status.Details = append(status.Details, "Run 'devhelper-cli localenv start' to initialize Dapr")
+		return status
+	}
+
+	// Check if we can run dapr list
+	listCmd := exec.CommandContext(env.ctx(), "dapr", "list")
+	output, err := listCmd.CombinedOutput()
+	if err != nil {
+		status.Message = "Not running properly"
+		if env.Verbose && len(output) > 0 {
+			status.Details = append(status.Details, "Details: "+strings.TrimSpace(string(output)))
+		}
+		return status
+	}
+
+	status.Running = true
+	status.Healthy = true
+	status.Message = "Running"
+	status.Endpoints = append(status.Endpoints, ComponentEndpoint{
+		Name:       "Zipkin UI (tracing)",
+		URL:        getZipkinURL(env.ConfigLoaded, env.Config),
+		Accessible: true,
+	})
+
+	status.Ports = append(status.Ports, getZipkinPort(env.ConfigLoaded, env.Config))
+
+	// List the Dapr service containers started by dapr init
+	containers, err := containerRuntime.List(env.ctx(), "dapr_", false)
+	if err == nil {
+		for _, container := range containers {
+			status.Containers = append(status.Containers, container.Name)
+		}
+	}
+
+	// List the installed components and the ones each running app has loaded
+	if env.ConfigLoaded {
+		dir := daprResourcesDir(env.Config, env.ConfigPath)
+		if installed := installedDaprComponents(dir); len(installed) > 0 {
+			status.Details = append(status.Details, fmt.Sprintf("Components in %s: %s", dir, formatDaprComponents(installed)))
+		}
+	}
+	loaded, err := loadedDaprComponents()
+	if err == nil {
+		apps := []string{}
+		for app := range loaded {
+			apps = append(apps, app)
+		}
+		sort.Strings(apps)
+		for _, app := range apps {
+			status.Details = append(status.Details, fmt.Sprintf("App %s loaded: %s", app, formatDaprComponents(loaded[app])))
+		}
+	}
+	return status
+}
+
+// formatDaprComponents formats components as "name (type)" for status details
+func formatDaprComponents(components []DaprComponentInfo) string {
+	if len(components) == 0 {
+		return "none"
+	}
+	names := []string{}
+	for _, component := range components {
+		names = append(names, fmt.Sprintf("%s (%s)", component.Name, component.Type))
+	}
+	return strings.Join(names, ", ")
+}
+
+// LogSources returns the Dapr service containers started by dapr init
+func (daprDriver) LogSources(env *LocalEnv) []LogSource {
+	sources := []LogSource{}
+	containers, err := containerRuntime.List(env.ctx(), "dapr_", false)
+	if err != nil {
+		return sources
+	}
+	for _, container := range containers {
+		sources = append(sources, LogSource{Name: container.Name, Container: container.Name})
+	}
+	return sources
+}
+
+func (daprDashboardDriver) Name() string { return "DaprDashboard" }
+
+func (daprDashboardDriver) Aliases() []string { return []string{"dapr-dashboard"} }
+
+// The dashboard reads component state from the Dapr runtime
+func (daprDashboardDriver) Dependencies() []string { return []string{"Dapr"} }
+
+func (daprDashboardDriver) Installed() bool {
+	return isCommandAvailable("dapr") && isDaprDashboardAvailable()
+}
+
+func (daprDashboardDriver) Enabled(env *LocalEnv) bool {
+	// Skip flags take precedence over the configuration file
+	return !env.Skip["dapr-dashboard"] && getDaprDashboardRequirement(env.ConfigLoaded, env.Config.Components.Dapr.Dashboard, env.Skip["dapr-dashboard"])
+}
+
+func (daprDashboardDriver) Health(env *LocalEnv) error {
+	dashboardURL := getDaprDashboardURL(env.ConfigLoaded, env.Config)
+	if !isDaprDashboardAccessible(dashboardURL) {
+		return fmt.Errorf("dashboard is not accessible at %s", dashboardURL)
+	}
+	return nil
+}
+
+// Readiness waits for the dashboard to answer on its port
+func (daprDashboardDriver) Readiness(env *LocalEnv) Readiness {
+	return Readiness{
+		Probe:   dashboardProbe(getDaprDashboardPort(env.ConfigLoaded, env.Config)),
+		Options: probe.Options{Timeout: defaultDashboardStartTimeout},
+	}
+}
+
+// untrackedRunning reports whether a dashboard not started by localenv
+// answers on this profile's port. A dashboard another profile runs on a
+// different port doesn't count.
+func (d daprDashboardDriver) untrackedRunning(env *LocalEnv) bool {
+	if getDaprDashboardPID() == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(env.ctx(), 2*time.Second)
+	defer cancel()
+	return d.Readiness(env).Probe.Check(ctx) == nil
+}
+
+func (d daprDashboardDriver) Start(env *LocalEnv) error {
+	dashboardPort := getDaprDashboardPort(env.ConfigLoaded, env.Config)
+	trackedPIDs := env.State.runningPIDs(d.Name())
+
+	// Determine if a restart is required
+	restartRequired := env.ForceRestart ||
+		(env.ConfigChanged && env.PreviousCache.DaprDashboardPort != dashboardPort)
+
+	if len(trackedPIDs) > 0 {
+		if !restartRequired {
+			// Dashboard is already running with current configuration
+			fmt.Printf("✅ Dapr Dashboard already running at http://localhost:%d\n", dashboardPort)
+			return nil
+		}
+
+		if env.PreviousCache.DaprDashboardPort != dashboardPort {
+			fmt.Printf("Detected configuration change: Dashboard port changed (%d → %d)\n",
+				env.PreviousCache.DaprDashboardPort, dashboardPort)
+		}
+		fmt.Println("Stopping existing Dapr Dashboard...")
+
+		if err := stopTrackedComponent(env, d.Name()); err != nil && !errors.Is(err, errComponentNotRunning) {
+			fmt.Printf("❌ Failed to stop Dapr Dashboard: %v\n", err)
+			return err
+		}
+	} else if !restartRequired && d.untrackedRunning(env) {
+		fmt.Printf("✅ Dapr Dashboard already running at http://localhost:%d (not started by localenv)\n", dashboardPort)
+		return nil
+	}
+
+	// Check if the port is in use by something else
+	portFree := waitFor(env.ctx(), 5*time.Second, 500*time.Millisecond, func() error {
+		if isPortInUse(dashboardPort) {
+			return fmt.Errorf("port %d is already in use", dashboardPort)
+		}
+		return nil
+	})
+	if portFree != nil {
+		fmt.Printf("❌ Port %d is already in use by another process\n", dashboardPort)
+		fmt.Printf("   Run 'lsof -i :%d' to see which process is using it\n", dashboardPort)
+		fmt.Println("   Update the dashboardPort in localenv.yaml to a different value and try again.")
+		fmt.Printf("   For example: dapr.dashboardPort: %d\n", dashboardPort+1)
+		return portFree
+	}
+
+	// For Dapr Dashboard, we need special handling to make sure it stays running
+	fmt.Println("Starting DaprDashboard in background mode...")
+
+	logFile, err := openComponentLog(daprDashboardLogFile())
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	pid, ok := startDashboardProcess(env.ctx(), "dapr", dashboardPort, logFile, d.Readiness(env))
+	if !ok {
+		fmt.Printf("❌ Failed to start Dapr Dashboard on port %d\n", dashboardPort)
+		fmt.Println("   This could be because the port is already in use.")
+		fmt.Printf("   You can check which process is using the port with: lsof -i :%d\n", dashboardPort)
+		fmt.Println("   Update the dashboardPort in localenv.yaml to a different value and try again.")
+		fmt.Printf("   For example: dapr.dashboardPort: %d\n", dashboardPort+1)
+		return fmt.Errorf("dapr dashboard exited on startup")
+	}
+
+	env.State.forget(d.Name())
+	if err := env.State.recordProcess(d.Name(), pid, processGroupID(pid)); err != nil {
+		fmt.Printf("⚠️ Warning: Could not record Dapr Dashboard in state file: %v\n", err)
+	}
+
+	fmt.Printf("✅ Dapr Dashboard started at http://localhost:%d\n", dashboardPort)
+	return nil
+}
+
+func (d daprDashboardDriver) Stop(env *LocalEnv) error {
+	fmt.Println("Stopping Dapr Dashboard...")
+
+	err := stopTrackedComponent(env, d.Name())
+	switch {
+	case errors.Is(err, errComponentNotRunning) && env.Aggressive:
+		if err := d.stopByHeuristics(env); err != nil {
+			return err
+		}
+	case errors.Is(err, errComponentNotRunning):
+		printUntrackedHint("Dapr Dashboard")
+		return err
+	case err != nil:
+		fmt.Printf("❌ Failed to stop Dapr Dashboard: %v\n", err)
+		return err
+	}
+
+	fmt.Println("✅ Dapr Dashboard stopped successfully.")
+
+	if env.CleanLogs {
+		if err := removeLogFile(daprDashboardLogFile()); err == nil {
+			fmt.Printf("✅ Removed Dapr Dashboard log file: %s\n", daprDashboardLogFile())
+		}
+	}
+	return nil
+}
+
+// stopByHeuristics stops Dapr Dashboard processes found by name and port.
+// It is only used with --aggressive as it can hit unrelated processes.
+func (daprDashboardDriver) stopByHeuristics(env *LocalEnv) error {
+	// Find dashboard processes by name and by the port they listen on
+	dashboardPids := findProcessPIDs("dapr dashboard")
+	addPid := func(pid int) {
+		for _, existingPid := range dashboardPids {
+			if existingPid == pid {
+				return
+			}
+		}
+		dashboardPids = append(dashboardPids, pid)
+	}
+
+	if env.ConfigLoaded && env.Config.Components.Dapr.DashboardPort > 0 {
+		pids, _ := prober.ListeningPIDs(env.Config.Components.Dapr.DashboardPort)
+		for _, pid := range pids {
+			addPid(pid)
+		}
+	}
+
+	if len(dashboardPids) == 0 {
+		if env.Verbose {
+			fmt.Println("No running Dapr Dashboard processes found.")
+		} else {
+			fmt.Println("❌ No running Dapr Dashboard processes found.")
+		}
+		return errComponentNotRunning
+	}
+
+	if env.Verbose {
+		fmt.Printf("Found %d Dapr Dashboard processes: %s\n", len(dashboardPids), joinPIDs(dashboardPids))
+	}
+
+	allKilled := true
+	for _, pid := range dashboardPids {
+		// SIGTERM first, SIGKILL only with --force
+		killErr := terminateProcess(env.ctx(), pid, 0, "", env.Force)
+
+		if killErr != nil {
+			allKilled = false
+			if env.Verbose {
+				fmt.Printf("Failed to kill Dapr Dashboard process %d: %v\n", pid, killErr)
+			}
+		} else if env.Verbose {
+			fmt.Printf("Killed Dapr Dashboard process with PID %d\n", pid)
+		}
+	}
+
+	if !allKilled {
+		fmt.Println("❌ Failed to stop some Dapr Dashboard processes.")
+		return errors.New("failed to stop some Dapr Dashboard processes")
+	}
+
+	return nil
+}
+
+func (d daprDashboardDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: d.Name(), Enabled: d.Enabled(env)}
+	dashboardURL := getDaprDashboardURL(env.ConfigLoaded, env.Config)
+	accessible := isDaprDashboardAccessible(dashboardURL)
+
+	status.PIDs = env.State.runningPIDs(d.Name())
+	if len(status.PIDs) == 0 && env.Aggressive {
+		if pid, err := strconv.Atoi(getDaprDashboardPID()); err == nil {
+			status.PIDs = append(status.PIDs, pid)
+		}
+	}
+
+	switch {
+	case len(status.PIDs) > 0:
+		status.Message = "Running"
+	case accessible:
+		status.Message = "Running (not started by localenv)"
+	default:
+		status.Message = "Not running"
+		return status
+	}
+
+	status.Running = true
+	status.Ports = append(status.Ports, getDaprDashboardPort(env.ConfigLoaded, env.Config))
+	status.Healthy = accessible
+	status.Endpoints = append(status.Endpoints, ComponentEndpoint{Name: "UI", URL: dashboardURL, Accessible: accessible})
+	if !accessible {
+		status.Details = append(status.Details,
+			"If the dashboard is running but not accessible on this port,",
+			"update the dashboardPort in localenv.yaml and restart the environment.")
+	}
+	return status
+}
+
+func (daprDashboardDriver) LogSources(env *LocalEnv) []LogSource {
+	return []LogSource{{Name: "dapr-dashboard", File: daprDashboardLogFile()}}
+}
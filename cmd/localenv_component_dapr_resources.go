@@ -0,0 +1,284 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultDaprResources is where Dapr components are installed, relative to localenv.yaml
+	defaultDaprResources = ".dapr/resources"
+	// daprGeneratedHeader marks the component files written by localenv, so
+	// hand-written files in the same directory are left alone
+	daprGeneratedHeader = "# Generated by devhelper-cli from localenv.yaml, do not edit.\n"
+)
+
+// DaprComponentConfig declares a Dapr component under components.dapr.components.
+// Metadata values are Go templates, see daprTemplateFuncs.
+type DaprComponentConfig struct {
+	Type     string            `yaml:"type"`
+	Version  string            `yaml:"version,omitempty"`
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+	// Scopes limits the component to these app IDs
+	Scopes []string `yaml:"scopes,omitempty"`
+}
+
+// DaprComponentInfo is a Dapr component installed in the resources directory or loaded by an app
+type DaprComponentInfo struct {
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// daprManifest is the Dapr component resource written to the resources directory
+type daprManifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Type     string              `yaml:"type"`
+		Version  string              `yaml:"version"`
+		Metadata []daprMetadataEntry `yaml:"metadata,omitempty"`
+	} `yaml:"spec"`
+	Scopes []string `yaml:"scopes,omitempty"`
+}
+
+type daprMetadataEntry struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// daprCLI runs a dapr command and returns its standard output. It is a
+// variable so tests can replace it.
+var daprCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "dapr", args...).Output()
+}
+
+// daprResourcesDir returns the directory the Dapr components of the active
+// profile are installed into
+func daprResourcesDir(config LocalEnvConfig, configPath string) string {
+	dir := config.Components.Dapr.Resources
+	if dir == "" {
+		dir = defaultDaprResources
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	return profileDir(dir, localenvProfile)
+}
+
+// daprComponentPorts returns the host ports component templates can refer to
+// by component name. Custom components use their first published port.
+func daprComponentPorts(config LocalEnvConfig) map[string]int {
+	env := &LocalEnv{Config: config, ConfigLoaded: true}
+	_, temporalGRPCPort := temporalPorts(env)
+	ports := map[string]int{
+		"redis":      6379,
+		"zipkin":     getZipkinPort(true, config),
+		"temporal":   temporalGRPCPort,
+		"opensearch": config.Components.OpenSearch.Port,
+	}
+	if ports["opensearch"] == 0 {
+		ports["opensearch"] = 9200
+	}
+	for name, custom := range config.Components.Custom {
+		if len(custom.Ports) == 0 {
+			continue
+		}
+		if hostPort, _, err := parsePortMapping(custom.Ports[0]); err == nil {
+			ports[name] = hostPort
+		}
+	}
+	return ports
+}
+
+// daprTemplateFuncs are the functions available in component metadata:
+// {{ port "kafka" }} is the host port of a component, {{ env "NAME" }} an
+// environment variable and {{ profile }} the active profile.
+func daprTemplateFuncs(ports map[string]int) template.FuncMap {
+	return template.FuncMap{
+		"port": func(name string) (int, error) {
+			port, ok := ports[strings.ToLower(name)]
+			if !ok {
+				return 0, fmt.Errorf("unknown component %q", name)
+			}
+			return port, nil
+		},
+		"env":     os.Getenv,
+		"profile": profileLabel,
+	}
+}
+
+// renderDaprComponent renders the manifest of a declared component
+func renderDaprComponent(name string, component DaprComponentConfig, funcs template.FuncMap) ([]byte, error) {
+	manifest := daprManifest{APIVersion: "dapr.io/v1alpha1", Kind: "Component", Scopes: component.Scopes}
+	manifest.Metadata.Name = name
+	manifest.Spec.Type = component.Type
+	manifest.Spec.Version = component.Version
+	if manifest.Spec.Version == "" {
+		manifest.Spec.Version = "v1"
+	}
+
+	keys := []string{}
+	for key := range component.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tmpl, err := template.New(key).Funcs(funcs).Parse(component.Metadata[key])
+		if err != nil {
+			return nil, fmt.Errorf("metadata %s: %w", key, err)
+		}
+		var value bytes.Buffer
+		if err := tmpl.Execute(&value, nil); err != nil {
+			return nil, fmt.Errorf("metadata %s: %w", key, err)
+		}
+		manifest.Spec.Metadata = append(manifest.Spec.Metadata, daprMetadataEntry{Name: key, Value: value.String()})
+	}
+
+	var data bytes.Buffer
+	data.WriteString(daprGeneratedHeader)
+	encoder := yamlv3.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// installDaprComponents renders the declared components into the resources
+// directory and removes the generated files of components no longer declared.
+// It returns the directory.
+func installDaprComponents(config LocalEnvConfig, configPath string) (string, error) {
+	dir := daprResourcesDir(config, configPath)
+	components := config.Components.Dapr.Components
+	if _, err := os.Stat(dir); len(components) == 0 && err != nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return dir, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	funcs := daprTemplateFuncs(daprComponentPorts(config))
+	for name, component := range components {
+		data, err := renderDaprComponent(name, component, funcs)
+		if err != nil {
+			return dir, fmt.Errorf("dapr component %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), data, 0644); err != nil {
+			return dir, err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return dir, err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if _, declared := components[name]; !ok || declared {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if data, err := os.ReadFile(path); err == nil && bytes.HasPrefix(data, []byte(daprGeneratedHeader)) {
+			os.Remove(path)
+		}
+	}
+	return dir, nil
+}
+
+// installedDaprComponents returns the components defined in the resources directory
+func installedDaprComponents(dir string) []DaprComponentInfo {
+	components := []DaprComponentInfo{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return components
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || (filepath.Ext(entry.Name()) != ".yaml" && filepath.Ext(entry.Name()) != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		// A file can hold several resources, of which only components are listed
+		decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+		for {
+			var manifest daprManifest
+			if err := decoder.Decode(&manifest); err != nil {
+				break
+			}
+			if manifest.Kind == "Component" {
+				components = append(components, DaprComponentInfo{
+					Name:    manifest.Metadata.Name,
+					Type:    manifest.Spec.Type,
+					Version: manifest.Spec.Version,
+				})
+			}
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+	return components
+}
+
+// loadedDaprComponents returns the components loaded by each running Dapr app, by app ID
+func loadedDaprComponents() (map[string][]DaprComponentInfo, error) {
+	apps, err := listDaprApps(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	client := http.Client{Timeout: 2 * time.Second}
+	loaded := map[string][]DaprComponentInfo{}
+	for _, app := range apps {
+		resp, err := client.Get(fmt.Sprintf("http://localhost:%d/v1.0/metadata", app.HTTPPort))
+		if err != nil {
+			continue
+		}
+		var metadata struct {
+			Components []DaprComponentInfo `json:"components"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&metadata)
+		resp.Body.Close()
+		if err == nil {
+			loaded[app.AppID] = metadata.Components
+		}
+	}
+	return loaded, nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const daprComponentsConfig = `components:
+  dapr:
+    enabled: true
+    components:
+      statestore:
+        type: state.redis
+        metadata:
+          redisHost: "localhost:{{ port \"redis\" }}"
+          actorStateStore: "true"
+      pubsub:
+        type: pubsub.kafka
+        metadata:
+          brokers: "localhost:{{ port \"kafka\" }}"
+          consumerGroup: "orders-{{ profile }}"
+        scopes: [orders]
+  custom:
+    kafka:
+      enabled: true
+      image: bitnami/kafka:3.7
+      ports: ["19092:9092"]
+`
+
+// TestDaprComponents tests rendering, installing and listing Dapr components
+func TestDaprComponents(t *testing.T) {
+	origCLI := daprCLI
+	defer func() { daprCLI = origCLI }()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "localenv.yaml")
+	config, err := parseLocalEnvConfig(configPath, []byte(daprComponentsConfig), "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Components should be installed next to localenv.yaml", func(t *testing.T) {
+		resources, err := installDaprComponents(config, configPath)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, ".dapr", "resources"), resources)
+
+		data, err := os.ReadFile(filepath.Join(resources, "pubsub.yaml"))
+		assert.NoError(t, err)
+		assert.Equal(t, daprGeneratedHeader+`apiVersion: dapr.io/v1alpha1
+kind: Component
+metadata:
+  name: pubsub
+spec:
+  type: pubsub.kafka
+  version: v1
+  metadata:
+    - name: brokers
+      value: localhost:19092
+    - name: consumerGroup
+      value: orders-default
+scopes:
+  - orders
+`, string(data))
+	})
+
+	t.Run("Installed components should be listed", func(t *testing.T) {
+		resources := daprResourcesDir(config, configPath)
+		os.WriteFile(filepath.Join(resources, "secrets.yaml"), []byte("apiVersion: dapr.io/v1alpha1\nkind: Component\nmetadata:\n  name: secrets\nspec:\n  type: secretstores.local.env\n---\nkind: Configuration\nmetadata:\n  name: tracing\n"), 0644)
+
+		assert.Equal(t, []DaprComponentInfo{
+			{Name: "pubsub", Type: "pubsub.kafka", Version: "v1"},
+			{Name: "secrets", Type: "secretstores.local.env"},
+			{Name: "statestore", Type: "state.redis", Version: "v1"},
+		}, installedDaprComponents(resources))
+	})
+
+	t.Run("Components no longer declared should be removed", func(t *testing.T) {
+		delete(config.Components.Dapr.Components, "pubsub")
+		resources, err := installDaprComponents(config, configPath)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(resources, "pubsub.yaml"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(resources, "secrets.yaml"))
+		assert.NoError(t, err, "hand-written components should be kept")
+	})
+
+	t.Run("Profiles should have their own resources directory", func(t *testing.T) {
+		origProfile := localenvProfile
+		defer func() { localenvProfile = origProfile }()
+		localenvProfile = "search-heavy"
+
+		config := LocalEnvConfig{}
+		config.Components.Dapr.Resources = "/tmp/dapr"
+		assert.Equal(t, filepath.Join("/tmp/dapr", "profiles", "search-heavy"), daprResourcesDir(config, configPath))
+	})
+
+	t.Run("Loaded components should be read from the running apps", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1.0/metadata", r.URL.Path)
+			fmt.Fprint(w, `{"id":"orders","components":[{"name":"statestore","type":"state.redis","version":"v1"}]}`)
+		}))
+		defer server.Close()
+		serverURL, _ := url.Parse(server.URL)
+
+		daprCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+			assert.Equal(t, []string{"list", "--output", "json"}, args)
+			return []byte(fmt.Sprintf(`[{"appId":"orders","httpPort":%s}]`, serverURL.Port())), nil
+		}
+		loaded, err := loadedDaprComponents()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]DaprComponentInfo{"orders": {{Name: "statestore", Type: "state.redis", Version: "v1"}}}, loaded)
+		assert.Equal(t, "statestore (state.redis)", formatDaprComponents(loaded["orders"]))
+	})
+
+	t.Run("Invalid components should be reported", func(t *testing.T) {
+		data := "components:\n  dapr:\n    components:\n      store:\n        type: redis\n        metadata:\n          host: \"{{ port \\\"kafka\\\" }}\"\n"
+		_, err := parseLocalEnvConfig(configPath, []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 2) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, `type must be a Dapr component type like state.redis, got "redis"`)
+		assert.Contains(t, errs[1].Message, `unknown component "kafka"`)
+	})
+}
@@ -0,0 +1,440 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
+)
+
+const (
+	openSearchContainer          = "opensearch-node"
+	openSearchDashboardContainer = "opensearch-dashboard"
+	openSearchNetwork            = "opensearch-network"
+	openSearchDataVolume         = "opensearch-data"
+	// openSearchDataPath is where OpenSearch keeps its data in the container
+	openSearchDataPath = "/usr/share/opensearch/data"
+)
+
+// openSearchReadyStatus matches the cluster health of a cluster serving requests
+var openSearchReadyStatus = regexp.MustCompile(`"status"\s*:\s*"(green|yellow)"`)
+
+// openSearchContainerName returns the OpenSearch container name of the active profile
+func openSearchContainerName() string { return profileResourceName(openSearchContainer) }
+
+// openSearchDashboardContainerName returns the OpenSearch Dashboards container name of the active profile
+func openSearchDashboardContainerName() string {
+	return profileResourceName(openSearchDashboardContainer)
+}
+
+// openSearchNetworkName returns the OpenSearch network name of the active profile
+func openSearchNetworkName() string { return profileResourceName(openSearchNetwork) }
+
+// openSearchDataVolumeName returns the OpenSearch data volume name of the active profile
+func openSearchDataVolumeName() string { return profileResourceName(openSearchDataVolume) }
+
+// openSearchDriver manages the OpenSearch container
+type openSearchDriver struct{}
+
+// openSearchDashboardDriver manages the OpenSearch Dashboards container
+type openSearchDashboardDriver struct{}
+
+// removeExistingContainer removes a leftover container so it can be recreated
+func removeExistingContainer(ctx context.Context, name, label string, verbose bool) error {
+	if !containerExists(ctx, name) {
+		return nil
+	}
+
+	fmt.Printf("Found existing %s container, removing it...\n", label)
+	err := containerRuntime.Remove(ctx, name)
+	if err != nil {
+		fmt.Printf("❌ Failed to remove existing %s container: %v\n", label, err)
+	}
+	return err
+}
+
+// runContainer starts a detached container with the container runtime and returns its ID
+func runContainer(ctx context.Context, label string, spec ContainerSpec, verbose bool) (string, error) {
+	if verbose {
+		fmt.Printf("Starting %s container %s from %s with %s\n", label, spec.Name, spec.Image, containerRuntime.Label())
+	}
+
+	containerID, err := containerRuntime.Run(ctx, spec)
+	if err != nil {
+		fmt.Printf("❌ Failed to start %s: %v\n", label, err)
+		return "", err
+	}
+	return containerID, nil
+}
+
+// stopContainerComponent removes the container recorded for a component.
+// With --aggressive, a container that isn't recorded is removed by name.
+func stopContainerComponent(env *LocalEnv, component, containerName, label string) error {
+	err := stopTrackedComponent(env, component)
+	switch {
+	case err == nil:
+		fmt.Printf("✅ %s stopped\n", label)
+		return nil
+	case !errors.Is(err, errComponentNotRunning):
+		fmt.Printf("❌ Failed to stop %s: %v\n", label, err)
+		return err
+	case !containerExists(env.ctx(), containerName):
+		fmt.Printf("ℹ️ %s is not running\n", label)
+		return errComponentNotRunning
+	case !env.Aggressive:
+		printUntrackedHint(label)
+		return errComponentNotRunning
+	}
+
+	// Stop and remove the container
+	if err := containerRuntime.Remove(env.ctx(), containerName); err != nil {
+		fmt.Printf("❌ Failed to stop %s container: %v\n", label, err)
+		return err
+	}
+	fmt.Printf("✅ %s stopped\n", label)
+	return nil
+}
+
+func (openSearchDriver) Name() string { return "OpenSearch" }
+
+func (openSearchDriver) Dependencies() []string { return nil }
+
+func (openSearchDriver) Installed() bool { return containerRuntime.Available() }
+
+func (openSearchDriver) Enabled(env *LocalEnv) bool {
+	return getOpenSearchRequirement(env.ConfigLoaded, env.Config.Components.OpenSearch.Enabled, env.Skip["opensearch"])
+}
+
+// containerSpec returns the OpenSearch container to run. Its data is kept
+// in a named volume so it survives restarts, unless OpenSearch is ephemeral.
+func (openSearchDriver) containerSpec(env *LocalEnv) ContainerSpec {
+	spec := ContainerSpec{
+		Name:  openSearchContainerName(),
+		Image: fmt.Sprintf("opensearchproject/opensearch:%s", env.Config.Components.OpenSearch.Version),
+		Ports: []string{fmt.Sprintf("%d:9200", env.Config.Components.OpenSearch.Port)},
+		Env: []string{
+			"cluster.name=devhelper-cluster",
+			"node.name=" + openSearchContainerName(),
+			"discovery.type=single-node",
+			"DISABLE_SECURITY_PLUGIN=true",
+			"DISABLE_INSTALL_DEMO_CONFIG=true",
+		},
+		HealthCheck: &ContainerHealthCheck{
+			Command:  fmt.Sprintf("curl -u %s:%s -f http://localhost:9200/_cluster/health || exit 1", "admin", "admin"),
+			Interval: 30 * time.Second,
+			Timeout:  10 * time.Second,
+			Retries:  5,
+		},
+		Network: openSearchNetworkName(),
+		Restart: "unless-stopped",
+	}
+	if !env.Config.Components.OpenSearch.Ephemeral {
+		spec.Volumes = append(spec.Volumes, openSearchDataVolumeName()+":"+openSearchDataPath)
+	}
+	return spec
+}
+
+// checkClusterHealth queries the OpenSearch cluster health endpoint once
+func (openSearchDriver) checkClusterHealth(env *LocalEnv) error {
+	url := fmt.Sprintf("http://localhost:%d/_cluster/health", env.Config.Components.OpenSearch.Port)
+
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read and log response for debugging
+	if env.Verbose {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("OpenSearch response (status %d): %s\n", resp.StatusCode, string(body))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unhealthy, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Readiness waits for the cluster health to turn green or yellow, the best a
+// single node with replicated indices gets. The container health check runs
+// every 30s, too rarely to wait on.
+func (openSearchDriver) Readiness(env *LocalEnv) Readiness {
+	container := containerProbe(openSearchContainerName())
+	container.IgnoreHealth = true
+	return Readiness{
+		Probe: probe.All(container, probe.HTTP{
+			URL:    fmt.Sprintf("http://localhost:%d/_cluster/health", env.Config.Components.OpenSearch.Port),
+			Status: probe.StatusRange(200, 299),
+			Body:   openSearchReadyStatus,
+		}),
+		Options: probe.Options{Timeout: 60 * time.Second},
+	}
+}
+
+func (o openSearchDriver) Health(env *LocalEnv) error {
+	if !isContainerRunning(env.ctx(), openSearchContainerName()) {
+		return errComponentNotRunning
+	}
+	return o.checkClusterHealth(env)
+}
+
+func (o openSearchDriver) Start(env *LocalEnv) error {
+	if err := containerRuntime.CreateNetwork(env.ctx(), openSearchNetworkName()); err != nil {
+		fmt.Printf("❌ Failed to create %s: %v\n", openSearchNetworkName(), err)
+		return err
+	}
+
+	if err := removeExistingContainer(env.ctx(), openSearchContainerName(), "OpenSearch", env.Verbose); err != nil {
+		return err
+	}
+
+	containerID, err := runContainer(env.ctx(), "OpenSearch", o.containerSpec(env), env.Verbose)
+	if err != nil {
+		return err
+	}
+	env.State.forget(o.Name())
+	if err := env.State.recordContainer(o.Name(), containerID); err != nil {
+		fmt.Printf("⚠️ Warning: Could not record OpenSearch in state file: %v\n", err)
+	}
+
+	fmt.Println("⏳ Waiting for OpenSearch container to start...")
+	if !waitForContainer(env.ctx(), openSearchContainerName(), "OpenSearch") {
+		fmt.Println("❌ OpenSearch container failed to start")
+		if env.Verbose {
+			printContainerLogs(env.ctx(), openSearchContainerName(), "OpenSearch")
+		}
+		return errors.New("opensearch container failed to start")
+	}
+
+	// Now wait for OpenSearch service to be ready
+	fmt.Println("⏳ Waiting for OpenSearch service to be ready...")
+	if err := waitReady(env, o); err == nil {
+		fmt.Println("✅ OpenSearch is running and ready")
+		return nil
+	}
+
+	fmt.Println("❌ OpenSearch is not running. Please check its logs for errors.")
+	if env.Verbose {
+		printContainerLogs(env.ctx(), openSearchContainerName(), "OpenSearch")
+	}
+	return errors.New("opensearch did not become ready")
+}
+
+func (o openSearchDriver) Stop(env *LocalEnv) error {
+	fmt.Println("Stopping OpenSearch...")
+	return stopContainerComponent(env, o.Name(), openSearchContainerName(), "OpenSearch")
+}
+
+func (o openSearchDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: o.Name(), Enabled: o.Enabled(env)}
+
+	if !isContainerRunning(env.ctx(), openSearchContainerName()) {
+		status.Message = "Not running"
+		status.Details = append(status.Details, "Run 'devhelper-cli localenv start' to start OpenSearch")
+		return status
+	}
+
+	status.Running = true
+	status.Message = "Running"
+	status.Containers = append(status.Containers, openSearchContainerName())
+	status.Ports = append(status.Ports, env.Config.Components.OpenSearch.Port)
+
+	apiURL := fmt.Sprintf("http://localhost:%d", env.Config.Components.OpenSearch.Port)
+	client := http.Client{
+		Timeout: 2 * time.Second,
+	}
+	resp, err := client.Get(apiURL)
+	if err == nil {
+		resp.Body.Close()
+		status.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+		if !status.Healthy {
+			status.Details = append(status.Details, fmt.Sprintf("API is unhealthy, status code: %d", resp.StatusCode))
+		}
+	} else {
+		status.Details = append(status.Details, "API is unavailable, service may still be starting")
+		if env.Verbose {
+			status.Details = append(status.Details, fmt.Sprintf("Error: %v", err))
+		}
+	}
+	status.Endpoints = append(status.Endpoints, ComponentEndpoint{Name: "API", URL: apiURL, Accessible: status.Healthy})
+
+	// Check if security is disabled
+	container, err := containerRuntime.Inspect(env.ctx(), openSearchContainerName())
+	if err == nil && container.Health != "" {
+		status.Details = append(status.Details, "Container health: "+container.Health)
+	}
+	if !env.Config.Components.OpenSearch.Ephemeral {
+		status.Details = append(status.Details, "Data volume: "+openSearchDataVolumeName())
+	}
+	if err == nil && slices.Contains(container.Env, "DISABLE_SECURITY_PLUGIN=true") {
+		status.Details = append(status.Details, "Security plugin disabled - no credentials required for API")
+	}
+	return status
+}
+
+func (openSearchDriver) LogSources(env *LocalEnv) []LogSource {
+	return []LogSource{{Name: openSearchContainerName(), Container: openSearchContainerName()}}
+}
+
+func (openSearchDashboardDriver) Name() string { return "OpenSearchDashboard" }
+
+func (openSearchDashboardDriver) Aliases() []string { return []string{"opensearch-dashboard"} }
+
+// The dashboard connects to the OpenSearch node on startup
+func (openSearchDashboardDriver) Dependencies() []string { return []string{"OpenSearch"} }
+
+func (openSearchDashboardDriver) Installed() bool { return containerRuntime.Available() }
+
+func (openSearchDashboardDriver) Enabled(env *LocalEnv) bool {
+	return getOpenSearchRequirement(env.ConfigLoaded, env.Config.Components.OpenSearch.Enabled, env.Skip["opensearch"])
+}
+
+// containerSpec returns the OpenSearch Dashboards container to run
+func (openSearchDashboardDriver) containerSpec(env *LocalEnv) ContainerSpec {
+	return ContainerSpec{
+		Name:  openSearchDashboardContainerName(),
+		Image: fmt.Sprintf("opensearchproject/opensearch-dashboards:%s", env.Config.Components.OpenSearch.Version),
+		Ports: []string{fmt.Sprintf("%d:5601", env.Config.Components.OpenSearch.DashboardPort)},
+		Env: []string{
+			fmt.Sprintf("OPENSEARCH_HOSTS=[\"http://%s:9200\"]", openSearchContainerName()),
+			"DISABLE_SECURITY_DASHBOARDS_PLUGIN=true",
+		},
+		Network: openSearchNetworkName(),
+		Restart: "unless-stopped",
+	}
+}
+
+// checkAccessible sends a single request to the dashboard. Any response,
+// even a 404, means the server is up.
+func (openSearchDashboardDriver) checkAccessible(env *LocalEnv) error {
+	client := http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d", env.Config.Components.OpenSearch.DashboardPort))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Readiness waits for the dashboard to answer, which takes longer than the
+// container start
+func (openSearchDashboardDriver) Readiness(env *LocalEnv) Readiness {
+	return Readiness{
+		Probe: probe.All(
+			containerProbe(openSearchDashboardContainerName()),
+			dashboardProbe(env.Config.Components.OpenSearch.DashboardPort),
+		),
+		Options: probe.Options{Timeout: 90 * time.Second},
+	}
+}
+
+func (d openSearchDashboardDriver) Health(env *LocalEnv) error {
+	if !isContainerRunning(env.ctx(), openSearchDashboardContainerName()) {
+		return errComponentNotRunning
+	}
+	return d.checkAccessible(env)
+}
+
+func (d openSearchDashboardDriver) Start(env *LocalEnv) error {
+	// First check if OpenSearch is running as the Dashboard depends on it
+	if !isContainerRunning(env.ctx(), openSearchContainerName()) {
+		fmt.Println("❌ OpenSearch is not running. Dashboard cannot start without OpenSearch.")
+		return errors.New("opensearch is not running")
+	}
+
+	if err := removeExistingContainer(env.ctx(), openSearchDashboardContainerName(), "OpenSearch Dashboard", env.Verbose); err != nil {
+		return err
+	}
+
+	containerID, err := runContainer(env.ctx(), "OpenSearch Dashboard", d.containerSpec(env), env.Verbose)
+	if err != nil {
+		return err
+	}
+	env.State.forget(d.Name())
+	if err := env.State.recordContainer(d.Name(), containerID); err != nil {
+		fmt.Printf("⚠️ Warning: Could not record OpenSearch Dashboard in state file: %v\n", err)
+	}
+
+	fmt.Println("⏳ Waiting for OpenSearch Dashboard container to start...")
+	if !waitForContainer(env.ctx(), openSearchDashboardContainerName(), "Dashboard") {
+		fmt.Println("❌ OpenSearch Dashboard container failed to start")
+		if env.Verbose {
+			printContainerLogs(env.ctx(), openSearchDashboardContainerName(), "OpenSearch Dashboard")
+		}
+		return errors.New("opensearch dashboard container failed to start")
+	}
+
+	// Dashboard needs more time to initialize than just the container start
+	fmt.Println("⏳ Waiting for OpenSearch Dashboard to initialize...")
+	if err := waitReady(env, d); err == nil {
+		fmt.Println("✅ OpenSearch Dashboard is accessible")
+		return nil
+	}
+
+	fmt.Println("❌ OpenSearch Dashboard is not responding. It may still be initializing.")
+	fmt.Println("   OpenSearch Dashboard can take longer to start up than OpenSearch itself.")
+	fmt.Println("   The container is running but may need more time to fully initialize.")
+	if env.Verbose {
+		printContainerLogs(env.ctx(), openSearchDashboardContainerName(), "OpenSearch Dashboard")
+	}
+
+	// Treat it as running anyway as the container is up. This prevents the
+	// entire localenv from failing when just the Dashboard UI is slow to start.
+	return nil
+}
+
+func (d openSearchDashboardDriver) Stop(env *LocalEnv) error {
+	fmt.Println("Stopping OpenSearch Dashboard...")
+	return stopContainerComponent(env, d.Name(), openSearchDashboardContainerName(), "OpenSearch Dashboard")
+}
+
+func (d openSearchDashboardDriver) Status(env *LocalEnv) ComponentStatus {
+	status := ComponentStatus{Name: d.Name(), Enabled: d.Enabled(env)}
+	dashboardURL := fmt.Sprintf("http://localhost:%d", env.Config.Components.OpenSearch.DashboardPort)
+
+	if !isContainerRunning(env.ctx(), openSearchDashboardContainerName()) {
+		status.Message = "Not running"
+		status.Details = append(status.Details, "Run 'devhelper-cli localenv start' to start the OpenSearch Dashboard")
+		return status
+	}
+
+	status.Running = true
+	status.Message = "Running"
+	status.Containers = append(status.Containers, openSearchDashboardContainerName())
+	status.Ports = append(status.Ports, env.Config.Components.OpenSearch.DashboardPort)
+	status.Healthy = d.checkAccessible(env) == nil
+	status.Endpoints = append(status.Endpoints, ComponentEndpoint{Name: "UI", URL: dashboardURL, Accessible: status.Healthy})
+	return status
+}
+
+func (openSearchDashboardDriver) LogSources(env *LocalEnv) []LogSource {
+	return []LogSource{{Name: openSearchDashboardContainerName(), Container: openSearchDashboardContainerName()}}
+}
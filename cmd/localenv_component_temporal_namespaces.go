@@ -0,0 +1,274 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemporalNamespaceConfig declares a namespace under components.temporal.namespaces
+type TemporalNamespaceConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Retention is how long closed workflows are kept, such as 72h or 7d
+	Retention string `yaml:"retention,omitempty"`
+	// SearchAttributes maps custom search attribute names to their type
+	SearchAttributes map[string]string `yaml:"searchAttributes,omitempty"`
+}
+
+// temporalSearchAttributeTypes are the types a custom search attribute can have
+var temporalSearchAttributeTypes = []string{"Text", "Keyword", "Int", "Double", "Bool", "Datetime", "KeywordList"}
+
+// temporalCLI runs a temporal command and returns its standard output. It is
+// a variable so tests can replace the server.
+var temporalCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "temporal", args...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout, err
+}
+
+// temporalNamespaceState is the configuration of an existing namespace
+type temporalNamespaceState struct {
+	Description      string
+	Retention        time.Duration
+	SearchAttributes map[string]string
+}
+
+// temporalAddress returns the gRPC address of the Temporal server
+func temporalAddress(env *LocalEnv) string {
+	_, grpcPort := temporalPorts(env)
+	return fmt.Sprintf("localhost:%d", grpcPort)
+}
+
+// parseRetention parses a retention period, which is a Go duration or a number of days
+func parseRetention(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if retention, err := time.ParseDuration(value); err == nil && retention > 0 {
+		return retention, nil
+	}
+	return 0, fmt.Errorf("%q is not a duration like 72h or 7d", value)
+}
+
+// searchAttributeType returns the canonical name of a search attribute type given
+// in localenv.yaml or by the server (e.g. INDEXED_VALUE_TYPE_KEYWORD_LIST), or an
+// empty string for unknown types
+func searchAttributeType(value string) string {
+	normalized := strings.ReplaceAll(strings.TrimPrefix(value, "INDEXED_VALUE_TYPE_"), "_", "")
+	for _, attributeType := range temporalSearchAttributeTypes {
+		if strings.EqualFold(attributeType, normalized) {
+			return attributeType
+		}
+	}
+	return ""
+}
+
+// temporalNamespaces returns the namespaces declared in localenv.yaml. The
+// single namespace setting is kept for compatibility and added when it isn't
+// listed; the default namespace always exists, so it is only managed when listed.
+func temporalNamespaces(config LocalEnvConfig) []TemporalNamespaceConfig {
+	namespaces := slices.Clone(config.Components.Temporal.Namespaces)
+	name := config.Components.Temporal.Namespace
+	listed := slices.ContainsFunc(namespaces, func(namespace TemporalNamespaceConfig) bool {
+		return namespace.Name == name
+	})
+	if name != "" && name != "default" && !listed {
+		namespaces = append([]TemporalNamespaceConfig{{Name: name}}, namespaces...)
+	}
+	return namespaces
+}
+
+// sortedSearchAttributes returns the declared search attribute names in order
+func sortedSearchAttributes(namespace TemporalNamespaceConfig) []string {
+	names := []string{}
+	for name := range namespace.SearchAttributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeTemporalNamespace returns the configuration of a namespace, or nil if it doesn't exist
+func describeTemporalNamespace(ctx context.Context, address, name string) (*temporalNamespaceState, error) {
+	output, err := temporalCLI(ctx, "operator", "namespace", "describe", "--address", address, "--namespace", name, "--output", "json")
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var described struct {
+		NamespaceInfo struct {
+			Description string `json:"description"`
+		} `json:"namespaceInfo"`
+		Config struct {
+			WorkflowExecutionRetentionTtl string `json:"workflowExecutionRetentionTtl"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(output, &described); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace %s: %w", name, err)
+	}
+	state := &temporalNamespaceState{Description: described.NamespaceInfo.Description, SearchAttributes: map[string]string{}}
+	if ttl := described.Config.WorkflowExecutionRetentionTtl; ttl != "" {
+		if state.Retention, err = time.ParseDuration(ttl); err != nil {
+			return nil, fmt.Errorf("failed to parse retention of namespace %s: %w", name, err)
+		}
+	}
+
+	output, err = temporalCLI(ctx, "operator", "search-attribute", "list", "--address", address, "--namespace", name, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+	var attributes struct {
+		CustomAttributes map[string]string `json:"customAttributes"`
+	}
+	if err := json.Unmarshal(output, &attributes); err != nil {
+		return nil, fmt.Errorf("failed to parse search attributes of namespace %s: %w", name, err)
+	}
+	for attribute, attributeType := range attributes.CustomAttributes {
+		state.SearchAttributes[attribute] = searchAttributeType(attributeType)
+	}
+	return state, nil
+}
+
+// temporalNamespaceDrift returns how an existing namespace differs from its
+// declaration. Settings that aren't declared and extra search attributes are ignored.
+func temporalNamespaceDrift(declared TemporalNamespaceConfig, actual *temporalNamespaceState) []string {
+	if actual == nil {
+		return []string{"namespace does not exist"}
+	}
+
+	drift := []string{}
+	if retention, err := parseRetention(declared.Retention); err == nil && retention != actual.Retention {
+		drift = append(drift, fmt.Sprintf("retention is %s, declared %s", actual.Retention, retention))
+	}
+	if declared.Description != "" && declared.Description != actual.Description {
+		drift = append(drift, fmt.Sprintf("description is %q, declared %q", actual.Description, declared.Description))
+	}
+	for _, name := range sortedSearchAttributes(declared) {
+		declaredType := searchAttributeType(declared.SearchAttributes[name])
+		actualType, ok := actual.SearchAttributes[name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("search attribute %s is missing", name))
+		case actualType != declaredType:
+			drift = append(drift, fmt.Sprintf("search attribute %s is %s, declared %s", name, actualType, declaredType))
+		}
+	}
+	return drift
+}
+
+// reconcileTemporalNamespace creates a declared namespace or updates its
+// retention, description and search attributes. The type of an existing search
+// attribute can't be changed, so a mismatch is only reported.
+func reconcileTemporalNamespace(ctx context.Context, address string, namespace TemporalNamespaceConfig) error {
+	actual, err := describeTemporalNamespace(ctx, address, namespace.Name)
+	if err != nil {
+		return err
+	}
+
+	settings := []string{}
+	retention, retentionErr := parseRetention(namespace.Retention)
+	if retentionErr == nil {
+		settings = append(settings, "--retention", retention.String())
+	}
+	if namespace.Description != "" {
+		settings = append(settings, "--description", namespace.Description)
+	}
+
+	changed := false
+	switch {
+	case actual == nil:
+		fmt.Printf("Creating Temporal namespace '%s'...\n", namespace.Name)
+		args := append([]string{"operator", "namespace", "create", "--address", address, "--namespace", namespace.Name}, settings...)
+		if _, err := temporalCLI(ctx, args...); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Created Temporal namespace '%s'\n", namespace.Name)
+		actual = &temporalNamespaceState{SearchAttributes: map[string]string{}}
+		changed = true
+	case (retentionErr == nil && retention != actual.Retention) ||
+		(namespace.Description != "" && namespace.Description != actual.Description):
+		args := append([]string{"operator", "namespace", "update", "--address", address, "--namespace", namespace.Name}, settings...)
+		if _, err := temporalCLI(ctx, args...); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Updated Temporal namespace '%s'\n", namespace.Name)
+		changed = true
+	}
+
+	for _, name := range sortedSearchAttributes(namespace) {
+		declaredType := searchAttributeType(namespace.SearchAttributes[name])
+		actualType, ok := actual.SearchAttributes[name]
+		if ok && actualType != declaredType {
+			fmt.Printf("⚠️ Search attribute %s in namespace '%s' is %s but %s is declared.\n", name, namespace.Name, actualType, declaredType)
+			fmt.Println("   Temporal can't change the type of a search attribute; rename it or recreate the namespace.")
+			continue
+		}
+		if ok {
+			continue
+		}
+
+		// A namespace that was just created can take a moment to be visible to the operator service
+		err := waitFor(ctx, 10*time.Second, time.Second, func() error {
+			_, err := temporalCLI(ctx, "operator", "search-attribute", "create", "--address", address,
+				"--namespace", namespace.Name, "--name", name, "--type", declaredType)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add search attribute %s: %w", name, err)
+		}
+		fmt.Printf("✅ Added search attribute %s (%s) to namespace '%s'\n", name, declaredType, namespace.Name)
+		changed = true
+	}
+
+	if !changed {
+		fmt.Printf("✅ Temporal namespace '%s' is up to date\n", namespace.Name)
+	}
+	return nil
+}
+
+// reconcileTemporalNamespaces brings every declared namespace in line with localenv.yaml
+func reconcileTemporalNamespaces(env *LocalEnv) error {
+	failed := []string{}
+	for _, namespace := range temporalNamespaces(env.Config) {
+		if err := reconcileTemporalNamespace(env.ctx(), temporalAddress(env), namespace); err != nil {
+			fmt.Printf("❌ Failed to configure Temporal namespace '%s': %v\n", namespace.Name, err)
+			failed = append(failed, namespace.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to configure Temporal namespaces: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTemporalServerArgs tests building the start-dev command line from localenv.yaml
+func TestTemporalServerArgs(t *testing.T) {
+	origProfile := localenvProfile
+	defer func() { localenvProfile = origProfile }()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Run("Configured ports should be passed to the server", func(t *testing.T) {
+		env := &LocalEnv{ConfigPath: "localenv.yaml"}
+		env.Config.Components.Temporal.UIPort = 8234
+		env.Config.Components.Temporal.GRPCPort = 7234
+
+		assert.Equal(t, []string{"server", "start-dev", "--ui-port", "8234", "--port", "7234"}, temporalServerArgs(env))
+	})
+
+	t.Run("Persist should use a database in the profile data directory", func(t *testing.T) {
+		config := LocalEnvConfig{}
+		config.Components.Temporal.Persist = true
+		assert.Equal(t, filepath.Join(home, ".local", "share", "devhelper-cli", "temporal.db"), temporalDBFile(config))
+
+		localenvProfile = "search-heavy"
+		assert.Equal(t, filepath.Join(home, ".local", "share", "devhelper-cli", "profiles", "search-heavy", "temporal.db"), temporalDBFile(config))
+		localenvProfile = ""
+	})
+
+	t.Run("Server options should be passed through", func(t *testing.T) {
+		env := &LocalEnv{ConfigPath: filepath.Join("/work", "project", "localenv.yaml")}
+		env.Config.Components.Temporal.DBFile = "data/temporal.db"
+		env.Config.Components.Temporal.LogLevel = "warn"
+		env.Config.Components.Temporal.DynamicConfig = map[string]interface{}{
+			"limit.maxIDLength":                        255,
+			"frontend.enableUpdateWorkflowExecution":   true,
+			"system.forceSearchAttributesCacheRefresh": "on",
+		}
+
+		assert.Equal(t, []string{
+			"server", "start-dev", "--ui-port", "8233", "--port", "7233",
+			"--db-filename", filepath.Join("/work", "project", "data", "temporal.db"),
+			"--log-level", "warn",
+			"--dynamic-config-value", "frontend.enableUpdateWorkflowExecution=true",
+			"--dynamic-config-value", "limit.maxIDLength=255",
+			"--dynamic-config-value", `system.forceSearchAttributesCacheRefresh="on"`,
+		}, temporalServerArgs(env))
+	})
+
+	t.Run("Changed server options should be detected", func(t *testing.T) {
+		config := LocalEnvConfig{}
+		config.Components.Temporal.UIPort = 8233
+		config.Components.Temporal.DBFile = "temporal.db"
+
+		changed, newCache, changes := hasConfigChanged(config, ConfigCache{TemporalUIPort: 8233})
+		assert.True(t, changed)
+		assert.Equal(t, []string{"--db-filename", "temporal.db"}, newCache.TemporalServerOptions)
+		assert.Equal(t, []string{"Temporal server options changed: [] → [--db-filename temporal.db]"}, changes)
+
+		changed, _, _ = hasConfigChanged(config, newCache)
+		assert.False(t, changed)
+	})
+
+	t.Run("Invalid server options should be reported", func(t *testing.T) {
+		data := "components:\n  temporal:\n    persist: false\n    dbFile: temporal.db\n    logLevel: verbose\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 2) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "persist is false but components.temporal.dbFile is set")
+		assert.Contains(t, errs[1].Message, `logLevel "verbose" is not one of debug, info, warn, error, never`)
+	})
+}
+
+// fakeTemporal is an in-memory Temporal server answering the operator commands
+type fakeTemporal struct {
+	namespaces map[string]*temporalNamespaceState
+	calls      []string
+}
+
+// flag returns the value of a --name flag in args
+func (f *fakeTemporal) flag(args []string, name string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == name {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func (f *fakeTemporal) run(ctx context.Context, args ...string) ([]byte, error) {
+	command := strings.Join(args[:3], " ")
+	name := f.flag(args, "--namespace")
+	namespace := f.namespaces[name]
+	if command != "operator namespace describe" && command != "operator search-attribute list" {
+		f.calls = append(f.calls, strings.Join(args, " "))
+	}
+
+	switch command {
+	case "operator namespace describe", "operator search-attribute list":
+		if namespace == nil {
+			return nil, fmt.Errorf("exit status 1: Namespace %s is not found", name)
+		}
+		if command == "operator namespace describe" {
+			return json.Marshal(map[string]interface{}{
+				"namespaceInfo": map[string]string{"name": name, "description": namespace.Description},
+				"config":        map[string]string{"workflowExecutionRetentionTtl": fmt.Sprintf("%.0fs", namespace.Retention.Seconds())},
+			})
+		}
+		attributes := map[string]string{}
+		for attribute, attributeType := range namespace.SearchAttributes {
+			attributes[attribute] = "INDEXED_VALUE_TYPE_" + strings.ToUpper(attributeType)
+		}
+		return json.Marshal(map[string]interface{}{"customAttributes": attributes})
+	case "operator namespace create":
+		namespace = &temporalNamespaceState{Retention: 72 * time.Hour, SearchAttributes: map[string]string{}}
+		f.namespaces[name] = namespace
+		fallthrough
+	case "operator namespace update":
+		if retention := f.flag(args, "--retention"); retention != "" {
+			namespace.Retention, _ = time.ParseDuration(retention)
+		}
+		if description := f.flag(args, "--description"); description != "" {
+			namespace.Description = description
+		}
+	case "operator search-attribute create":
+		namespace.SearchAttributes[f.flag(args, "--name")] = f.flag(args, "--type")
+	}
+	return nil, nil
+}
+
+// TestTemporalNamespaces tests reconciling the namespaces declared in localenv.yaml
+func TestTemporalNamespaces(t *testing.T) {
+	origCLI := temporalCLI
+	defer func() { temporalCLI = origCLI }()
+
+	fake := &fakeTemporal{namespaces: map[string]*temporalNamespaceState{
+		"default": {Retention: 24 * time.Hour, SearchAttributes: map[string]string{"CustomKeywordField": "Keyword"}},
+	}}
+	temporalCLI = fake.run
+
+	config := LocalEnvConfig{}
+	config.Components.Temporal.Namespace = "legacy"
+	config.Components.Temporal.Namespaces = []TemporalNamespaceConfig{
+		{Name: "orders", Description: "Order workflows", Retention: "7d", SearchAttributes: map[string]string{"OrderId": "keyword", "Total": "Double"}},
+		{Name: "default", SearchAttributes: map[string]string{"CustomKeywordField": "Keyword"}},
+	}
+	env := &LocalEnv{Config: config, ConfigLoaded: true}
+
+	t.Run("Single namespace setting should still be managed", func(t *testing.T) {
+		names := []string{}
+		for _, namespace := range temporalNamespaces(config) {
+			names = append(names, namespace.Name)
+		}
+		assert.Equal(t, []string{"legacy", "orders", "default"}, names)
+	})
+
+	t.Run("Retention and search attribute types should be parsed", func(t *testing.T) {
+		retention, err := parseRetention("7d")
+		assert.NoError(t, err)
+		assert.Equal(t, 168*time.Hour, retention)
+		_, err = parseRetention("forever")
+		assert.Error(t, err)
+
+		assert.Equal(t, "KeywordList", searchAttributeType("INDEXED_VALUE_TYPE_KEYWORD_LIST"))
+		assert.Equal(t, "Datetime", searchAttributeType("datetime"))
+		assert.Empty(t, searchAttributeType("Uuid"))
+	})
+
+	t.Run("Missing namespaces should be created with their settings", func(t *testing.T) {
+		assert.NoError(t, reconcileTemporalNamespaces(env))
+		assert.Equal(t, []string{
+			"operator namespace create --address localhost:7233 --namespace legacy",
+			"operator namespace create --address localhost:7233 --namespace orders --retention 168h0m0s --description Order workflows",
+			"operator search-attribute create --address localhost:7233 --namespace orders --name OrderId --type Keyword",
+			"operator search-attribute create --address localhost:7233 --namespace orders --name Total --type Double",
+		}, fake.calls)
+	})
+
+	t.Run("Reconciling again should not change anything", func(t *testing.T) {
+		fake.calls = nil
+		assert.NoError(t, reconcileTemporalNamespaces(env))
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("Drift should be reported and reconciled", func(t *testing.T) {
+		orders := fake.namespaces["orders"]
+		orders.Retention = 72 * time.Hour
+		orders.SearchAttributes["Total"] = "Int"
+		delete(orders.SearchAttributes, "OrderId")
+
+		actual, err := describeTemporalNamespace(context.Background(), "localhost:7233", "orders")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"retention is 72h0m0s, declared 168h0m0s",
+			"search attribute OrderId is missing",
+			"search attribute Total is Int, declared Double",
+		}, temporalNamespaceDrift(config.Components.Temporal.Namespaces[0], actual))
+
+		fake.calls = nil
+		assert.NoError(t, reconcileTemporalNamespaces(env))
+		assert.Equal(t, []string{
+			"operator namespace update --address localhost:7233 --namespace orders --retention 168h0m0s --description Order workflows",
+			"operator search-attribute create --address localhost:7233 --namespace orders --name OrderId --type Keyword",
+		}, fake.calls, "the type of an existing search attribute can't be changed")
+	})
+
+	t.Run("Missing namespaces should be reported as drift", func(t *testing.T) {
+		actual, err := describeTemporalNamespace(context.Background(), "localhost:7233", "missing")
+		assert.NoError(t, err)
+		assert.Nil(t, actual)
+		assert.Equal(t, []string{"namespace does not exist"}, temporalNamespaceDrift(TemporalNamespaceConfig{Name: "missing"}, actual))
+	})
+
+	t.Run("Invalid namespaces should be reported", func(t *testing.T) {
+		data := "components:\n  temporal:\n    namespaces:\n      - name: orders\n        retention: forever\n      - name: orders\n        searchAttributes:\n          OrderId: Uuid\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 3) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, `retention "forever" is not a duration like 72h or 7d`)
+		assert.Contains(t, errs[1].Message, `Temporal namespace "orders" is declared more than once`)
+		assert.Contains(t, errs[2].Message, `search attribute OrderId of namespace "orders" has unknown type "Uuid"`)
+	})
+}
+
+// TestTemporalHealth tests that health checks target the profile's server
+func TestTemporalHealth(t *testing.T) {
+	origCLI := temporalCLI
+	defer func() { temporalCLI = origCLI }()
+
+	var addresses []string
+	temporalCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+		for i, arg := range args {
+			if arg == "--address" && i+1 < len(args) {
+				addresses = append(addresses, args[i+1])
+			}
+		}
+		if len(addresses) == 0 || addresses[len(addresses)-1] != "localhost:7233" {
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	}
+
+	t.Run("A server on another profile's port should not count as running", func(t *testing.T) {
+		addresses = nil
+		env := &LocalEnv{}
+		env.Config.Components.Temporal.GRPCPort = 17233
+
+		assert.Error(t, temporalDriver{}.Health(env))
+		assert.Equal(t, []string{"localhost:17233"}, addresses, "only this profile's port should be checked")
+	})
+
+	t.Run("The default port should be checked without a configured port", func(t *testing.T) {
+		addresses = nil
+		assert.NoError(t, temporalDriver{}.Health(&LocalEnv{}))
+		assert.Equal(t, []string{"localhost:7233"}, addresses)
+	})
+}
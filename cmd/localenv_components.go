@@ -0,0 +1,285 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ComponentDriver manages the lifecycle of a single localenv component.
+// The start, stop, status and logs commands all iterate the same registry
+// of drivers, so adding a component only requires adding a driver.
+// Start order is derived from Dependencies, see sortComponents.
+type ComponentDriver interface {
+	// Name returns the display name of the component (e.g. "Temporal")
+	Name() string
+	// Dependencies returns the names of components that must be started first
+	Dependencies() []string
+	// Installed reports whether the tooling needed by the component is available
+	Installed() bool
+	// Enabled reports whether the component should be managed for this invocation
+	Enabled(env *LocalEnv) bool
+	// Start brings the component up, or leaves it running if it already is
+	Start(env *LocalEnv) error
+	// Stop shuts the component down
+	Stop(env *LocalEnv) error
+	// Status reports the current state of the component without side effects
+	Status(env *LocalEnv) ComponentStatus
+	// LogSources returns the log files and containers holding the component
+	// logs, or nothing if they aren't captured
+	LogSources(env *LocalEnv) []LogSource
+	// Health returns nil if the component is running and responding
+	Health(env *LocalEnv) error
+	// Readiness declares the probe Start waits on after launching the component
+	Readiness(env *LocalEnv) Readiness
+}
+
+// aliasedComponent is implemented by drivers that accept alternative names
+// on the command line (e.g. "temporal-server" for Temporal)
+type aliasedComponent interface {
+	Aliases() []string
+}
+
+// LocalEnv carries the configuration and command options shared by every
+// component driver during a single localenv command invocation
+type LocalEnv struct {
+	// Context is cancelled when the command is interrupted, e.g. with Ctrl+C.
+	// Component operations stop waiting once it is done.
+	Context context.Context
+
+	Config       LocalEnvConfig
+	ConfigPath   string
+	ConfigLoaded bool
+	Verbose      bool
+
+	// Skip holds component keys disabled by --skip-* flags (e.g. "dapr", "opensearch")
+	Skip map[string]bool
+
+	// State records the processes and containers started by localenv
+	State *LocalEnvState
+	// Aggressive allows finding components by process name and port when
+	// they aren't recorded in State
+	Aggressive bool
+
+	// Options used by start
+	ForceRestart  bool
+	StreamLogs    bool
+	PreviousCache ConfigCache
+	ConfigChanged bool
+	Changes       []string
+
+	// Options used by stop
+	Force     bool
+	CleanLogs bool
+}
+
+// ctx returns the context of the invocation, which is never done when the
+// command doesn't handle interrupts
+func (env *LocalEnv) ctx() context.Context {
+	if env.Context == nil {
+		return context.Background()
+	}
+	return env.Context
+}
+
+// ComponentStatus describes the observed state of a component. It is part of
+// the `localenv status --output json|yaml` schema, so fields should only be added.
+type ComponentStatus struct {
+	Name       string              `json:"name" yaml:"name"`
+	Enabled    bool                `json:"enabled" yaml:"enabled"`
+	Running    bool                `json:"running" yaml:"running"`
+	Healthy    bool                `json:"healthy" yaml:"healthy"`
+	Message    string              `json:"message" yaml:"message"` // Short state description shown next to the name
+	PIDs       []int               `json:"pids" yaml:"pids"`
+	Containers []string            `json:"containers" yaml:"containers"`
+	Ports      []int               `json:"ports" yaml:"ports"`
+	Namespace  string              `json:"namespace,omitempty" yaml:"namespace,omitempty"` // Temporal only
+	Endpoints  []ComponentEndpoint `json:"endpoints" yaml:"endpoints"`
+	Details    []string            `json:"details" yaml:"details"`                 // Additional human readable lines
+	Drift      []string            `json:"drift,omitempty" yaml:"drift,omitempty"` // Temporal only: differences from the declared namespaces
+}
+
+// ComponentEndpoint is a URL or address exposed by a component
+type ComponentEndpoint struct {
+	Name       string `json:"name" yaml:"name"`
+	URL        string `json:"url" yaml:"url"`
+	Accessible bool   `json:"accessible" yaml:"accessible"`
+}
+
+// LogOptions controls how component logs are displayed
+type LogOptions struct {
+	Follow bool
+	Lines  int
+	// Since skips container logs older than this time when set
+	Since time.Time
+	// Timestamps prefixes container log lines with their RFC 3339 timestamp
+	Timestamps bool
+	// Previous includes the rotated segments of log files, oldest first
+	Previous bool
+}
+
+// LogSource is a log file or container holding logs of a component
+type LogSource struct {
+	// Name prefixes the lines of the source when several are shown
+	Name      string
+	File      string
+	Container string
+}
+
+// errComponentNotRunning is returned by Stop when there was nothing to stop
+var errComponentNotRunning = errors.New("component is not running")
+
+// componentRegistry holds the registered drivers in registration order
+var componentRegistry []ComponentDriver
+
+func init() {
+	// Registration order breaks ties between independent components
+	registerComponent(daprDriver{})
+	registerComponent(daprDashboardDriver{})
+	registerComponent(temporalDriver{})
+	registerComponent(openSearchDriver{})
+	registerComponent(openSearchDashboardDriver{})
+}
+
+// registerComponent adds a driver to the registry
+func registerComponent(driver ComponentDriver) {
+	for _, existing := range componentRegistry {
+		if strings.EqualFold(existing.Name(), driver.Name()) {
+			panic(fmt.Sprintf("component %q registered twice", driver.Name()))
+		}
+	}
+	componentRegistry = append(componentRegistry, driver)
+}
+
+// registeredComponents returns all registered drivers in registration order
+func registeredComponents() []ComponentDriver {
+	drivers := make([]ComponentDriver, len(componentRegistry))
+	copy(drivers, componentRegistry)
+	return drivers
+}
+
+// findComponent looks up a driver by name or alias, ignoring case
+func findComponent(name string) (ComponentDriver, bool) {
+	for _, driver := range componentRegistry {
+		if strings.EqualFold(driver.Name(), name) {
+			return driver, true
+		}
+		if aliased, ok := driver.(aliasedComponent); ok {
+			for _, alias := range aliased.Aliases() {
+				if strings.EqualFold(alias, name) {
+					return driver, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// loadLocalEnvConfig reads and parses the localenv configuration file.
+// It returns loaded=false without an error when the file doesn't exist.
+func loadLocalEnvConfig(configPath string) (LocalEnvConfig, bool, error) {
+	config := LocalEnvConfig{}
+
+	if _, err := os.Stat(configPath); err != nil {
+		if localenvProfile != "" {
+			return config, false, fmt.Errorf("profile %q requires a configuration file, %s not found", localenvProfile, configPath)
+		}
+		return config, false, nil
+	}
+
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return config, false, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	config, err = parseLocalEnvConfig(configPath, configData, localenvProfile)
+	if err != nil {
+		return LocalEnvConfig{}, false, err
+	}
+
+	return config, true, nil
+}
+
+// logsDir returns the directory where devhelper-cli writes component logs
+// for the active profile
+func logsDir() string {
+	return profileDir(filepath.Join(os.Getenv("HOME"), ".logs", "devhelper-cli"), localenvProfile)
+}
+
+// localenvTool is a prerequisite binary that is verified but never started by localenv
+type localenvTool struct {
+	Name     string
+	Command  string
+	Verify   func(verbose bool) bool
+	ReadyMsg string
+	FailMsg  string
+	FailHint string
+}
+
+// localenvTools lists the tools every local environment depends on,
+// starting with the selected container runtime
+func localenvTools() []localenvTool {
+	return []localenvTool{
+		{
+			Name:     containerRuntime.Label(),
+			Command:  containerRuntime.Name(),
+			Verify:   checkContainerRuntime,
+			ReadyMsg: "is available and can run containers.",
+			FailMsg:  "is not working properly.",
+			FailHint: fmt.Sprintf("Make sure %s is installed correctly and has proper permissions.", containerRuntime.Label()),
+		},
+		{
+			Name:     "Kind",
+			Command:  "kind",
+			Verify:   checkKindRunning,
+			ReadyMsg: "is available and can create clusters.",
+			FailMsg:  "does not have any clusters configured.",
+			FailHint: "Note: Kubernetes functionality is not required for local development.",
+		},
+	}
+}
+
+// checkContainerRuntime verifies that the container runtime can list containers
+func checkContainerRuntime(verbose bool) bool {
+	if err := containerRuntime.Ping(context.Background()); err != nil {
+		if verbose {
+			fmt.Printf("%s check failed: %v\n", containerRuntime.Label(), err)
+		}
+		return false
+	}
+	return true
+}
+
+// checkKindRunning verifies that Kind has at least one cluster
+func checkKindRunning(verbose bool) bool {
+	checkCmd := exec.Command("kind", "get", "clusters")
+	output, err := checkCmd.CombinedOutput()
+	if err != nil {
+		if verbose {
+			fmt.Printf("Kind check failed: %v\n", err)
+		}
+		return false
+	}
+	// Check if there's at least one cluster
+	return len(strings.TrimSpace(string(output))) > 0
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestComponentRegistry tests the localenv component driver registry
+func TestComponentRegistry(t *testing.T) {
+	t.Run("Built-in components should be registered in start order", func(t *testing.T) {
+		names := []string{}
+		for _, driver := range registeredComponents() {
+			names = append(names, driver.Name())
+		}
+		assert.Equal(t, []string{"Dapr", "DaprDashboard", "Temporal", "OpenSearch", "OpenSearchDashboard"}, names)
+	})
+
+	t.Run("findComponent should match names and aliases ignoring case", func(t *testing.T) {
+		driver, ok := findComponent("temporal")
+		assert.True(t, ok, "temporal should be found")
+		assert.Equal(t, "Temporal", driver.Name())
+
+		driver, ok = findComponent("temporal-server")
+		assert.True(t, ok, "temporal-server alias should be found")
+		assert.Equal(t, "Temporal", driver.Name())
+
+		driver, ok = findComponent("OPENSEARCH-DASHBOARD")
+		assert.True(t, ok, "opensearch-dashboard alias should be found")
+		assert.Equal(t, "OpenSearchDashboard", driver.Name())
+
+		_, ok = findComponent("unknown")
+		assert.False(t, ok, "unknown components should not be found")
+	})
+
+	t.Run("registerComponent should reject duplicate names", func(t *testing.T) {
+		assert.Panics(t, func() { registerComponent(temporalDriver{}) })
+		assert.Len(t, registeredComponents(), 5, "registry should be unchanged")
+	})
+
+	t.Run("Skip flags should override enabled components", func(t *testing.T) {
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{"dapr": true}}
+		env.Config.Components.Dapr.Enabled = true
+		env.Config.Components.Temporal.Enabled = true
+
+		assert.False(t, daprDriver{}.Enabled(env), "skipped Dapr should be disabled")
+		assert.True(t, temporalDriver{}.Enabled(env), "Temporal should be enabled")
+
+		env.Config.Components.Temporal.Enabled = false
+		assert.False(t, temporalDriver{}.Enabled(env), "Temporal disabled in config should be disabled")
+	})
+}
+
+// TestLoadLocalEnvConfig tests reading the localenv configuration file
+func TestLoadLocalEnvConfig(t *testing.T) {
+	tempDir := t.TempDir()
+
+	t.Run("Missing file should not be an error", func(t *testing.T) {
+		_, loaded, err := loadLocalEnvConfig(filepath.Join(tempDir, "missing.yaml"))
+		assert.NoError(t, err)
+		assert.False(t, loaded)
+	})
+
+	t.Run("Valid file should be parsed", func(t *testing.T) {
+		configPath := filepath.Join(tempDir, "localenv.yaml")
+		content := "components:\n  temporal:\n    enabled: true\n    namespace: orders\n"
+		assert.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+		config, loaded, err := loadLocalEnvConfig(configPath)
+		assert.NoError(t, err)
+		assert.True(t, loaded)
+		assert.True(t, config.Components.Temporal.Enabled)
+		assert.Equal(t, "orders", config.Components.Temporal.Namespace)
+	})
+
+	t.Run("Invalid file should return an error", func(t *testing.T) {
+		configPath := filepath.Join(tempDir, "invalid.yaml")
+		assert.NoError(t, os.WriteFile(configPath, []byte("components: [\n"), 0644))
+
+		_, loaded, err := loadLocalEnvConfig(configPath)
+		assert.Error(t, err)
+		assert.False(t, loaded)
+	})
+}
@@ -0,0 +1,706 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ConfigError is a problem found at a position in localenv.yaml
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// ConfigErrors is every problem found in localenv.yaml, in file order
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// openSearchVersionPattern matches the semver-ish tags of the OpenSearch images
+var openSearchVersionPattern = regexp.MustCompile(`^\d+\.\d+(\.\d+)?(-[0-9A-Za-z.-]+)?$`)
+
+// yamlLinePattern finds the line number in yaml.v3 syntax errors
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// configPort is a port setting and the default used when it's not set
+type configPort struct {
+	Path    string
+	Value   int
+	Default int
+	Used    bool // The component using the port is enabled
+}
+
+// localenvConfigCmd groups the commands working on localenv.yaml
+var localenvConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the local environment configuration file",
+	Long:  `Commands for checking the local environment configuration in localenv.yaml.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// localenvConfigValidateCmd validates localenv.yaml
+var localenvConfigValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate localenv.yaml",
+	Long: `Validate the local environment configuration and every profile in it.
+
+Unknown fields, values of the wrong type, ports outside 1-65535, ports used by
+more than one component, invalid OpenSearch versions, empty or duplicate
+Temporal namespaces, invalid retention periods, unknown search attribute types
+and unknown Temporal log levels are reported with their line and column in the file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			fmt.Printf("❌ Failed to read configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		profiles, err := validateLocalEnvConfigFile(configPath, data)
+		if err != nil {
+			printConfigError(configPath, err)
+			os.Exit(1)
+		}
+
+		if len(profiles) > 0 {
+			fmt.Printf("✅ %s is valid (profiles: %s)\n", configPath, strings.Join(profiles, ", "))
+		} else {
+			fmt.Printf("✅ %s is valid\n", configPath)
+		}
+	},
+}
+
+// printConfigError prints a configuration error, one line per problem
+func printConfigError(configPath string, err error) {
+	var configErrs ConfigErrors
+	if !errors.As(err, &configErrs) {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("❌ %s has %d problem(s):\n", configPath, len(configErrs))
+	for _, configErr := range configErrs {
+		fmt.Printf("   %v\n", configErr)
+	}
+}
+
+// parseLocalEnvConfig strictly decodes localenv.yaml, applies the profile
+// and validates the result. Problems are returned as ConfigErrors.
+func parseLocalEnvConfig(configPath string, data []byte, profile string) (LocalEnvConfig, error) {
+	config := LocalEnvConfig{}
+
+	root, err := parseConfigNode(configPath, data)
+	if err != nil {
+		return config, err
+	}
+	if root == nil {
+		// An empty file is a configuration with every setting at its default
+		return config, nil
+	}
+
+	errs := checkConfigNode(configPath, root, reflect.TypeOf(config), "")
+	if len(errs) > 0 {
+		return config, errs
+	}
+	if err := root.Decode(&config); err != nil {
+		return config, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	var overlay *yamlv3.Node
+	if profile != "" {
+		if err := applyProfile(&config, profile); err != nil {
+			return LocalEnvConfig{}, err
+		}
+		node := config.Profiles[profile]
+		overlay = &node
+	}
+
+	if errs := validateConfigValues(configPath, config, overlay, root); len(errs) > 0 {
+		return config, errs
+	}
+	return config, nil
+}
+
+// validateLocalEnvConfigFile validates the top-level configuration and every
+// profile, returning the profile names
+func validateLocalEnvConfigFile(configPath string, data []byte) ([]string, error) {
+	config, err := parseLocalEnvConfig(configPath, data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := profileNames(config)
+	errs := ConfigErrors{}
+	for _, profile := range profiles {
+		if err := validateProfileName(profile); err != nil {
+			node := config.Profiles[profile]
+			errs = append(errs, ConfigError{File: configPath, Line: node.Line, Message: err.Error()})
+			continue
+		}
+
+		_, err := parseLocalEnvConfig(configPath, data, profile)
+		var profileErrs ConfigErrors
+		switch {
+		case errors.As(err, &profileErrs):
+			for _, profileErr := range profileErrs {
+				profileErr.Message = fmt.Sprintf("profile %s: %s", profile, profileErr.Message)
+				errs = append(errs, profileErr)
+			}
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, dedupeConfigErrors(errs)
+	}
+	return profiles, nil
+}
+
+// parseConfigNode parses the YAML document, converting syntax errors to
+// ConfigErrors. It returns nil for an empty document.
+func parseConfigNode(configPath string, data []byte) (*yamlv3.Node, error) {
+	document := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, document); err != nil {
+		if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			return nil, ConfigErrors{{File: configPath, Line: line, Message: match[2]}}
+		}
+		return nil, ConfigErrors{{File: configPath, Message: strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+
+	if document.Kind != yamlv3.DocumentNode || len(document.Content) == 0 {
+		return nil, nil
+	}
+	return document.Content[0], nil
+}
+
+// checkConfigNode reports unknown fields and values of the wrong type by
+// walking the YAML nodes alongside the configuration struct
+func checkConfigNode(configPath string, node *yamlv3.Node, t reflect.Type, path string) ConfigErrors {
+	errs := ConfigErrors{}
+	fail := func(n *yamlv3.Node, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{File: configPath, Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!null" {
+		return errs
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			fail(node, "%s must be a mapping", displayPath(path))
+			return errs
+		}
+
+		fields := map[string]reflect.StructField{}
+		names := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields[name] = field
+			names = append(names, name)
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok || (key.Value == "profiles" && path != "") {
+				message := fmt.Sprintf("unknown field %q in %s", key.Value, displayPath(path))
+				if suggestion := closestName(key.Value, names); suggestion != "" {
+					message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				fail(key, "%s", message)
+				continue
+			}
+
+			if key.Value == "profiles" {
+				errs = append(errs, checkProfileNodes(configPath, value, t)...)
+				continue
+			}
+			errs = append(errs, checkConfigNode(configPath, value, field.Type, joinPath(path, key.Value))...)
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			fail(node, "%s must be a mapping", displayPath(path))
+			return errs
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkConfigNode(configPath, node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			fail(node, "%s must be a list", displayPath(path))
+			return errs
+		}
+		for i, item := range node.Content {
+			errs = append(errs, checkConfigNode(configPath, item, t.Elem(), joinPath(path, strconv.Itoa(i)))...)
+		}
+	case reflect.Int:
+		if node.Kind != yamlv3.ScalarNode || node.ShortTag() != "!!int" {
+			fail(node, "%s must be an integer, got %q", displayPath(path), node.Value)
+		}
+	case reflect.Bool:
+		if node.Kind != yamlv3.ScalarNode || node.ShortTag() != "!!bool" {
+			fail(node, "%s must be true or false, got %q", displayPath(path), node.Value)
+		}
+	case reflect.String:
+		if node.Kind != yamlv3.ScalarNode {
+			fail(node, "%s must be a string", displayPath(path))
+		}
+	}
+	return errs
+}
+
+// checkProfileNodes checks each profile overlay against the configuration struct
+func checkProfileNodes(configPath string, node *yamlv3.Node, t reflect.Type) ConfigErrors {
+	if node.Kind != yamlv3.MappingNode {
+		return ConfigErrors{{File: configPath, Line: node.Line, Column: node.Column, Message: "profiles must be a mapping of profile names to settings"}}
+	}
+
+	errs := ConfigErrors{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		errs = append(errs, checkConfigNode(configPath, node.Content[i+1], t, "profiles."+name)...)
+	}
+	return errs
+}
+
+// validateConfigValues checks the semantics of a decoded configuration.
+// Positions are looked up in the profile overlay first, then in root.
+func validateConfigValues(configPath string, config LocalEnvConfig, overlay, root *yamlv3.Node) ConfigErrors {
+	errs := ConfigErrors{}
+	fail := func(path, format string, args ...interface{}) {
+		line, column := 0, 0
+		if node := findConfigNode(overlay, path); node != nil {
+			line, column = node.Line, node.Column
+		} else if node := findConfigNode(root, path); node != nil {
+			line, column = node.Line, node.Column
+		}
+		errs = append(errs, ConfigError{File: configPath, Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+	isSet := func(path string) bool {
+		return findConfigNode(overlay, path) != nil || findConfigNode(root, path) != nil
+	}
+
+	components := config.Components
+	ports := []configPort{
+		{"components.dapr.dashboardPort", components.Dapr.DashboardPort, 8080, components.Dapr.Enabled && components.Dapr.Dashboard},
+		{"components.dapr.zipkinPort", components.Dapr.ZipkinPort, 9411, components.Dapr.Enabled},
+		{"components.temporal.uiPort", components.Temporal.UIPort, 8233, components.Temporal.Enabled},
+		{"components.temporal.grpcPort", components.Temporal.GRPCPort, 7233, components.Temporal.Enabled},
+		{"components.openSearch.port", components.OpenSearch.Port, 9200, components.OpenSearch.Enabled},
+		{"components.openSearch.dashboardPort", components.OpenSearch.DashboardPort, 5601, components.OpenSearch.Enabled},
+	}
+
+	customNames := []string{}
+	for name := range components.Custom {
+		customNames = append(customNames, name)
+	}
+	sort.Strings(customNames)
+
+	for _, name := range customNames {
+		custom := components.Custom[name]
+		path := "components.custom." + name
+		for i, mapping := range custom.Ports {
+			portPath := fmt.Sprintf("%s.ports.%d", path, i)
+			hostPort, containerPort, err := parsePortMapping(mapping)
+			if err != nil {
+				fail(portPath, "%s: %v", portPath, err)
+				continue
+			}
+			if containerPort < 1 || containerPort > 65535 {
+				fail(portPath, "%s container port must be between 1 and 65535, got %d", portPath, containerPort)
+				continue
+			}
+			ports = append(ports, configPort{portPath, hostPort, hostPort, custom.Enabled})
+		}
+	}
+
+	appNames := []string{}
+	for name := range config.Apps {
+		appNames = append(appNames, name)
+	}
+	sort.Strings(appNames)
+
+	for _, name := range appNames {
+		app := config.Apps[name]
+		path := "apps." + name
+		for _, port := range []configPort{
+			{path + ".appPort", app.AppPort, 0, true},
+			{path + ".dapr.httpPort", app.Dapr.HTTPPort, 0, true},
+			{path + ".dapr.grpcPort", app.Dapr.GRPCPort, 0, true},
+		} {
+			// Unset ports are chosen by dapr run
+			if port.Value != 0 || isSet(port.Path) {
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	usedBy := map[int]string{}
+	for _, port := range ports {
+		if isSet(port.Path) && (port.Value < 1 || port.Value > 65535) {
+			fail(port.Path, "%s must be between 1 and 65535, got %d", port.Path, port.Value)
+			continue
+		}
+		if !port.Used {
+			continue
+		}
+
+		effective := port.Value
+		if effective == 0 {
+			effective = port.Default
+		}
+		if other, ok := usedBy[effective]; ok {
+			fail(port.Path, "port %d of %s is already used by %s", effective, port.Path, other)
+			continue
+		}
+		usedBy[effective] = port.Path
+	}
+
+	version := components.OpenSearch.Version
+	switch {
+	case version == "" && components.OpenSearch.Enabled:
+		fail("components.openSearch.version", "components.openSearch.version is required when OpenSearch is enabled")
+	case version != "" && !openSearchVersionPattern.MatchString(version):
+		fail("components.openSearch.version", "components.openSearch.version %q is not a version like 2.17.1", version)
+	}
+
+	if runtime := config.Tools.ContainerRuntime; runtime != "" && !slices.Contains(containerRuntimeNames(), runtime) {
+		fail("tools.containerRuntime", "tools.containerRuntime %q is not supported, use %s", runtime, strings.Join(containerRuntimeNames(), " or "))
+	}
+
+	if isSet("components.temporal.namespace") && strings.TrimSpace(components.Temporal.Namespace) == "" {
+		fail("components.temporal.namespace", "components.temporal.namespace must not be empty")
+	}
+
+	daprNames := []string{}
+	for name := range components.Dapr.Components {
+		daprNames = append(daprNames, name)
+	}
+	sort.Strings(daprNames)
+	funcs := daprTemplateFuncs(daprComponentPorts(config))
+	for _, name := range daprNames {
+		component := components.Dapr.Components[name]
+		path := "components.dapr.components." + name
+		if !profileNamePattern.MatchString(name) {
+			fail(path, "invalid Dapr component name %q: use lowercase letters, digits and dashes", name)
+		}
+		if !strings.Contains(component.Type, ".") {
+			fail(path+".type", "%s.type must be a Dapr component type like state.redis, got %q", path, component.Type)
+		}
+		if _, err := renderDaprComponent(name, component, funcs); err != nil {
+			fail(path+".metadata", "%s: %v", path, err)
+		}
+	}
+
+	temporal := components.Temporal
+	namespaceNames := map[string]bool{}
+	for i, namespace := range temporal.Namespaces {
+		path := fmt.Sprintf("components.temporal.namespaces.%d", i)
+		switch {
+		case strings.TrimSpace(namespace.Name) == "":
+			fail(path, "%s.name is required", path)
+		case namespaceNames[namespace.Name]:
+			fail(path+".name", "Temporal namespace %q is declared more than once", namespace.Name)
+		}
+		namespaceNames[namespace.Name] = true
+
+		if namespace.Retention != "" {
+			if _, err := parseRetention(namespace.Retention); err != nil {
+				fail(path+".retention", "%s.retention %v", path, err)
+			}
+		}
+		for _, attribute := range sortedSearchAttributes(namespace) {
+			if searchAttributeType(namespace.SearchAttributes[attribute]) == "" {
+				fail(path+".searchAttributes."+attribute, "search attribute %s of namespace %q has unknown type %q, use one of %s",
+					attribute, namespace.Name, namespace.SearchAttributes[attribute], strings.Join(temporalSearchAttributeTypes, ", "))
+			}
+		}
+	}
+	if isSet("components.temporal.persist") && !temporal.Persist && temporal.DBFile != "" {
+		fail("components.temporal.persist", "components.temporal.persist is false but components.temporal.dbFile is set")
+	}
+	if temporal.LogLevel != "" && !slices.Contains(temporalLogLevels, temporal.LogLevel) {
+		fail("components.temporal.logLevel", "components.temporal.logLevel %q is not one of %s", temporal.LogLevel, strings.Join(temporalLogLevels, ", "))
+	}
+	dynamicKeys := []string{}
+	for key := range temporal.DynamicConfig {
+		dynamicKeys = append(dynamicKeys, key)
+	}
+	sort.Strings(dynamicKeys)
+	for _, key := range dynamicKeys {
+		if strings.TrimSpace(key) == "" || strings.Contains(key, "=") {
+			fail("components.temporal.dynamicConfig", "components.temporal.dynamicConfig has an invalid key %q", key)
+		}
+	}
+
+	for _, name := range customNames {
+		validateCustomComponent(name, components.Custom, fail)
+	}
+	for _, name := range appNames {
+		validateApp(name, config, fail, isSet)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	return errs
+}
+
+// validateCustomComponent checks a components.custom entry, reporting problems with fail
+func validateCustomComponent(name string, customs map[string]CustomComponentConfig, fail func(path, format string, args ...interface{})) {
+	custom := customs[name]
+	path := "components.custom." + name
+
+	if !profileNamePattern.MatchString(name) {
+		fail(path, "invalid custom component name %q: use lowercase letters, digits and dashes", name)
+	}
+	if _, ok := findComponent(name); ok {
+		fail(path, "custom component %q has the name of a built-in component", name)
+	}
+	if custom.Enabled && strings.TrimSpace(custom.Image) == "" {
+		fail(path+".image", "%s.image is required when the component is enabled", path)
+	}
+
+	for i, dependency := range custom.DependsOn {
+		dependencyPath := fmt.Sprintf("%s.dependsOn.%d", path, i)
+		_, builtIn := findComponent(dependency)
+		_, declared := customs[dependency]
+		switch {
+		case dependency == name:
+			fail(dependencyPath, "%s depends on itself", path)
+		case !builtIn && !declared:
+			fail(dependencyPath, "%s depends on unknown component %q", path, dependency)
+		}
+	}
+
+	check := custom.HealthCheck
+	checks := 0
+	for _, set := range []bool{check.HTTP != "", check.TCP != "", check.Command != ""} {
+		if set {
+			checks++
+		}
+	}
+	if checks > 1 {
+		fail(path+".healthCheck", "%s.healthCheck must set only one of http, tcp and command", path)
+	}
+	if check.HTTP != "" {
+		if parsed, err := url.Parse(check.HTTP); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			fail(path+".healthCheck.http", "%s.healthCheck.http %q is not an http(s) URL", path, check.HTTP)
+		}
+	}
+	if check.Timeout < 0 {
+		fail(path+".healthCheck.timeout", "%s.healthCheck.timeout must not be negative", path)
+	}
+}
+
+// validateApp checks an apps entry, reporting problems with fail
+func validateApp(name string, config LocalEnvConfig, fail func(path, format string, args ...interface{}), isSet func(path string) bool) {
+	app := config.Apps[name]
+	path := "apps." + name
+
+	if !profileNamePattern.MatchString(name) {
+		fail(path, "invalid app name %q: use lowercase letters, digits and dashes", name)
+	}
+	_, builtIn := findComponent(name)
+	if _, custom := config.Components.Custom[name]; builtIn || custom {
+		fail(path, "app %q has the name of a component", name)
+	}
+	if len(app.Command) == 0 || strings.TrimSpace(app.Command[0]) == "" {
+		fail(path+".command", "%s.command is required", path)
+	}
+	if app.AppProtocol != "" && !slices.Contains(appProtocols, app.AppProtocol) {
+		fail(path+".appProtocol", "%s.appProtocol %q is not one of %s", path, app.AppProtocol, strings.Join(appProtocols, ", "))
+	}
+	if isSet(path+".build") && (len(app.Build) == 0 || strings.TrimSpace(app.Build[0]) == "") {
+		fail(path+".build", "%s.build must not be empty", path)
+	}
+	if debounce := app.Watch.Debounce; debounce != "" {
+		if value, err := time.ParseDuration(debounce); err != nil || value <= 0 {
+			fail(path+".watch.debounce", "%s.watch.debounce %q is not a duration like 500ms", path, debounce)
+		}
+	}
+	for i, pattern := range app.Watch.Ignore {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			fail(fmt.Sprintf("%s.watch.ignore.%d", path, i), "%s.watch.ignore pattern %q is invalid: %v", path, pattern, err)
+		}
+	}
+
+	for i, dependency := range app.DependsOn {
+		dependencyPath := fmt.Sprintf("%s.dependsOn.%d", path, i)
+		_, builtIn := findComponent(dependency)
+		_, custom := config.Components.Custom[dependency]
+		_, otherApp := config.Apps[dependency]
+		switch {
+		case dependency == name:
+			fail(dependencyPath, "%s depends on itself", path)
+		case !builtIn && !custom && !otherApp:
+			fail(dependencyPath, "%s depends on unknown component or app %q", path, dependency)
+		}
+	}
+}
+
+// findConfigNode returns the value node at a dotted path in a mapping, or
+// nil. Numeric keys index into lists.
+func findConfigNode(node *yamlv3.Node, path string) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		if node.Kind == yamlv3.SequenceNode {
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[index]
+			continue
+		}
+		if node.Kind != yamlv3.MappingNode {
+			return nil
+		}
+		var next *yamlv3.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// dedupeConfigErrors drops repeated errors, which profiles inherit from the top level
+func dedupeConfigErrors(errs ConfigErrors) ConfigErrors {
+	seen := map[string]bool{}
+	unique := ConfigErrors{}
+	for _, err := range errs {
+		key := fmt.Sprintf("%d:%d:%s", err.Line, err.Column, err.Message)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, err)
+		}
+	}
+	return unique
+}
+
+// joinPath appends a key to a dotted configuration path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// displayPath names a configuration path in messages
+func displayPath(path string) string {
+	if path == "" {
+		return "the top level"
+	}
+	return path
+}
+
+// closestName returns the name within an edit distance of 2 of name, if any
+func closestName(name string, names []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range names {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func init() {
+	localenvCmd.AddCommand(localenvConfigCmd)
+	localenvConfigCmd.AddCommand(localenvConfigValidateCmd)
+	localenvConfigValidateCmd.Flags().StringP("config", "c", "", "Path to environment configuration file (default: localenv.yaml)")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigValidation tests strict decoding and validation of localenv.yaml
+func TestConfigValidation(t *testing.T) {
+	t.Run("Valid configuration should pass", func(t *testing.T) {
+		config, err := parseLocalEnvConfig("localenv.yaml", []byte(profileConfig), "")
+		assert.NoError(t, err)
+		assert.Equal(t, "2.11.0", config.Components.OpenSearch.Version)
+
+		profiles, err := validateLocalEnvConfigFile("localenv.yaml", []byte(profileConfig))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"search-heavy"}, profiles)
+	})
+
+	t.Run("Empty configuration should pass", func(t *testing.T) {
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(""), "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown field should point at its position", func(t *testing.T) {
+		data := "components:\n  dapr:\n    enabled: true\n    dashbordPort: 8080\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 1) {
+			return
+		}
+		assert.Equal(t, 4, errs[0].Line)
+		assert.Equal(t, 5, errs[0].Column)
+		assert.Contains(t, errs[0].Error(), `localenv.yaml:4:5: unknown field "dashbordPort"`)
+		assert.Contains(t, errs[0].Message, `did you mean "dashboardPort"?`)
+	})
+
+	t.Run("Wrong types should be reported", func(t *testing.T) {
+		data := "components:\n  temporal:\n    enabled: yes-please\n    uiPort: abc\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		assert.Len(t, errs, 2)
+	})
+
+	t.Run("Syntax errors should have a line", func(t *testing.T) {
+		data := "components:\n  dapr:\n    enabled: true\n   bad: [\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		if assert.True(t, errors.As(err, &errs)) && assert.NotEmpty(t, errs) {
+			assert.Greater(t, errs[0].Line, 0)
+		}
+	})
+
+	t.Run("Ports should be in range and unique", func(t *testing.T) {
+		data := `components:
+  temporal:
+    enabled: true
+    uiPort: 0
+  openSearch:
+    enabled: true
+    version: "2.17.1"
+    port: 9200
+    dashboardPort: 9200
+`
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 2) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "components.temporal.uiPort must be between 1 and 65535")
+		assert.Equal(t, 4, errs[0].Line)
+		assert.Contains(t, errs[1].Message, "already used by components.openSearch.port")
+		assert.Equal(t, 9, errs[1].Line)
+	})
+
+	t.Run("Ports of disabled components should not collide", func(t *testing.T) {
+		data := "components:\n  temporal:\n    enabled: false\n    uiPort: 9200\n  openSearch:\n    enabled: true\n    version: \"2.17.1\"\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("OpenSearch version and Temporal namespace should be checked", func(t *testing.T) {
+		data := "components:\n  temporal:\n    namespace: \" \"\n  openSearch:\n    enabled: true\n    version: latest\n"
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 2) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "namespace must not be empty")
+		assert.Contains(t, errs[1].Message, `"latest" is not a version`)
+	})
+
+	t.Run("Profile errors should name the profile", func(t *testing.T) {
+		data := profileConfig + "  broken:\n    components:\n      openSearch:\n        port: 70000\n"
+		_, err := validateLocalEnvConfigFile("localenv.yaml", []byte(data))
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 1) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "profile broken:")
+		assert.Equal(t, 19, errs[0].Line)
+	})
+}
@@ -0,0 +1,347 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// snapshotImage runs tar against the data volume
+	snapshotImage = "docker.io/library/busybox:1.36"
+	// snapshotExt is the extension of snapshot archives
+	snapshotExt = ".tar.gz"
+	// restoreStaging is the directory in the data volume a snapshot is
+	// extracted to before it replaces the data
+	restoreStaging = ".localenv-restore"
+)
+
+// snapshotNamePattern matches valid snapshot names, which are also used in shell commands
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// dataSnapshot is an archive of the OpenSearch data volume
+type dataSnapshot struct {
+	Name    string
+	Size    int64
+	Created time.Time
+}
+
+// snapshotsDir returns where data snapshots are stored. Snapshots are shared
+// by all profiles so a seeded dataset can be restored into any of them.
+func snapshotsDir() string {
+	return filepath.Join(baseDataDir(), "snapshots")
+}
+
+// snapshotPath returns the archive of the named snapshot
+func snapshotPath(name string) string {
+	return filepath.Join(snapshotsDir(), name+snapshotExt)
+}
+
+// validateSnapshotName checks a snapshot name given on the command line
+func validateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: use letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// releaseOpenSearchData makes sure no container uses the data volume. A
+// running OpenSearch would change its files during a snapshot or restore,
+// while a stopped container is removed as start recreates it anyway.
+func releaseOpenSearchData(ctx context.Context) error {
+	container, err := containerRuntime.Inspect(ctx, openSearchContainerName())
+	if err != nil {
+		return nil
+	}
+	if container.Running {
+		return errors.New("OpenSearch is running, stop it first with 'devhelper-cli localenv stop'")
+	}
+	return containerRuntime.Remove(ctx, openSearchContainerName())
+}
+
+// runDataHelper runs a shell script in a throwaway container with the
+// OpenSearch data volume at /data and the snapshots directory at /snapshots
+func runDataHelper(ctx context.Context, script string) error {
+	name := profileResourceName("localenv-data-helper")
+	if containerExists(ctx, name) {
+		containerRuntime.Remove(ctx, name)
+	}
+
+	containerID, err := containerRuntime.Run(ctx, ContainerSpec{
+		Name:    name,
+		Image:   snapshotImage,
+		Command: []string{"sh", "-c", script},
+		Volumes: []string{openSearchDataVolumeName() + ":/data", snapshotsDir() + ":/snapshots"},
+	})
+	if err != nil {
+		return err
+	}
+	defer containerRuntime.Remove(ctx, containerID)
+
+	exitCode, err := containerRuntime.Wait(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		var output bytes.Buffer
+		containerRuntime.Logs(ctx, containerID, LogOptions{}, &output, &output)
+		return fmt.Errorf("exit status %d: %s", exitCode, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// snapshotData archives the OpenSearch data volume as the named snapshot
+func snapshotData(ctx context.Context, name string, force bool) error {
+	if err := validateSnapshotName(name); err != nil {
+		return err
+	}
+	if err := releaseOpenSearchData(ctx); err != nil {
+		return err
+	}
+	if !containerRuntime.VolumeExists(ctx, openSearchDataVolumeName()) {
+		return fmt.Errorf("there is no OpenSearch data in %s yet, start OpenSearch first", openSearchDataVolumeName())
+	}
+	if _, err := os.Stat(snapshotPath(name)); err == nil && !force {
+		return fmt.Errorf("snapshot %q already exists, use --force to replace it", name)
+	}
+	if err := os.MkdirAll(snapshotsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", snapshotsDir(), err)
+	}
+
+	// Write to a temporary file so a failed snapshot doesn't replace a good one
+	return runDataHelper(ctx, fmt.Sprintf("tar czf /snapshots/.%[1]s.tmp -C /data . && mv /snapshots/.%[1]s.tmp /snapshots/%[1]s%[2]s", name, snapshotExt))
+}
+
+// restoreData replaces the contents of the OpenSearch data volume with the named snapshot
+func restoreData(ctx context.Context, name string) error {
+	if err := validateSnapshotName(name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(snapshotPath(name)); err != nil {
+		return fmt.Errorf("snapshot %q not found, see 'devhelper-cli localenv data list'", name)
+	}
+	if err := releaseOpenSearchData(ctx); err != nil {
+		return err
+	}
+	return runDataHelper(ctx, restoreScript("/data", "/snapshots/"+name+snapshotExt))
+}
+
+// restoreScript returns the shell script replacing the contents of dataDir
+// with archive. The archive is fully extracted next to the data first, so a
+// corrupt or truncated snapshot fails before anything is deleted.
+func restoreScript(dataDir, archive string) string {
+	staging := dataDir + "/" + restoreStaging
+	return fmt.Sprintf(`set -e
+rm -rf '%[2]s' && mkdir '%[2]s'
+tar xzf '%[3]s' -C '%[2]s' || { rm -rf '%[2]s'; exit 1; }
+find '%[1]s' -mindepth 1 -maxdepth 1 ! -name '%[4]s' -exec rm -rf {} \;
+find '%[2]s' -mindepth 1 -maxdepth 1 -exec mv {} '%[1]s/' \;
+rmdir '%[2]s'`, dataDir, staging, archive, restoreStaging)
+}
+
+// listSnapshots returns the stored snapshots sorted by name
+func listSnapshots() ([]dataSnapshot, error) {
+	entries, err := os.ReadDir(snapshotsDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []dataSnapshot{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExt)
+		if !ok || entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, dataSnapshot{Name: name, Size: info.Size(), Created: info.ModTime()})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// resetData removes the OpenSearch data volume, reporting whether there was one
+func resetData(ctx context.Context) (bool, error) {
+	if err := releaseOpenSearchData(ctx); err != nil {
+		return false, err
+	}
+	if !containerRuntime.VolumeExists(ctx, openSearchDataVolumeName()) {
+		return false, nil
+	}
+	return true, containerRuntime.RemoveVolume(ctx, openSearchDataVolumeName())
+}
+
+// formatSize formats a size in bytes for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// selectDataRuntime selects the container runtime from the configuration given by --config
+func selectDataRuntime(cmd *cobra.Command) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	// If no config path is provided, look for localenv.yaml in current directory
+	if configPath == "" {
+		configPath = "localenv.yaml"
+	}
+	config, _, _ := loadLocalEnvConfig(configPath)
+	containerRuntime = selectContainerRuntime(config)
+}
+
+// localenvDataCmd groups the commands managing OpenSearch data
+var localenvDataCmd = &cobra.Command{
+	Use:   "data",
+	Short: "Snapshot, restore and reset OpenSearch data",
+	Long: `OpenSearch keeps its data in a named volume (opensearch-data, suffixed with the
+profile name when one is active), so indexed documents survive restarts.
+
+Snapshots archive the volume to ~/.local/share/devhelper-cli/snapshots so a
+seeded dataset can be shared, or restored after destructive tests. OpenSearch
+must be stopped while its data is snapshotted, restored or reset.
+
+Examples:
+  devhelper-cli localenv data snapshot seeded
+  devhelper-cli localenv data restore seeded
+  devhelper-cli localenv data list
+  devhelper-cli localenv data reset`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var localenvDataSnapshotCmd = &cobra.Command{
+	Use:   "snapshot <name>",
+	Short: "Archive the OpenSearch data as a named snapshot",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		selectDataRuntime(cmd)
+
+		fmt.Printf("📦 Creating snapshot %s from %s...\n", args[0], openSearchDataVolumeName())
+		if err := snapshotData(cmd.Context(), args[0], force); err != nil {
+			fmt.Printf("❌ Failed to create snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Snapshot saved to %s\n", snapshotPath(args[0]))
+	},
+}
+
+var localenvDataRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Replace the OpenSearch data with a snapshot",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		selectDataRuntime(cmd)
+
+		fmt.Printf("📦 Restoring snapshot %s into %s...\n", args[0], openSearchDataVolumeName())
+		if err := restoreData(cmd.Context(), args[0]); err != nil {
+			fmt.Printf("❌ Failed to restore snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✅ Snapshot restored. Run 'devhelper-cli localenv start' to start OpenSearch with it.")
+	},
+}
+
+var localenvDataListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stored snapshots",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshots, err := listSnapshots()
+		if err != nil {
+			fmt.Printf("❌ Failed to list snapshots: %v\n", err)
+			os.Exit(1)
+		}
+		if len(snapshots) == 0 {
+			fmt.Printf("No snapshots in %s\n", snapshotsDir())
+			return
+		}
+
+		fmt.Printf("Snapshots in %s:\n", snapshotsDir())
+		for _, snapshot := range snapshots {
+			fmt.Printf("- %s (%s, %s)\n", snapshot.Name, formatSize(snapshot.Size), snapshot.Created.Format("2006-01-02 15:04"))
+		}
+	},
+}
+
+var localenvDataResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete the OpenSearch data volume",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		selectDataRuntime(cmd)
+
+		if !yes {
+			fmt.Printf("Delete all OpenSearch data in %s? (y/n): ", openSearchDataVolumeName())
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(response)) != "y" {
+				fmt.Println("Reset cancelled.")
+				return
+			}
+		}
+
+		removed, err := resetData(cmd.Context())
+		if err != nil {
+			fmt.Printf("❌ Failed to reset OpenSearch data: %v\n", err)
+			os.Exit(1)
+		}
+		if !removed {
+			fmt.Println("ℹ️ There is no OpenSearch data to reset")
+			return
+		}
+		fmt.Println("✅ OpenSearch data removed. It starts empty on the next 'devhelper-cli localenv start'.")
+	},
+}
+
+func init() {
+	localenvCmd.AddCommand(localenvDataCmd)
+	localenvDataCmd.AddCommand(localenvDataSnapshotCmd, localenvDataRestoreCmd, localenvDataListCmd, localenvDataResetCmd)
+
+	localenvDataCmd.PersistentFlags().StringP("config", "c", "", "Path to environment configuration file (default: localenv.yaml)")
+	localenvDataSnapshotCmd.Flags().Bool("force", false, "Replace a snapshot with the same name")
+	localenvDataResetCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation")
+}
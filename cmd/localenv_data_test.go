@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLocalEnvData tests the OpenSearch data volume and its snapshots
+func TestLocalEnvData(t *testing.T) {
+	origRuntime := containerRuntime
+	defer func() { containerRuntime = origRuntime }()
+	t.Setenv("HOME", t.TempDir())
+
+	fake := newFakeRuntime()
+	containerRuntime = fake
+
+	t.Run("OpenSearch data should be kept in a volume", func(t *testing.T) {
+		env := &LocalEnv{}
+		assert.Equal(t, []string{"opensearch-data:/usr/share/opensearch/data"}, openSearchDriver{}.containerSpec(env).Volumes)
+
+		env.Config.Components.OpenSearch.Ephemeral = true
+		assert.Empty(t, openSearchDriver{}.containerSpec(env).Volumes)
+	})
+
+	t.Run("Snapshot names should be validated", func(t *testing.T) {
+		assert.NoError(t, validateSnapshotName("seeded-2024.01_a"))
+		assert.Error(t, validateSnapshotName("../etc"))
+		assert.Error(t, validateSnapshotName("a b"))
+	})
+
+	t.Run("Snapshot should archive the volume", func(t *testing.T) {
+		assert.ErrorContains(t, snapshotData(context.Background(), "seeded", false), "no OpenSearch data")
+
+		fake.volumes["opensearch-data"] = true
+		fake.containers["opensearch-node"] = ContainerInfo{ID: "os", Name: "opensearch-node", State: "running", Running: true}
+		assert.ErrorContains(t, snapshotData(context.Background(), "seeded", false), "OpenSearch is running")
+
+		fake.containers["opensearch-node"] = ContainerInfo{ID: "os", Name: "opensearch-node", State: "exited"}
+		assert.NoError(t, snapshotData(context.Background(), "seeded", false))
+		assert.False(t, containerExists(context.Background(), "opensearch-node"), "a stopped OpenSearch container should be removed")
+
+		helper := fake.specs[len(fake.specs)-1]
+		assert.Equal(t, snapshotImage, helper.Image)
+		assert.Equal(t, []string{"opensearch-data:/data", snapshotsDir() + ":/snapshots"}, helper.Volumes)
+		assert.Contains(t, strings.Join(helper.Command, " "), "tar czf /snapshots/.seeded.tmp -C /data . && mv /snapshots/.seeded.tmp /snapshots/seeded.tar.gz")
+		assert.Empty(t, fake.containers, "the helper container should be removed")
+	})
+
+	t.Run("Existing snapshots should only be replaced with force", func(t *testing.T) {
+		os.WriteFile(snapshotPath("seeded"), []byte("archive"), 0644)
+		assert.ErrorContains(t, snapshotData(context.Background(), "seeded", false), "use --force")
+		assert.NoError(t, snapshotData(context.Background(), "seeded", true))
+	})
+
+	t.Run("Restore should replace the volume contents", func(t *testing.T) {
+		assert.ErrorContains(t, restoreData(context.Background(), "missing"), `snapshot "missing" not found`)
+
+		assert.NoError(t, restoreData(context.Background(), "seeded"))
+		helper := fake.specs[len(fake.specs)-1]
+		assert.Contains(t, strings.Join(helper.Command, " "), "tar xzf '/snapshots/seeded.tar.gz' -C '/data/.localenv-restore'")
+	})
+
+	t.Run("A bad snapshot should not destroy the existing data", func(t *testing.T) {
+		for _, command := range []string{"sh", "tar"} {
+			if _, err := exec.LookPath(command); err != nil {
+				t.Skipf("%s is not available: %v", command, err)
+			}
+		}
+		dataDir, archiveDir := t.TempDir(), t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dataDir, "index"), []byte("current"), 0644))
+
+		truncated := filepath.Join(archiveDir, "truncated.tar.gz")
+		assert.NoError(t, os.WriteFile(truncated, []byte("\x1f\x8b\x08\x00not gzip"), 0644))
+		assert.Error(t, exec.Command("sh", "-c", restoreScript(dataDir, truncated)).Run())
+
+		data, err := os.ReadFile(filepath.Join(dataDir, "index"))
+		assert.NoError(t, err, "the existing data should survive")
+		assert.Equal(t, "current", string(data))
+		assert.NoDirExists(t, filepath.Join(dataDir, restoreStaging), "the staging directory should be removed")
+
+		snapshotDir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(snapshotDir, "restored"), []byte("snapshot"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(snapshotDir, ".hidden"), nil, 0644))
+		good := filepath.Join(archiveDir, "good.tar.gz")
+		assert.NoError(t, exec.Command("tar", "czf", good, "-C", snapshotDir, ".").Run())
+
+		output, err := exec.Command("sh", "-c", restoreScript(dataDir, good)).CombinedOutput()
+		assert.NoError(t, err, string(output))
+		assert.NoFileExists(t, filepath.Join(dataDir, "index"))
+		assert.FileExists(t, filepath.Join(dataDir, "restored"))
+		assert.FileExists(t, filepath.Join(dataDir, ".hidden"))
+		assert.NoDirExists(t, filepath.Join(dataDir, restoreStaging))
+	})
+
+	t.Run("Snapshots should be listed by name", func(t *testing.T) {
+		os.WriteFile(snapshotPath("another"), []byte("archive"), 0644)
+		os.WriteFile(filepath.Join(snapshotsDir(), ".partial.tmp"), nil, 0644)
+		os.WriteFile(filepath.Join(snapshotsDir(), "notes.txt"), nil, 0644)
+
+		snapshots, err := listSnapshots()
+		assert.NoError(t, err)
+		names := []string{}
+		for _, snapshot := range snapshots {
+			names = append(names, snapshot.Name)
+		}
+		assert.Equal(t, []string{"another", "seeded"}, names)
+		assert.Equal(t, int64(7), snapshots[0].Size)
+	})
+
+	t.Run("Reset should remove the volume", func(t *testing.T) {
+		removed, err := resetData(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, removed)
+		assert.False(t, fake.VolumeExists(context.Background(), "opensearch-data"))
+
+		removed, err = resetData(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, removed, "there should be nothing left to reset")
+	})
+
+	t.Run("Sizes should be readable", func(t *testing.T) {
+		assert.Equal(t, "512 B", formatSize(512))
+		assert.Equal(t, "1.5 KiB", formatSize(1536))
+		assert.Equal(t, "2.0 MiB", formatSize(2*1024*1024))
+	})
+}
@@ -0,0 +1,560 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// checkLevel is the outcome of a doctor check
+type checkLevel int
+
+const (
+	checkPass checkLevel = iota
+	checkSkip            // Check doesn't apply to this environment
+	checkWarn
+	checkFail
+)
+
+// minMaxMapCount is the vm.max_map_count OpenSearch needs
+const minMaxMapCount = 262144
+
+// checkResult is the result of a single doctor check
+type checkResult struct {
+	Level   checkLevel
+	Message string
+	Hint    string       // How to fix the problem by hand
+	Fix     func() error // Fixes the problem automatically, nil if it can't be
+}
+
+// doctorCheck is a named diagnosis of one aspect of the local environment
+type doctorCheck struct {
+	Name string
+	Run  func(env *LocalEnv) checkResult
+}
+
+// doctorChecks is the catalogue of checks run by `localenv doctor`, in order
+var doctorChecks = []doctorCheck{
+	{Name: "config-file", Run: checkConfigFile},
+	{Name: "tool-container-runtime", Run: checkContainerRuntimeTool},
+	{Name: "tool-kind", Run: toolCheck("kind", nil)},
+	{Name: "tool-dapr", Run: toolCheck("dapr", []string{"Dapr", "DaprDashboard"})},
+	{Name: "tool-temporal", Run: toolCheck("temporal", []string{"Temporal"})},
+	{Name: "container-engine", Run: checkContainerEngine},
+	{Name: "container-api", Run: checkContainerAPI},
+	{Name: "cgroups-v2", Run: checkCgroupsV2},
+	{Name: "ports", Run: checkPorts},
+	{Name: "opensearch-network", Run: checkOpenSearchNetwork},
+	{Name: "vm-max-map-count", Run: checkMaxMapCount},
+	{Name: "dapr-containers", Run: checkDaprContainers},
+	{Name: "config-cache", Run: checkYAMLFile(configCachePath, &ConfigCache{})},
+	{Name: "state-file", Run: checkYAMLFile(stateFilePath, &LocalEnvState{})},
+}
+
+// readMaxMapCount returns the kernel's vm.max_map_count where containers run.
+// It is a variable so tests can replace it.
+var readMaxMapCount = func() (int, error) {
+	var output []byte
+	var err error
+	if runtime.GOOS == "linux" {
+		output, err = os.ReadFile("/proc/sys/vm/max_map_count")
+	} else if containerRuntime.Name() == "podman" {
+		// Containers run inside the Podman machine VM
+		output, err = exec.Command("podman", "machine", "ssh", "cat", "/proc/sys/vm/max_map_count").Output()
+	} else {
+		err = fmt.Errorf("it can't be read from the %s VM", containerRuntime.Label())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// doctorCmd represents the doctor command for localenv
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the local development environment",
+	Long: `Run a series of checks that explain why the local development environment
+fails to start or behaves unexpectedly, including:
+- Missing or outdated tools
+- Podman machine or Docker engine not running, or rootless Podman without cgroups v2
+- Container runtime API socket not available
+- Ports already taken by other processes
+- Stale OpenSearch network and Dapr containers
+- vm.max_map_count too low for OpenSearch
+- Corrupt config cache or state files
+
+Each check passes, warns or fails with a hint on how to fix it.
+Use --fix to apply the automatic fixes that are available.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		fix, _ := cmd.Flags().GetBool("fix")
+		configPath, _ := cmd.Flags().GetString("config")
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+
+		// A broken config is reported by the config-file check
+		config, configLoaded, _ := loadLocalEnvConfig(configPath)
+		containerRuntime = selectContainerRuntime(config)
+
+		env := &LocalEnv{
+			Config:       config,
+			ConfigPath:   configPath,
+			ConfigLoaded: configLoaded,
+			Verbose:      verbose,
+			Skip:         map[string]bool{},
+			State:        loadLocalEnvState(),
+		}
+
+		fmt.Println("🩺 Diagnosing local development environment...")
+		fmt.Println()
+
+		if !runDoctorChecks(env, fix) {
+			os.Exit(1)
+		}
+	},
+}
+
+// runDoctorChecks runs every check, applying fixes when fix is set, and
+// reports whether no check failed
+func runDoctorChecks(env *LocalEnv, fix bool) bool {
+	counts := map[checkLevel]int{}
+	fixable := 0
+
+	for _, check := range doctorChecks {
+		result := check.Run(env)
+		printCheckResult(check.Name, result, fix)
+
+		if fix && result.Fix != nil && result.Level >= checkWarn {
+			fmt.Printf("   🔧 Fixing %s...\n", check.Name)
+			if err := result.Fix(); err != nil {
+				fmt.Printf("   ❌ Fix failed: %v\n", err)
+			} else {
+				result = check.Run(env)
+				printCheckResult(check.Name, result, fix)
+			}
+		}
+
+		counts[result.Level]++
+		if result.Fix != nil && result.Level >= checkWarn {
+			fixable++
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d passed, %d warnings, %d failed, %d skipped\n",
+		counts[checkPass], counts[checkWarn], counts[checkFail], counts[checkSkip])
+	if fixable > 0 && !fix {
+		fmt.Printf("💡 %d problem(s) can be fixed automatically with 'devhelper-cli localenv doctor --fix'\n", fixable)
+	}
+	return counts[checkFail] == 0
+}
+
+// printCheckResult prints one check result with its hint
+func printCheckResult(name string, result checkResult, fix bool) {
+	icon := map[checkLevel]string{checkPass: "✅", checkSkip: "➖", checkWarn: "⚠️", checkFail: "❌"}[result.Level]
+	fmt.Printf("%s %s: %s\n", icon, name, result.Message)
+
+	if result.Level < checkWarn {
+		return
+	}
+	if result.Hint != "" {
+		fmt.Printf("   💡 %s\n", result.Hint)
+	}
+	if result.Fix != nil && !fix {
+		fmt.Println("   🔧 Can be fixed automatically with --fix")
+	}
+}
+
+// checkConfigFile checks that the configuration file can be loaded
+func checkConfigFile(env *LocalEnv) checkResult {
+	configPath := env.ConfigPath
+	if configPath == "" {
+		configPath = "localenv.yaml"
+	}
+
+	_, loaded, err := loadLocalEnvConfig(configPath)
+	switch {
+	case err != nil:
+		return checkResult{
+			Level:   checkFail,
+			Message: err.Error(),
+			Hint:    fmt.Sprintf("Fix the YAML in %s or recreate it with 'devhelper-cli localenv init --force'", configPath),
+		}
+	case !loaded:
+		return checkResult{
+			Level:   checkWarn,
+			Message: fmt.Sprintf("%s not found, defaults will be used", configPath),
+			Hint:    "Run 'devhelper-cli localenv init' to create a configuration",
+		}
+	}
+	return checkResult{Level: checkPass, Message: fmt.Sprintf("Loaded %s", configPath)}
+}
+
+// toolCheck returns a check that the tool is installed and recent enough.
+// Missing tools only fail when one of components is enabled; with no
+// components the tool is always required.
+func toolCheck(name string, components []string) func(env *LocalEnv) checkResult {
+	return func(env *LocalEnv) checkResult {
+		info := requiredVersions[name]
+		required := len(components) == 0 || anyComponentEnabled(env, components)
+		interactiveFix := func() error {
+			_, err, _ := validateToolWithVersionDetection(name, "--version", env.Verbose)
+			return err
+		}
+
+		path, err := exec.LookPath(name)
+		if err != nil {
+			level := checkWarn
+			if required && name != "kind" {
+				level = checkFail
+			}
+			return checkResult{
+				Level:   level,
+				Message: fmt.Sprintf("%s not found in PATH", info.Name),
+				Hint:    fmt.Sprintf("Install with '%s' or see %s", info.InstallCommand, info.InstallURL),
+				Fix:     interactiveFix,
+			}
+		}
+
+		version, err := detectToolVersion(path, name, "--version", env.Verbose)
+		if err != nil {
+			return checkResult{
+				Level:   checkFail,
+				Message: fmt.Sprintf("%s at %s %v", info.Name, path, err),
+				Hint:    fmt.Sprintf("Reinstall with '%s'", info.InstallCommand),
+			}
+		}
+		if version == "" {
+			return checkResult{Level: checkWarn, Message: fmt.Sprintf("%s found at %s but its version could not be detected", info.Name, path)}
+		}
+		if compareVersions(version, info.MinVersion) < 0 {
+			return checkResult{
+				Level:   checkWarn,
+				Message: fmt.Sprintf("%s %s is below recommended minimum %s", info.Name, version, info.MinVersion),
+				Hint:    fmt.Sprintf("Update with '%s'", info.UpdateCommand),
+				Fix:     interactiveFix,
+			}
+		}
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("%s %s", info.Name, version)}
+	}
+}
+
+// anyComponentEnabled reports whether one of the named components is enabled
+func anyComponentEnabled(env *LocalEnv, names []string) bool {
+	for _, name := range names {
+		if driver, ok := findComponent(name); ok && driver.Enabled(env) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContainerRuntimeTool checks the selected container runtime is installed and recent enough
+func checkContainerRuntimeTool(env *LocalEnv) checkResult {
+	return toolCheck(containerRuntime.Name(), nil)(env)
+}
+
+// checkContainerEngine checks that the container runtime can reach its engine
+func checkContainerEngine(env *LocalEnv) checkResult {
+	label := containerRuntime.Label()
+	if !containerRuntime.Available() {
+		return checkResult{Level: checkSkip, Message: label + " is not installed"}
+	}
+	if checkToolFunctionality(containerRuntime.Name(), []string{"info"}, env.Verbose) {
+		return checkResult{Level: checkPass, Message: label + " engine is reachable"}
+	}
+
+	if containerRuntime.Name() != "podman" {
+		return checkResult{
+			Level:   checkFail,
+			Message: label + " cannot reach its engine",
+			Hint:    fmt.Sprintf("Start Docker Desktop or the docker service, and run '%s info' to see the error", containerRuntime.Name()),
+		}
+	}
+	if runtime.GOOS == "linux" {
+		return checkResult{
+			Level:   checkFail,
+			Message: "Podman cannot reach its engine",
+			Hint:    "Run 'podman info' to see the error, and 'podman system migrate' after upgrading Podman",
+		}
+	}
+	return checkResult{
+		Level:   checkFail,
+		Message: "Podman machine is not running",
+		Hint:    "Run 'podman machine start' (or 'podman machine init' if no machine exists)",
+		Fix: func() error {
+			cmd := exec.Command("podman", "machine", "start")
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		},
+	}
+}
+
+// checkContainerAPI checks that localenv can use the runtime's API socket
+// rather than falling back to parsing its CLI output
+func checkContainerAPI(env *LocalEnv) checkResult {
+	if api, ok := containerRuntime.(*apiRuntime); ok {
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("Using the %s API at %s", api.Label(), api.Socket())}
+	}
+	if !containerRuntime.Available() {
+		return checkResult{Level: checkSkip, Message: containerRuntime.Label() + " is not installed"}
+	}
+
+	result := checkResult{
+		Level:   checkWarn,
+		Message: fmt.Sprintf("%s API socket not found, falling back to the %s CLI", containerRuntime.Label(), containerRuntime.Name()),
+		Hint:    "Start the Docker daemon, or set DOCKER_HOST to its unix:// socket",
+	}
+	if containerRuntime.Name() == "podman" && runtime.GOOS == "linux" {
+		result.Hint = "Run 'systemctl --user enable --now podman.socket', or set CONTAINER_HOST to a unix:// socket"
+		result.Fix = func() error {
+			return exec.Command("systemctl", "--user", "enable", "--now", "podman.socket").Run()
+		}
+	} else if containerRuntime.Name() == "podman" {
+		result.Hint = "Run 'podman machine start', or set CONTAINER_HOST to a unix:// socket"
+	}
+	return result
+}
+
+// checkCgroupsV2 checks that rootless Podman on Linux has cgroups v2, which
+// it needs to apply resource limits such as OpenSearch's memory settings
+func checkCgroupsV2(env *LocalEnv) checkResult {
+	if runtime.GOOS != "linux" || containerRuntime.Name() != "podman" {
+		return checkResult{Level: checkSkip, Message: "Only applies to Podman on Linux"}
+	}
+	if os.Geteuid() == 0 {
+		return checkResult{Level: checkSkip, Message: "Podman runs as root"}
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return checkResult{
+			Level:   checkWarn,
+			Message: "Rootless Podman is running without cgroups v2",
+			Hint:    "Boot with systemd.unified_cgroup_hierarchy=1 to enable cgroups v2",
+		}
+	}
+	return checkResult{Level: checkPass, Message: "cgroups v2 is available"}
+}
+
+// componentPort is a port an enabled component listens on
+type componentPort struct {
+	Component string
+	Label     string
+	Port      int
+}
+
+// enabledComponentPorts lists the ports of every enabled component
+func enabledComponentPorts(env *LocalEnv) []componentPort {
+	ports := []componentPort{}
+	add := func(component, label string, port int) {
+		if driver, ok := findComponent(component); ok && driver.Enabled(env) && port > 0 {
+			ports = append(ports, componentPort{Component: driver.Name(), Label: label, Port: port})
+		}
+	}
+
+	add("DaprDashboard", "Dapr Dashboard", getDaprDashboardPort(env.ConfigLoaded, env.Config))
+	add("Dapr", "Zipkin", getZipkinPort(env.ConfigLoaded, env.Config))
+	uiPort, grpcPort := temporalPorts(env)
+	add("Temporal", "Temporal UI", uiPort)
+	add("Temporal", "Temporal gRPC", grpcPort)
+	add("OpenSearch", "OpenSearch", env.Config.Components.OpenSearch.Port)
+	add("OpenSearchDashboard", "OpenSearch Dashboard", env.Config.Components.OpenSearch.DashboardPort)
+	for _, driver := range environmentComponents(env.Config) {
+		if custom, ok := driver.(customComponentDriver); ok && custom.Enabled(env) {
+			for _, port := range custom.hostPorts() {
+				ports = append(ports, componentPort{Component: custom.Name(), Label: custom.Name(), Port: port})
+			}
+		}
+	}
+	return ports
+}
+
+// checkPorts checks that the ports of enabled components aren't taken by
+// processes localenv didn't start
+func checkPorts(env *LocalEnv) checkResult {
+	collisions := []string{}
+	for _, port := range enabledComponentPorts(env) {
+		if !isPortInUse(port.Port) {
+			continue
+		}
+		if tracked, ok := env.State.component(port.Component); ok && (len(tracked.PIDs) > 0 || len(tracked.Containers) > 0) {
+			// Started by localenv, so the port is expected to be in use
+			continue
+		}
+
+		collision := fmt.Sprintf("%s port %d", port.Label, port.Port)
+		if pids, err := prober.ListeningPIDs(port.Port); err == nil && len(pids) > 0 {
+			collision += fmt.Sprintf(" (PID %s)", joinPIDs(pids))
+		}
+		collisions = append(collisions, collision)
+	}
+
+	if len(collisions) > 0 {
+		return checkResult{
+			Level:   checkFail,
+			Message: "In use by processes not started by localenv: " + strings.Join(collisions, ", "),
+			Hint:    "Stop the processes using these ports or change the ports in localenv.yaml",
+		}
+	}
+	return checkResult{Level: checkPass, Message: "All component ports are free or used by localenv"}
+}
+
+// checkOpenSearchNetwork checks for an OpenSearch network left behind
+// without any OpenSearch containers
+func checkOpenSearchNetwork(env *LocalEnv) checkResult {
+	if !anyComponentEnabled(env, []string{"OpenSearch"}) {
+		return checkResult{Level: checkSkip, Message: "OpenSearch is not enabled"}
+	}
+	if !containerRuntime.Available() {
+		return checkResult{Level: checkSkip, Message: containerRuntime.Label() + " is not installed"}
+	}
+	if !containerRuntime.NetworkExists(env.ctx(), openSearchNetworkName()) {
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("No stale %s", openSearchNetworkName())}
+	}
+	if containerExists(env.ctx(), openSearchContainerName()) || containerExists(env.ctx(), openSearchDashboardContainerName()) {
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("%s is in use by OpenSearch containers", openSearchNetworkName())}
+	}
+
+	return checkResult{
+		Level:   checkWarn,
+		Message: fmt.Sprintf("%s exists without any OpenSearch containers", openSearchNetworkName()),
+		Hint:    fmt.Sprintf("Remove it with '%s network rm %s'; it's recreated on the next start", containerRuntime.Name(), openSearchNetworkName()),
+		Fix: func() error {
+			return containerRuntime.RemoveNetwork(env.ctx(), openSearchNetworkName())
+		},
+	}
+}
+
+// checkMaxMapCount checks the kernel's vm.max_map_count is high enough for OpenSearch
+func checkMaxMapCount(env *LocalEnv) checkResult {
+	if !anyComponentEnabled(env, []string{"OpenSearch"}) {
+		return checkResult{Level: checkSkip, Message: "OpenSearch is not enabled"}
+	}
+
+	count, err := readMaxMapCount()
+	if err != nil {
+		return checkResult{Level: checkWarn, Message: fmt.Sprintf("Could not read vm.max_map_count: %v", err)}
+	}
+	if count >= minMaxMapCount {
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("vm.max_map_count is %d", count)}
+	}
+
+	sysctl := []string{"sudo", "sysctl", "-w", fmt.Sprintf("vm.max_map_count=%d", minMaxMapCount)}
+	if runtime.GOOS != "linux" {
+		sysctl = append([]string{"podman", "machine", "ssh"}, sysctl...)
+	}
+	return checkResult{
+		Level:   checkFail,
+		Message: fmt.Sprintf("vm.max_map_count is %d, OpenSearch needs at least %d", count, minMaxMapCount),
+		Hint:    fmt.Sprintf("Run '%s'", strings.Join(sysctl, " ")),
+		Fix: func() error {
+			cmd := exec.Command(sysctl[0], sysctl[1:]...)
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		},
+	}
+}
+
+// checkDaprContainers checks for Dapr containers left over from a previous
+// `dapr init` that are stopped or no longer wanted
+func checkDaprContainers(env *LocalEnv) checkResult {
+	if !containerRuntime.Available() {
+		return checkResult{Level: checkSkip, Message: containerRuntime.Label() + " is not installed"}
+	}
+
+	containers, err := containerRuntime.List(env.ctx(), "dapr_", true)
+	if err != nil {
+		return checkResult{Level: checkSkip, Message: "Could not list containers"}
+	}
+
+	daprEnabled := anyComponentEnabled(env, []string{"Dapr"})
+	stale := []string{}
+	for _, container := range containers {
+		if !daprEnabled || !container.Running {
+			stale = append(stale, container.Name)
+		}
+	}
+
+	if len(stale) == 0 {
+		return checkResult{Level: checkPass, Message: "No stale Dapr containers"}
+	}
+
+	hint := fmt.Sprintf("Remove them with 'dapr uninstall' or '%s rm -f %s'", containerRuntime.Name(), strings.Join(stale, " "))
+	if daprEnabled {
+		hint += ", then run 'devhelper-cli localenv start' to reinitialize Dapr"
+	}
+	return checkResult{
+		Level:   checkWarn,
+		Message: "Stale Dapr containers: " + strings.Join(stale, ", "),
+		Hint:    hint,
+		Fix: func() error {
+			for _, container := range stale {
+				if err := containerRuntime.Remove(env.ctx(), container); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// checkYAMLFile returns a check that the file at path() is valid YAML for v.
+// Corrupt files are removed by --fix as localenv recreates them.
+func checkYAMLFile(path func() string, v interface{}) func(env *LocalEnv) checkResult {
+	return func(env *LocalEnv) checkResult {
+		file := path()
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			return checkResult{Level: checkPass, Message: fmt.Sprintf("%s does not exist yet", file)}
+		}
+		if err != nil {
+			return checkResult{Level: checkFail, Message: err.Error(), Hint: fmt.Sprintf("Check the permissions of %s", file)}
+		}
+
+		if err := yamlv3.Unmarshal(data, v); err != nil {
+			return checkResult{
+				Level:   checkFail,
+				Message: fmt.Sprintf("%s is corrupt: %v", file, err),
+				Hint:    fmt.Sprintf("Delete %s; it's recreated by 'devhelper-cli localenv start'", file),
+				Fix: func() error {
+					return os.Remove(file)
+				},
+			}
+		}
+		return checkResult{Level: checkPass, Message: fmt.Sprintf("%s is valid", file)}
+	}
+}
+
+func init() {
+	localenvCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringP("config", "c", "", "Path to environment configuration file (default: localenv.yaml)")
+	doctorCmd.Flags().Bool("fix", false, "Apply automatic fixes for the problems found")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lirtsman/devhelper-cli/internal/procnet"
+)
+
+// TestDoctorCommand tests the localenv doctor command and its checks
+func TestDoctorCommand(t *testing.T) {
+	t.Run("Doctor command structure should be valid", func(t *testing.T) {
+		assert.Equal(t, "doctor", doctorCmd.Use)
+		assert.NotNil(t, doctorCmd.Flags().Lookup("fix"), "fix flag should exist")
+		assert.NotNil(t, doctorCmd.Flags().Lookup("config"), "config flag should exist")
+
+		names := map[string]bool{}
+		for _, check := range doctorChecks {
+			assert.False(t, names[check.Name], "check %s should be registered once", check.Name)
+			names[check.Name] = true
+		}
+	})
+
+	t.Run("Config file check", func(t *testing.T) {
+		dir := t.TempDir()
+		env := &LocalEnv{ConfigPath: filepath.Join(dir, "localenv.yaml")}
+		assert.Equal(t, checkWarn, checkConfigFile(env).Level, "missing config should warn")
+
+		os.WriteFile(env.ConfigPath, []byte("components: [broken"), 0644)
+		assert.Equal(t, checkFail, checkConfigFile(env).Level, "invalid config should fail")
+
+		os.WriteFile(env.ConfigPath, []byte("components:\n  temporal:\n    enabled: true\n"), 0644)
+		assert.Equal(t, checkPass, checkConfigFile(env).Level)
+	})
+
+	t.Run("Corrupt cache should be fixable", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		check := checkYAMLFile(configCachePath, &ConfigCache{})
+
+		assert.Equal(t, checkPass, check(&LocalEnv{}).Level, "missing cache should pass")
+
+		os.MkdirAll(filepath.Dir(configCachePath()), 0755)
+		os.WriteFile(configCachePath(), []byte("temporal: [not a struct"), 0644)
+		result := check(&LocalEnv{})
+		assert.Equal(t, checkFail, result.Level)
+		assert.NotNil(t, result.Fix, "corrupt cache should be fixable")
+
+		assert.NoError(t, result.Fix())
+		assert.Equal(t, checkPass, check(&LocalEnv{}).Level, "cache should be removed by the fix")
+	})
+
+	t.Run("Port collisions", func(t *testing.T) {
+		originalProber := prober
+		defer func() { prober = originalProber }()
+		t.Setenv("HOME", t.TempDir())
+
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{}, State: loadLocalEnvState()}
+		env.Config.Components.Temporal.Enabled = true
+
+		prober = &procnet.Fake{Listening: map[int][]int{}}
+		assert.Equal(t, checkPass, checkPorts(env).Level, "free ports should pass")
+
+		prober = &procnet.Fake{Listening: map[int][]int{8233: {99}}}
+		result := checkPorts(env)
+		assert.Equal(t, checkFail, result.Level, "port taken by another process should fail")
+		assert.Contains(t, result.Message, "Temporal UI port 8233 (PID 99)")
+
+		env.State.recordProcess("Temporal", 99, 99)
+		assert.Equal(t, checkPass, checkPorts(env).Level, "port used by a tracked component should pass")
+	})
+
+	t.Run("vm.max_map_count", func(t *testing.T) {
+		originalRead := readMaxMapCount
+		defer func() { readMaxMapCount = originalRead }()
+
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{}}
+		assert.Equal(t, checkSkip, checkMaxMapCount(env).Level, "should be skipped without OpenSearch")
+
+		env.Config.Components.OpenSearch.Enabled = true
+		readMaxMapCount = func() (int, error) { return 65530, nil }
+		result := checkMaxMapCount(env)
+		assert.Equal(t, checkFail, result.Level)
+		assert.NotNil(t, result.Fix)
+		assert.Contains(t, result.Hint, "vm.max_map_count=262144")
+
+		readMaxMapCount = func() (int, error) { return 262144, nil }
+		assert.Equal(t, checkPass, checkMaxMapCount(env).Level)
+	})
+}
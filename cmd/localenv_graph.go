@@ -0,0 +1,196 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errDependencyFailed is returned for components that were not started
+// because one of their dependencies failed to start
+var errDependencyFailed = errors.New("dependency failed to start")
+
+// errStartInterrupted is returned for components that were not started
+// because the command was interrupted
+var errStartInterrupted = errors.New("start was interrupted")
+
+// rollbackTimeout bounds how long rollbackComponents may take to stop components
+const rollbackTimeout = 2 * time.Minute
+
+// sortComponents orders drivers so every component comes after the components
+// it depends on. Dependencies on components that aren't in drivers (because
+// they are disabled or skipped) are ignored. Independent components keep
+// their relative order so the result is deterministic.
+func sortComponents(drivers []ComponentDriver) ([]ComponentDriver, error) {
+	byName := map[string]ComponentDriver{}
+	for _, driver := range drivers {
+		byName[strings.ToLower(driver.Name())] = driver
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	sorted := make([]ComponentDriver, 0, len(drivers))
+	path := []string{}
+
+	var visit func(driver ComponentDriver) error
+	visit = func(driver ComponentDriver) error {
+		key := strings.ToLower(driver.Name())
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			// Report the cycle starting from the first occurrence of this component
+			for i, name := range path {
+				if strings.EqualFold(name, driver.Name()) {
+					cycle := append(append([]string{}, path[i:]...), driver.Name())
+					return fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+
+		state[key] = visiting
+		path = append(path, driver.Name())
+		for _, dependency := range driver.Dependencies() {
+			dep, ok := byName[strings.ToLower(dependency)]
+			if !ok {
+				if _, registered := findComponent(dependency); !registered {
+					return fmt.Errorf("component %s depends on unknown component %s", driver.Name(), dependency)
+				}
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		sorted = append(sorted, driver)
+		return nil
+	}
+
+	for _, driver := range drivers {
+		if err := visit(driver); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// startComponents starts drivers concurrently, starting each component as
+// soon as all of the components it depends on have started. Components whose
+// dependencies failed are not started and report errDependencyFailed, and
+// once env's context is done no more components are started.
+// The returned map holds the start error of every driver, keyed by name.
+func startComponents(env *LocalEnv, drivers []ComponentDriver) (map[string]error, error) {
+	sorted, err := sortComponents(drivers)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[string]chan struct{}{}
+	for _, driver := range sorted {
+		done[strings.ToLower(driver.Name())] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	errs := map[string]error{}
+
+	var wg sync.WaitGroup
+	for _, driver := range sorted {
+		wg.Add(1)
+		go func(driver ComponentDriver) {
+			defer wg.Done()
+			key := strings.ToLower(driver.Name())
+			defer close(done[key])
+
+			// Wait for the dependencies that are part of this start
+			for _, dependency := range driver.Dependencies() {
+				ch, ok := done[strings.ToLower(dependency)]
+				if !ok {
+					continue
+				}
+				<-ch
+
+				mu.Lock()
+				depErr := errs[strings.ToLower(dependency)]
+				if depErr != nil {
+					errs[key] = fmt.Errorf("%s: %w", dependency, errDependencyFailed)
+				}
+				mu.Unlock()
+				if depErr != nil {
+					return
+				}
+			}
+
+			if env.ctx().Err() != nil {
+				mu.Lock()
+				errs[key] = errStartInterrupted
+				mu.Unlock()
+				return
+			}
+
+			fmt.Printf("Starting %s...\n", driver.Name())
+			err := driver.Start(env)
+
+			mu.Lock()
+			errs[key] = err
+			mu.Unlock()
+		}(driver)
+	}
+	wg.Wait()
+
+	results := map[string]error{}
+	for _, driver := range sorted {
+		results[driver.Name()] = errs[strings.ToLower(driver.Name())]
+	}
+	return results, nil
+}
+
+// rollbackComponents stops, in reverse start order, the components recorded
+// as started since the given time, so a failed or interrupted start doesn't
+// leave half of the environment running. Components that were already
+// running before are left alone.
+func rollbackComponents(env *LocalEnv, drivers []ComponentDriver, since time.Time) {
+	sorted, err := sortComponents(drivers)
+	if err != nil {
+		return
+	}
+
+	// Stopping must not be cut short by the interrupt that triggered the
+	// rollback, but it must not hang either
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+	rollback := *env
+	rollback.Context = ctx
+	for i := len(sorted) - 1; i >= 0; i-- {
+		driver := sorted[i]
+		if !env.State.startedSince(driver.Name(), since) {
+			continue
+		}
+		if err := driver.Stop(&rollback); err != nil && !errors.Is(err, errComponentNotRunning) {
+			fmt.Printf("⚠️ Failed to roll back %s: %v\n", driver.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver is a ComponentDriver used to test component ordering
+type fakeDriver struct {
+	name  string
+	deps  []string
+	start func() error
+	stop  func(env *LocalEnv) error
+}
+
+func (f fakeDriver) Name() string                         { return f.name }
+func (f fakeDriver) Dependencies() []string               { return f.deps }
+func (f fakeDriver) Installed() bool                      { return true }
+func (f fakeDriver) Enabled(env *LocalEnv) bool           { return true }
+func (f fakeDriver) Status(env *LocalEnv) ComponentStatus { return ComponentStatus{Name: f.name} }
+func (f fakeDriver) LogSources(env *LocalEnv) []LogSource { return nil }
+func (f fakeDriver) Health(env *LocalEnv) error           { return nil }
+func (f fakeDriver) Readiness(env *LocalEnv) Readiness    { return Readiness{} }
+func (f fakeDriver) Start(env *LocalEnv) error {
+	if f.start != nil {
+		return f.start()
+	}
+	return nil
+}
+
+func (f fakeDriver) Stop(env *LocalEnv) error {
+	if f.stop != nil {
+		return f.stop(env)
+	}
+	return nil
+}
+
+func driverNames(drivers []ComponentDriver) []string {
+	names := []string{}
+	for _, driver := range drivers {
+		names = append(names, driver.Name())
+	}
+	return names
+}
+
+// TestSortComponents tests dependency ordering of component drivers
+func TestSortComponents(t *testing.T) {
+	t.Run("Dependencies should come before dependents", func(t *testing.T) {
+		drivers := []ComponentDriver{
+			fakeDriver{name: "Dashboard", deps: []string{"Runtime"}},
+			fakeDriver{name: "Runtime"},
+			fakeDriver{name: "Search"},
+		}
+
+		sorted, err := sortComponents(drivers)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Runtime", "Dashboard", "Search"}, driverNames(sorted))
+	})
+
+	t.Run("Built-in components should sort without errors", func(t *testing.T) {
+		sorted, err := sortComponents(registeredComponents())
+		assert.NoError(t, err)
+		names := driverNames(sorted)
+		assert.Less(t, indexOf(names, "Dapr"), indexOf(names, "DaprDashboard"))
+		assert.Less(t, indexOf(names, "OpenSearch"), indexOf(names, "OpenSearchDashboard"))
+	})
+
+	t.Run("Dependencies that are not enabled should be ignored", func(t *testing.T) {
+		sorted, err := sortComponents([]ComponentDriver{daprDashboardDriver{}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"DaprDashboard"}, driverNames(sorted))
+	})
+
+	t.Run("Unknown dependencies should be reported", func(t *testing.T) {
+		_, err := sortComponents([]ComponentDriver{fakeDriver{name: "A", deps: []string{"Missing"}}})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown component Missing")
+	})
+
+	t.Run("Cycles should be detected", func(t *testing.T) {
+		drivers := []ComponentDriver{
+			fakeDriver{name: "A", deps: []string{"B"}},
+			fakeDriver{name: "B", deps: []string{"C"}},
+			fakeDriver{name: "C", deps: []string{"A"}},
+		}
+
+		_, err := sortComponents(drivers)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "A -> B -> C -> A")
+	})
+}
+
+// TestStartComponents tests concurrent startup of component drivers
+func TestStartComponents(t *testing.T) {
+	t.Run("Independent components should start concurrently", func(t *testing.T) {
+		// Both components block until the other one has started
+		var wg sync.WaitGroup
+		wg.Add(2)
+		bothStarted := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(bothStarted)
+		}()
+
+		start := func() error {
+			wg.Done()
+			select {
+			case <-bothStarted:
+				return nil
+			case <-time.After(2 * time.Second):
+				return errors.New("components were started sequentially")
+			}
+		}
+
+		results, err := startComponents(&LocalEnv{}, []ComponentDriver{
+			fakeDriver{name: "A", start: start},
+			fakeDriver{name: "B", start: start},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, results["A"])
+		assert.NoError(t, results["B"])
+	})
+
+	t.Run("Dependents should start after their dependencies", func(t *testing.T) {
+		var mu sync.Mutex
+		order := []string{}
+		record := func(name string) func() error {
+			return func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			}
+		}
+
+		_, err := startComponents(&LocalEnv{}, []ComponentDriver{
+			fakeDriver{name: "Dashboard", deps: []string{"Runtime"}, start: record("Dashboard")},
+			fakeDriver{name: "Runtime", start: func() error {
+				time.Sleep(50 * time.Millisecond)
+				return record("Runtime")()
+			}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Runtime", "Dashboard"}, order)
+	})
+
+	t.Run("Dependents of failed components should not start", func(t *testing.T) {
+		dashboardStarted := false
+		results, err := startComponents(&LocalEnv{}, []ComponentDriver{
+			fakeDriver{name: "Runtime", start: func() error { return errors.New("boom") }},
+			fakeDriver{name: "Dashboard", deps: []string{"Runtime"}, start: func() error {
+				dashboardStarted = true
+				return nil
+			}},
+		})
+		assert.NoError(t, err)
+		assert.EqualError(t, results["Runtime"], "boom")
+		assert.ErrorIs(t, results["Dashboard"], errDependencyFailed)
+		assert.False(t, dashboardStarted, "dashboard should not be started")
+	})
+
+	t.Run("Components should not start once start is interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		dashboardStarted := false
+		results, err := startComponents(&LocalEnv{Context: ctx}, []ComponentDriver{
+			fakeDriver{name: "Runtime", start: func() error {
+				cancel()
+				return context.Canceled
+			}},
+			fakeDriver{name: "Dashboard", deps: []string{"Search"}, start: func() error {
+				dashboardStarted = true
+				return nil
+			}},
+			fakeDriver{name: "Search", deps: []string{"Runtime"}},
+		})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results["Runtime"], context.Canceled)
+		assert.ErrorIs(t, results["Search"], errDependencyFailed)
+		assert.False(t, dashboardStarted, "dashboard should not be started")
+
+		results, _ = startComponents(&LocalEnv{Context: ctx}, []ComponentDriver{fakeDriver{name: "Search"}})
+		assert.ErrorIs(t, results["Search"], errStartInterrupted)
+	})
+
+	t.Run("Rollback should stop what this start launched in reverse order", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		env := &LocalEnv{Context: ctx, State: loadLocalEnvState()}
+		env.State.recordProcess("Runtime", 100, 0)
+		time.Sleep(time.Millisecond)
+		startedAt := time.Now()
+		env.State.recordContainer("Search", "search-id")
+		env.State.recordProcess("Dashboard", 101, 0)
+
+		stopped := []string{}
+		stop := func(name string) func(env *LocalEnv) error {
+			return func(env *LocalEnv) error {
+				assert.NoError(t, env.ctx().Err(), "stopping should not be interrupted")
+				stopped = append(stopped, name)
+				return nil
+			}
+		}
+		rollbackComponents(env, []ComponentDriver{
+			fakeDriver{name: "Dashboard", deps: []string{"Search"}, stop: stop("Dashboard")},
+			fakeDriver{name: "Runtime", stop: stop("Runtime")},
+			fakeDriver{name: "Search", deps: []string{"Runtime"}, stop: stop("Search")},
+		}, startedAt)
+		assert.Equal(t, []string{"Dashboard", "Search"}, stopped, "Runtime was already running")
+		assert.NotNil(t, startCmd.Flags().Lookup("rollback-on-failure"))
+	})
+
+	t.Run("Cycles should prevent startup", func(t *testing.T) {
+		_, err := startComponents(&LocalEnv{}, []ComponentDriver{
+			fakeDriver{name: "A", deps: []string{"B"}},
+			fakeDriver{name: "B", deps: []string{"A"}},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
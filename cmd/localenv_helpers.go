@@ -16,13 +16,27 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
 )
 
+// containerStartTimeout is how long a container may take to start running
+const containerStartTimeout = 10 * time.Second
+
+// defaultDashboardStartTimeout is how long a dashboard may take to answer
+const defaultDashboardStartTimeout = 15 * time.Second
+
 // Check if a Temporal namespace exists
 func isTemporalNamespaceExist(namespace string) bool {
 	cmd := exec.Command("temporal", "operator", "namespace", "describe", namespace)
@@ -61,12 +75,32 @@ func getOpenSearchRequirement(configLoaded bool, configValue bool, skipFlag bool
 	return true // OpenSearch is enabled by default if no config
 }
 
-func tryStartDashboard(command string, port int, logFile *os.File) bool {
-	return tryStartDashboardWithTimeout(command, port, logFile, 3*time.Second)
+func tryStartDashboard(ctx context.Context, command string, port int, logFile *os.File) bool {
+	return tryStartDashboardWithTimeout(ctx, command, port, logFile, defaultDashboardStartTimeout)
+}
+
+func tryStartDashboardWithTimeout(ctx context.Context, command string, port int, logFile *os.File, timeout time.Duration) bool {
+	_, ok := startDashboardProcess(ctx, command, port, logFile, Readiness{
+		Probe:   dashboardProbe(port),
+		Options: probe.Options{Timeout: timeout},
+	})
+	return ok
+}
+
+// dashboardProbe returns the readiness probe of a dashboard served on port.
+// Any response means it is up.
+func dashboardProbe(port int) probe.Probe {
+	return probe.HTTP{URL: fmt.Sprintf("http://localhost:%d", port), Status: probe.AnyStatus}
 }
 
-func tryStartDashboardWithTimeout(command string, port int, logFile *os.File, timeout time.Duration) bool {
+// startDashboardProcess starts the dashboard in its own process group, waits
+// for it to pass the readiness probe and returns its PID. A dashboard that
+// is still running when the probe times out or ctx is done is kept as it may
+// just be slow.
+func startDashboardProcess(ctx context.Context, command string, port int, logFile *os.File, ready Readiness) (int, bool) {
+	// Not bound to ctx: the dashboard keeps running after start returns
 	dashboardCmd := exec.Command(command, "dashboard", "-p", strconv.Itoa(port), "--address", "0.0.0.0")
+	detachProcess(dashboardCmd)
 
 	// Redirect output to null device or log file
 	if logFile == nil {
@@ -78,25 +112,171 @@ func tryStartDashboardWithTimeout(command string, port int, logFile *os.File, ti
 		dashboardCmd.Stderr = logFile
 	}
 
-	// Start the dashboard in a goroutine
-	resultChan := make(chan error, 1)
+	if err := dashboardCmd.Start(); err != nil {
+		fmt.Printf("Dashboard failed to start: %v\n", err)
+		return 0, false
+	}
+
+	// Reap the process so an early exit can be told apart from a slow start
+	exited := make(chan error, 1)
 	go func() {
-		resultChan <- dashboardCmd.Run()
+		exited <- dashboardCmd.Wait()
 	}()
 
-	// Wait a bit for the dashboard to start
-	time.Sleep(timeout)
+	err := probe.Wait(ctx, probe.All(processRunning("Dashboard", exited), ready.Probe), ready.Options)
+	switch {
+	case errors.Is(err, errExitedDuringStartup):
+		fmt.Printf("Dashboard failed to start: %v\n", err)
+		return 0, false
+	case err != nil && ctx.Err() == nil:
+		fmt.Printf("⚠️ Dashboard is running but not answering yet: %v\n", err)
+	}
+	return dashboardCmd.Process.Pid, true
+}
+
+// isContainerRunning checks if the container with the given name or ID is running
+func isContainerRunning(ctx context.Context, name string) bool {
+	container, err := containerRuntime.Inspect(ctx, name)
+	return err == nil && container.Running
+}
 
-	// Check if the process exited quickly (indicating failure)
-	select {
-	case err := <-resultChan:
-		// If we get here, the process exited before our timeout
-		if err != nil {
-			fmt.Printf("Dashboard failed to start: %v\n", err)
+// containerExists checks if the container with the given name or ID exists in any state
+func containerExists(ctx context.Context, name string) bool {
+	_, err := containerRuntime.Inspect(ctx, name)
+	return err == nil
+}
+
+// listContainerIDs returns the IDs of running containers whose name matches filter
+func listContainerIDs(ctx context.Context, filter string) []string {
+	containers, err := containerRuntime.List(ctx, filter, false)
+	if err != nil {
+		return nil
+	}
+
+	ids := []string{}
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	return ids
+}
+
+// printContainerLogs prints the logs of a container, used for diagnostics in verbose mode
+func printContainerLogs(ctx context.Context, name, label string) {
+	var logsOutput bytes.Buffer
+	containerRuntime.Logs(ctx, name, LogOptions{}, &logsOutput, &logsOutput)
+	if logsOutput.Len() > 0 {
+		fmt.Printf("\n%s container logs:\n", label)
+		fmt.Println(logsOutput.String())
+	}
+}
+
+// waitForContainer waits until the named container is running. It gives up
+// early when the container exits, reporting its exit code.
+func waitForContainer(ctx context.Context, name, label string) bool {
+	if source, ok := containerRuntime.(containerEventSource); ok {
+		if running, err := waitForContainerEvent(ctx, source, name, label); err == nil {
+			return running
 		}
-		return false
-	default:
-		// No error yet, process is still running
-		return true
 	}
+
+	// The health check is left to the component's readiness probe
+	started := containerProbe(name)
+	started.IgnoreHealth = true
+	err := probe.Wait(ctx, started, probe.Options{
+		Timeout:  containerStartTimeout,
+		Interval: time.Second,
+		OnFailure: func(int, error) {
+			fmt.Printf("Waiting for %s container to start...\n", label)
+		},
+	})
+	if err != nil && !errors.Is(err, probe.ErrNotReady) && ctx.Err() == nil {
+		fmt.Printf("%s %v\n", label, errors.Unwrap(err))
+	}
+	return err == nil
+}
+
+// waitForContainerEvent waits for the container's start or die event,
+// returning an error when events can't be streamed
+func waitForContainerEvent(ctx context.Context, source containerEventSource, name, label string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, containerStartTimeout)
+	defer cancel()
+
+	// Subscribe before inspecting so an event in between isn't missed
+	events, err := source.Events(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	if container, err := containerRuntime.Inspect(ctx, name); err == nil && container.Running {
+		return true, nil
+	} else if err == nil && container.State == "exited" {
+		fmt.Printf("%s container exited with code %d\n", label, container.ExitCode)
+		return false, nil
+	}
+
+	for event := range events {
+		switch event.Action {
+		case "start":
+			return true, nil
+		case "die":
+			fmt.Printf("%s container exited with code %d\n", label, event.ExitCode)
+			return false, nil
+		}
+	}
+	return isContainerRunning(ctx, name), nil
+}
+
+// findProcessPIDs returns the PIDs of processes whose command line matches pattern
+func findProcessPIDs(pattern string) []int {
+	processes, err := prober.FindProcesses(pattern)
+	if err != nil {
+		return nil
+	}
+
+	pids := []int{}
+	for _, process := range processes {
+		pids = append(pids, process.PID)
+	}
+	return pids
+}
+
+// joinPIDs formats PIDs as a comma separated list
+func joinPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// interruptContext returns a context cancelled on the first Ctrl+C or
+// SIGTERM, so the command can stop waiting and clean up. The signals are
+// then handled as usual again, so a second Ctrl+C exits immediately.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			fmt.Println("\n⚠️ Interrupted, stopping... Press Ctrl+C again to exit immediately.")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// waitFor checks every interval until check succeeds, timeout elapses or
+// ctx is done, returning the last error from check
+func waitFor(ctx context.Context, timeout, interval time.Duration, check func() error) error {
+	var last error
+	err := probe.Wait(ctx, probe.Func("condition", func(context.Context) error {
+		last = check()
+		return last
+	}), probe.Options{Timeout: timeout, Interval: interval, Multiplier: 1})
+	if err != nil && last != nil && ctx.Err() == nil {
+		return last
+	}
+	return err
 }
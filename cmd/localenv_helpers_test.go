@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lirtsman/devhelper-cli/internal/procnet"
 )
 
 // Create a variable for exec.Command that we can override in tests
@@ -101,6 +107,63 @@ func TestIsPortInUse(t *testing.T) {
 	}
 }
 
+// TestProcessDiscovery tests the helpers backed by the process prober
+func TestProcessDiscovery(t *testing.T) {
+	originalProber := prober
+	defer func() { prober = originalProber }()
+
+	t.Run("Dashboard found by command line", func(t *testing.T) {
+		prober = &procnet.Fake{
+			Processes: []procnet.Process{{PID: 42, Cmdline: "dapr dashboard -p 8080"}},
+		}
+		assert.Equal(t, "42", getDaprDashboardPID())
+	})
+
+	t.Run("Dashboard found by port", func(t *testing.T) {
+		prober = &procnet.Fake{
+			Listening: map[int][]int{8082: {7}, 8083: {43}},
+			Processes: []procnet.Process{{PID: 43, Cmdline: "/usr/local/bin/dashboard --port 8083"}},
+		}
+		assert.Equal(t, "43", getDaprDashboardPID())
+	})
+
+	t.Run("No dashboard", func(t *testing.T) {
+		prober = &procnet.Fake{Listening: map[int][]int{8080: {7}}}
+		assert.Equal(t, "", getDaprDashboardPID())
+		assert.True(t, isPortInUse(8080))
+		assert.False(t, isPortInUse(8081))
+	})
+
+	t.Run("Dashboards of other profiles should not count", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		port := server.Listener.Addr().(*net.TCPAddr).Port
+
+		// Another profile's dashboard runs, nothing answers on this profile's port
+		prober = &procnet.Fake{Processes: []procnet.Process{{PID: 42, Cmdline: "dapr dashboard -p 8080"}}}
+		env := &LocalEnv{ConfigLoaded: true}
+		env.Config.Components.Dapr.DashboardPort = closedPort(t)
+		assert.False(t, daprDashboardDriver{}.untrackedRunning(env), "a dashboard on another port should not count")
+
+		env.Config.Components.Dapr.DashboardPort = port
+		assert.True(t, daprDashboardDriver{}.untrackedRunning(env), "a dashboard answering on this port should count")
+
+		prober = &procnet.Fake{}
+		assert.False(t, daprDashboardDriver{}.untrackedRunning(env), "something else answering on the port should not count")
+	})
+
+	t.Run("findProcessPIDs", func(t *testing.T) {
+		prober = &procnet.Fake{
+			Processes: []procnet.Process{
+				{PID: 1, Cmdline: "temporal server start-dev"},
+				{PID: 2, Cmdline: "vim"},
+			},
+		}
+		assert.Equal(t, []int{1}, findProcessPIDs("temporal server"))
+		assert.Equal(t, "1, 2", joinPIDs([]int{1, 2}))
+	})
+}
+
 // TestDashboardFunctions tests all dashboard-related functionality in one place
 func TestDashboardFunctions(t *testing.T) {
 	// Define test cases for both functions
@@ -115,7 +178,7 @@ func TestDashboardFunctions(t *testing.T) {
 		{
 			name: "tryStartDashboard with non-existent command",
 			testFunc: func() bool {
-				return tryStartDashboard("non-existent-command", 8080, nil)
+				return tryStartDashboard(context.Background(), "non-existent-command", 8080, nil)
 			},
 			expectedResult: false,
 			description:    "Should return false for non-existent command",
@@ -125,7 +188,7 @@ func TestDashboardFunctions(t *testing.T) {
 		{
 			name: "tryStartDashboardWithTimeout with non-existent command",
 			testFunc: func() bool {
-				return tryStartDashboardWithTimeout("non-existent-command", 8080, nil, 100*time.Millisecond)
+				return tryStartDashboardWithTimeout(context.Background(), "non-existent-command", 8080, nil, 100*time.Millisecond)
 			},
 			expectedResult: false,
 			description:    "Should return false for non-existent command with custom timeout",
@@ -165,3 +228,14 @@ func TestHelperProcess(t *testing.T) {
 	// Default behavior
 	os.Exit(0)
 }
+
+// closedPort returns a port nothing listens on
+func closedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
@@ -0,0 +1,708 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// composeFile is the part of a docker-compose.yml that localenv understands
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+// composeService is a compose service. Fields with several accepted syntaxes
+// are decoded as interface{} and normalized by the import.
+type composeService struct {
+	Image       string              `yaml:"image"`
+	Build       interface{}         `yaml:"build"`
+	Ports       []interface{}       `yaml:"ports"`
+	Environment interface{}         `yaml:"environment"`
+	Volumes     []interface{}       `yaml:"volumes"`
+	DependsOn   interface{}         `yaml:"depends_on"`
+	Healthcheck *composeHealthcheck `yaml:"healthcheck"`
+	Command     interface{}         `yaml:"command"`
+	Networks    interface{}         `yaml:"networks"`
+}
+
+// composeHealthcheck is a compose service healthcheck
+type composeHealthcheck struct {
+	Test        interface{} `yaml:"test"`
+	Disable     bool        `yaml:"disable"`
+	Interval    string      `yaml:"interval"`
+	Timeout     string      `yaml:"timeout"`
+	StartPeriod string      `yaml:"start_period"`
+	Retries     int         `yaml:"retries"`
+}
+
+// composeNamePattern matches characters not allowed in component names
+var composeNamePattern = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// composeVariablePattern matches $$, $VAR and ${VAR} with the optional
+// :-, -, :?, ?, :+ and + modifiers compose supports
+var composeVariablePattern = regexp.MustCompile(`\$(?:\$|\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?+])([^}]*))?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// composeSource tells where a compose file is imported from and to
+type composeSource struct {
+	// ComposeDir is the directory of the compose file, which compose resolves relative paths against
+	ComposeDir string
+	// ConfigDir is the directory of localenv.yaml, which the custom driver resolves relative paths against
+	ConfigDir string
+	// LookupEnv returns the value of a variable used in the compose file
+	LookupEnv func(name string) (string, bool)
+}
+
+// newComposeSource returns the source of importing composePath into configPath.
+// Like compose, variables are taken from the environment and then from the
+// .env file next to the compose file.
+func newComposeSource(composePath, configPath string) composeSource {
+	composeDir := filepath.Dir(composePath)
+	dotEnv := readDotEnv(filepath.Join(composeDir, ".env"))
+	return composeSource{
+		ComposeDir: composeDir,
+		ConfigDir:  filepath.Dir(configPath),
+		LookupEnv: func(name string) (string, bool) {
+			if value, ok := os.LookupEnv(name); ok {
+				return value, true
+			}
+			value, ok := dotEnv[name]
+			return value, ok
+		},
+	}
+}
+
+// localenvImportCmd groups the commands importing configuration from other tools
+var localenvImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import components into localenv.yaml from other tools",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// localenvImportComposeCmd imports the services of a docker-compose.yml
+var localenvImportComposeCmd = &cobra.Command{
+	Use:   "compose <file>",
+	Short: "Import docker-compose.yml services as custom components",
+	Long: `Translate the services of a docker-compose.yml into components.custom entries
+in localenv.yaml, so they are started, health-checked, stopped and shown in
+status like the built-in components.
+
+Image, ports, environment, volumes, command, depends_on and healthcheck are
+imported. Services that are only built from source, and settings localenv
+can't represent, are reported and skipped. Variables are substituted from the
+environment and the .env file next to the compose file, and relative bind
+mounts are rewritten relative to localenv.yaml.
+
+Examples:
+  devhelper-cli localenv import compose docker-compose.yml
+  devhelper-cli localenv import compose docker-compose.yml --dry-run
+  devhelper-cli localenv import compose deps/compose.yml --force`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, _ := cmd.Flags().GetString("config")
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+
+		composeData, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Printf("❌ Failed to read compose file: %v\n", err)
+			os.Exit(1)
+		}
+
+		components, warnings, err := importComposeServices(composeData, newComposeSource(args[0], configPath))
+		if err != nil {
+			fmt.Printf("❌ Failed to parse %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Printf("⚠️ %s\n", warning)
+		}
+		if len(components) == 0 {
+			fmt.Println("❌ No services with an image were found to import")
+			os.Exit(1)
+		}
+
+		configData, err := os.ReadFile(configPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("❌ Failed to read configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		updated, imported, err := mergeCustomComponents(configPath, configData, components, force)
+		if err != nil {
+			printConfigError(configPath, err)
+			os.Exit(1)
+		}
+
+		if dryRun {
+			fmt.Print(string(updated))
+			return
+		}
+		if err := os.WriteFile(configPath, updated, 0644); err != nil {
+			fmt.Printf("❌ Failed to write configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, name := range imported {
+			fmt.Printf("✅ Imported %s\n", name)
+		}
+		fmt.Printf("\n✅ Configuration written to %s\n", configPath)
+		fmt.Println("Run 'devhelper-cli localenv start' to start the imported components.")
+	},
+}
+
+// importComposeServices converts compose services to custom components,
+// returning warnings for everything that couldn't be imported
+func importComposeServices(data []byte, source composeSource) (map[string]CustomComponentConfig, []string, error) {
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, nil, err
+	}
+	warnings, err := interpolateCompose(&root, source.LookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	compose := composeFile{}
+	if err := root.Decode(&compose); err != nil {
+		return nil, nil, err
+	}
+
+	serviceNames := []string{}
+	for name := range compose.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	components := map[string]CustomComponentConfig{}
+	for _, serviceName := range serviceNames {
+		service := compose.Services[serviceName]
+		name := composeComponentName(serviceName)
+		warn := func(format string, args ...interface{}) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", serviceName, fmt.Sprintf(format, args...)))
+		}
+
+		if service.Image == "" {
+			warn("skipped, services built from source are not supported (set image to import it)")
+			continue
+		}
+
+		component := CustomComponentConfig{Enabled: true, Image: service.Image}
+		for _, port := range service.Ports {
+			if mapping, ok := composePortMapping(port); ok {
+				component.Ports = append(component.Ports, mapping)
+			} else {
+				warn("port %v skipped, only single TCP ports are supported", port)
+			}
+		}
+
+		component.Env = composeEnvironment(service.Environment, warn)
+
+		for _, volume := range service.Volumes {
+			if mount, ok := composeVolume(volume, source); ok {
+				component.Volumes = append(component.Volumes, mount)
+			} else {
+				warn("volume %v skipped, only bind mounts and named volumes are supported", volume)
+			}
+		}
+
+		if command, ok := service.Command.(string); ok {
+			words, err := shellSplit(command)
+			if err != nil {
+				warn("command skipped, %v", err)
+			}
+			component.Command = words
+		} else {
+			component.Command = composeStrings(service.Command)
+		}
+
+		for _, dependency := range composeDependencies(service.DependsOn) {
+			if _, ok := compose.Services[dependency]; !ok {
+				warn("dependency %s skipped, it isn't a service in the compose file", dependency)
+				continue
+			}
+			component.DependsOn = append(component.DependsOn, composeComponentName(dependency))
+		}
+
+		if service.Healthcheck != nil && !service.Healthcheck.Disable {
+			component.HealthCheck = composeHealthCheck(*service.Healthcheck)
+		}
+
+		if networks := composeStrings(service.Networks); len(networks) > 0 {
+			component.Network = networks[0]
+			if len(networks) > 1 {
+				warn("only the first network (%s) is used", networks[0])
+			}
+		}
+
+		if _, exists := components[name]; exists {
+			warn("skipped, another service is also imported as %s", name)
+			continue
+		}
+		components[name] = component
+	}
+	return components, warnings, nil
+}
+
+// mergeCustomComponents adds components to components.custom in the
+// configuration, keeping the rest of the file as it is. Existing components
+// are only replaced with force. The result is validated before it's returned.
+func mergeCustomComponents(configPath string, configData []byte, components map[string]CustomComponentConfig, force bool) ([]byte, []string, error) {
+	root, err := parseConfigNode(configPath, configData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if root == nil {
+		root = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	}
+
+	names := []string{}
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := customComponentsKey + "." + name
+		if findConfigNode(root, path) != nil && !force {
+			return nil, nil, fmt.Errorf("custom component %s already exists in %s, use --force to replace it", name, configPath)
+		}
+
+		node := &yamlv3.Node{}
+		if err := node.Encode(components[name]); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate configuration: %w", err)
+		}
+		if err := setConfigNode(root, path, node); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate configuration: %w", err)
+	}
+
+	if _, err := validateLocalEnvConfigFile(configPath, buf.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), names, nil
+}
+
+// composeComponentName converts a compose service name to a component name
+func composeComponentName(service string) string {
+	name := composeNamePattern.ReplaceAllString(strings.ToLower(service), "-")
+	return strings.Trim(name, "-")
+}
+
+// composePortMapping converts a compose port to "hostPort:containerPort".
+// The host IP is dropped; ranges and UDP ports are not supported.
+func composePortMapping(port interface{}) (string, bool) {
+	var host, container string
+	switch value := port.(type) {
+	case int:
+		container = strconv.Itoa(value)
+	case string:
+		spec, protocol, _ := strings.Cut(value, "/")
+		if protocol != "" && protocol != "tcp" {
+			return "", false
+		}
+		parts := strings.Split(spec, ":")
+		container = parts[len(parts)-1]
+		if len(parts) > 1 {
+			host = parts[len(parts)-2]
+		}
+	case map[string]interface{}:
+		if protocol, ok := value["protocol"].(string); ok && protocol != "tcp" {
+			return "", false
+		}
+		container = fmt.Sprint(value["target"])
+		if published, ok := value["published"]; ok {
+			host = fmt.Sprint(published)
+		}
+	default:
+		return "", false
+	}
+
+	if host == "" {
+		host = container
+	}
+	mapping := host + ":" + container
+	if _, _, err := parsePortMapping(mapping); err != nil {
+		return "", false
+	}
+	return mapping, true
+}
+
+// composeEnvironment converts a compose environment map or "KEY=value" list.
+// Variables without a value would be taken from the shell by compose, so
+// they are reported instead.
+func composeEnvironment(environment interface{}, warn func(format string, args ...interface{})) map[string]string {
+	env := map[string]string{}
+	switch value := environment.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if item == nil {
+				warn("environment variable %s has no value and was skipped", key)
+				continue
+			}
+			env[key] = fmt.Sprint(item)
+		}
+	case []interface{}:
+		for _, item := range value {
+			key, val, found := strings.Cut(fmt.Sprint(item), "=")
+			if !found {
+				warn("environment variable %s has no value and was skipped", key)
+				continue
+			}
+			env[key] = val
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// composeVolume converts a compose volume to "source:target[:mode]".
+// Relative bind mounts are made relative to localenv.yaml instead of the
+// compose file.
+func composeVolume(volume interface{}, from composeSource) (string, bool) {
+	var mount string
+	switch value := volume.(type) {
+	case string:
+		if !strings.Contains(value, ":") {
+			// Anonymous volumes only live as long as the container
+			return "", false
+		}
+		mount = value
+	case map[string]interface{}:
+		volumeType, _ := value["type"].(string)
+		source, _ := value["source"].(string)
+		target, _ := value["target"].(string)
+		if (volumeType != "bind" && volumeType != "volume") || source == "" || target == "" {
+			return "", false
+		}
+		mount = source + ":" + target
+		if readOnly, _ := value["read_only"].(bool); readOnly {
+			mount += ":ro"
+		}
+	default:
+		return "", false
+	}
+
+	source, rest, _ := strings.Cut(mount, ":")
+	if source == "." || source == ".." || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		mount = rebasePath(source, from) + ":" + rest
+	}
+	return mount, true
+}
+
+// rebasePath converts a path relative to the compose file into one relative
+// to localenv.yaml, or an absolute path when there is no relative one
+func rebasePath(path string, from composeSource) string {
+	resolved, err := filepath.Abs(filepath.Join(from.ComposeDir, path))
+	if err != nil {
+		return path
+	}
+	configDir, err := filepath.Abs(from.ConfigDir)
+	if err != nil {
+		return filepath.ToSlash(resolved)
+	}
+	relative, err := filepath.Rel(configDir, resolved)
+	if err != nil {
+		return filepath.ToSlash(resolved)
+	}
+	// The custom driver only treats sources starting with ./ or ../ as paths
+	switch relative = filepath.ToSlash(relative); {
+	case relative == "." || relative == "..":
+		return relative + "/"
+	case strings.HasPrefix(relative, "../"):
+		return relative
+	}
+	return "./" + relative
+}
+
+// composeDependencies returns the service names of a depends_on list or map
+func composeDependencies(dependsOn interface{}) []string {
+	if value, ok := dependsOn.(map[string]interface{}); ok {
+		names := []string{}
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	return composeStrings(dependsOn)
+}
+
+// composeStrings converts a list to strings. A single string is returned as
+// the only item and maps return their keys.
+func composeStrings(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		return items
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	return nil
+}
+
+// composeHealthCheck converts a compose healthcheck into a command health
+// check, waiting as long as compose would before marking it unhealthy
+func composeHealthCheck(healthcheck composeHealthcheck) CustomHealthCheck {
+	test := composeStrings(healthcheck.Test)
+	check := CustomHealthCheck{}
+	switch {
+	case len(test) == 0 || test[0] == "NONE":
+		return check
+	case test[0] == "CMD-SHELL" && len(test) > 1:
+		check.Command = strings.Join(test[1:], " ")
+	case test[0] == "CMD":
+		check.Command = shellJoin(test[1:])
+	default:
+		check.Command = strings.Join(test, " ")
+	}
+
+	interval := parseComposeDuration(healthcheck.Interval, 30*time.Second)
+	startPeriod := parseComposeDuration(healthcheck.StartPeriod, 0)
+	retries := healthcheck.Retries
+	if retries <= 0 {
+		retries = 3
+	}
+	if wait := startPeriod + interval*time.Duration(retries); wait > defaultCustomHealthTimeout*time.Second {
+		check.Timeout = int(wait.Seconds())
+	}
+	return check
+}
+
+// parseComposeDuration parses a compose duration such as "10s" or "1m30s"
+func parseComposeDuration(value string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+	return fallback
+}
+
+// shellSplit splits a command into words like a POSIX shell, which compose
+// does for commands given as a string. Quotes and backslashes are
+// interpreted; variables, globs and operators are not.
+func shellSplit(command string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				// In double quotes a backslash only escapes characters special there
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("$`\"\\\n", command[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 < len(command) {
+				i++
+				word.WriteByte(command[i])
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// interpolateCompose substitutes variables in the values of a compose file
+// like compose does, returning a warning for every variable that isn't set
+func interpolateCompose(node *yamlv3.Node, lookupEnv func(string) (string, bool)) ([]string, error) {
+	warnings := []string{}
+	warned := map[string]bool{}
+	var interpolate func(node *yamlv3.Node, isKey bool) error
+	interpolate = func(node *yamlv3.Node, isKey bool) error {
+		if node.Kind == yamlv3.ScalarNode {
+			if isKey || !strings.Contains(node.Value, "$") {
+				return nil
+			}
+			var failure error
+			value := composeVariablePattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+				groups := composeVariablePattern.FindStringSubmatch(match)
+				name, modifier, operand := groups[1], groups[2], groups[3]
+				if name == "" {
+					name = groups[4]
+				}
+				if name == "" {
+					return "$"
+				}
+
+				value, set := lookupEnv(name)
+				nonEmpty := set && value != ""
+				switch modifier {
+				case ":-":
+					if !nonEmpty {
+						return operand
+					}
+				case "-":
+					if !set {
+						return operand
+					}
+				case ":?", "?":
+					if !nonEmpty && (modifier == ":?" || !set) && failure == nil {
+						failure = fmt.Errorf("variable %s is required: %s", name, operand)
+					}
+				case ":+":
+					if nonEmpty {
+						return operand
+					}
+					return ""
+				case "+":
+					if set {
+						return operand
+					}
+					return ""
+				}
+				if !set && !warned[name] {
+					warned[name] = true
+					warnings = append(warnings, fmt.Sprintf("variable %s is not set, an empty string was used", name))
+				}
+				return value
+			})
+			if failure != nil {
+				return failure
+			}
+			node.Value = value
+			if value == "" {
+				node.Tag = "!!str"
+			} else if node.Style&(yamlv3.SingleQuotedStyle|yamlv3.DoubleQuotedStyle|yamlv3.LiteralStyle|yamlv3.FoldedStyle) == 0 {
+				// Plain values are typed by what they contain after substitution, e.g. retries: ${RETRIES}
+				node.Tag = ""
+			}
+			return nil
+		}
+		for i, child := range node.Content {
+			if err := interpolate(child, node.Kind == yamlv3.MappingNode && i%2 == 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return warnings, interpolate(node, false)
+}
+
+// readDotEnv reads the KEY=value lines of a .env file, returning nothing if it doesn't exist
+func readDotEnv(path string) map[string]string {
+	values := map[string]string{}
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
+
+// shellJoin quotes arguments for sh -c
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()*?[]#~") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func init() {
+	localenvCmd.AddCommand(localenvImportCmd)
+	localenvImportCmd.AddCommand(localenvImportComposeCmd)
+
+	localenvImportComposeCmd.Flags().StringP("config", "c", "", "Path to environment configuration file (default: localenv.yaml)")
+	localenvImportComposeCmd.Flags().Bool("force", false, "Replace custom components that already exist")
+	localenvImportComposeCmd.Flags().Bool("dry-run", false, "Print the resulting configuration instead of writing it")
+}
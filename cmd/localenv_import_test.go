@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const composeConfig = `services:
+  db:
+    image: postgres:16
+    ports:
+      - "127.0.0.1:5432:5432"
+    environment:
+      POSTGRES_PASSWORD: secret
+      FROM_SHELL:
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - type: bind
+        source: ./init
+        target: /docker-entrypoint-initdb.d
+        read_only: true
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 10s
+      retries: 10
+  auth_server:
+    image: quay.io/keycloak/keycloak:${KEYCLOAK_VERSION:-25.0}
+    command: start-dev --http-port 8080
+    ports:
+      - target: 8080
+        published: 8180
+      - "9000-9001:9000-9001"
+    environment:
+      - KC_DB=postgres
+    depends_on:
+      db:
+        condition: service_healthy
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8080/health/ready"]
+  app:
+    build: .
+  worker:
+    image: busybox:1.36
+    command: sh -c "echo 'a b' \"c d\"" && exit
+    environment:
+      QUEUE: ${QUEUE}
+      PRICE: $$5
+      DEBUG: ${DEBUG-false}
+    healthcheck:
+      test: ["CMD", "true"]
+      retries: ${RETRIES}
+volumes:
+  pgdata:
+`
+
+// TestImportCompose tests translating docker-compose.yml services into custom components
+func TestImportCompose(t *testing.T) {
+	env := map[string]string{"RETRIES": "20"}
+	source := composeSource{ComposeDir: ".", ConfigDir: ".", LookupEnv: func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}}
+	components, warnings, err := importComposeServices([]byte(composeConfig), source)
+	assert.NoError(t, err)
+
+	t.Run("Services should be translated", func(t *testing.T) {
+		assert.Len(t, components, 3)
+
+		db := components["db"]
+		assert.True(t, db.Enabled)
+		assert.Equal(t, "postgres:16", db.Image)
+		assert.Equal(t, []string{"5432:5432"}, db.Ports)
+		assert.Equal(t, map[string]string{"POSTGRES_PASSWORD": "secret"}, db.Env)
+		assert.Equal(t, []string{"pgdata:/var/lib/postgresql/data", "./init:/docker-entrypoint-initdb.d:ro"}, db.Volumes)
+		assert.Equal(t, "pg_isready -U postgres", db.HealthCheck.Command)
+		assert.Equal(t, 100, db.HealthCheck.Timeout)
+
+		auth := components["auth-server"]
+		assert.Equal(t, "quay.io/keycloak/keycloak:25.0", auth.Image, "the default of an unset variable should be used")
+		assert.Equal(t, []string{"8180:8080"}, auth.Ports)
+		assert.Equal(t, []string{"start-dev", "--http-port", "8080"}, auth.Command)
+		assert.Equal(t, map[string]string{"KC_DB": "postgres"}, auth.Env)
+		assert.Equal(t, []string{"db"}, auth.DependsOn)
+		assert.Equal(t, "curl -f http://localhost:8080/health/ready", auth.HealthCheck.Command)
+		assert.Equal(t, 90, auth.HealthCheck.Timeout, "compose defaults of 30s interval and 3 retries should be used")
+
+		worker := components["worker"]
+		assert.Equal(t, []string{"sh", "-c", `echo 'a b' "c d"`, "&&", "exit"}, worker.Command, "quoted arguments should be kept together")
+		assert.Equal(t, map[string]string{"QUEUE": "", "PRICE": "$5", "DEBUG": "false"}, worker.Env)
+		assert.Equal(t, 600, worker.HealthCheck.Timeout, "retries should be taken from the environment")
+	})
+
+	t.Run("Unsupported settings should be reported", func(t *testing.T) {
+		assert.Len(t, warnings, 4)
+		assert.Contains(t, warnings, "variable QUEUE is not set, an empty string was used")
+		assert.Contains(t, warnings, "app: skipped, services built from source are not supported (set image to import it)")
+		assert.Contains(t, warnings, "auth_server: port 9000-9001:9000-9001 skipped, only single TCP ports are supported")
+		assert.Contains(t, warnings, "db: environment variable FROM_SHELL has no value and was skipped")
+	})
+
+	t.Run("Components should be merged into localenv.yaml", func(t *testing.T) {
+		existing := "components:\n  temporal:\n    enabled: true\n"
+		data, names, err := mergeCustomComponents("localenv.yaml", []byte(existing), components, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"auth-server", "db", "worker"}, names)
+
+		config, err := parseLocalEnvConfig("localenv.yaml", data, "")
+		assert.NoError(t, err)
+		assert.True(t, config.Components.Temporal.Enabled, "existing settings should be kept")
+		assert.Equal(t, components["db"], config.Components.Custom["db"])
+
+		_, _, err = mergeCustomComponents("localenv.yaml", data, components, false)
+		assert.ErrorContains(t, err, "use --force")
+
+		_, _, err = mergeCustomComponents("localenv.yaml", data, components, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Helpers should normalize compose syntax", func(t *testing.T) {
+		assert.Equal(t, "my-service", composeComponentName("My_Service"))
+
+		mapping, ok := composePortMapping(8080)
+		assert.True(t, ok)
+		assert.Equal(t, "8080:8080", mapping)
+		_, ok = composePortMapping("53:53/udp")
+		assert.False(t, ok)
+
+		_, ok = composeVolume("/anonymous", source)
+		assert.False(t, ok)
+
+		words, err := shellSplit(`echo "a \"b\"" 'c'd e\ f`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"echo", `a "b"`, "cd", "e f"}, words)
+		_, err = shellSplit(`echo "unterminated`)
+		assert.Error(t, err)
+
+		assert.Equal(t, `echo 'hello world'`, shellJoin([]string{"echo", "hello world"}))
+	})
+
+	t.Run("Relative bind mounts should be resolved against the compose file", func(t *testing.T) {
+		nested := composeSource{ComposeDir: "deploy", ConfigDir: "."}
+		mount, ok := composeVolume("./init:/docker-entrypoint-initdb.d:ro", nested)
+		assert.True(t, ok)
+		assert.Equal(t, "./deploy/init:/docker-entrypoint-initdb.d:ro", mount)
+
+		mount, _ = composeVolume(map[string]interface{}{"type": "bind", "source": "../shared", "target": "/shared"}, nested)
+		assert.Equal(t, "./shared:/shared", mount)
+		mount, _ = composeVolume(".:/app", composeSource{ComposeDir: ".", ConfigDir: "config"})
+		assert.Equal(t, "../:/app", mount)
+		mount, _ = composeVolume("pgdata:/data", nested)
+		assert.Equal(t, "pgdata:/data", mount, "named volumes should be kept")
+	})
+
+	t.Run("Required variables should fail the import", func(t *testing.T) {
+		_, _, err := importComposeServices([]byte("services:\n  db:\n    image: postgres:${TAG:?set the tag}\n"), source)
+		assert.ErrorContains(t, err, "variable TAG is required: set the tag")
+	})
+}
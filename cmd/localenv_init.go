@@ -18,6 +18,7 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -39,6 +40,9 @@ type ToolVersion struct {
 	AutoInstallable bool   // Whether the tool can be auto-installed
 }
 
+// defaultOpenSearchVersion is the OpenSearch image tag used by new configurations
+const defaultOpenSearchVersion = "2.17.1"
+
 // Required tool versions
 var requiredVersions = map[string]ToolVersion{
 	"podman": {
@@ -50,6 +54,15 @@ var requiredVersions = map[string]ToolVersion{
 		VersionRegex:    `version (\d+\.\d+\.\d+)`,
 		AutoInstallable: true,
 	},
+	"docker": {
+		Name:            "Docker",
+		MinVersion:      "24.0.0",
+		InstallCommand:  "brew install --cask docker",
+		UpdateCommand:   "brew upgrade --cask docker",
+		InstallURL:      "https://docs.docker.com/get-docker/",
+		VersionRegex:    `version (\d+\.\d+\.\d+)`,
+		AutoInstallable: false,
+	},
 	"kind": {
 		Name:            "Kind",
 		MinVersion:      "0.14.0", // Keeping existing version as we don't know user's version
@@ -92,7 +105,9 @@ var requiredVersions = map[string]ToolVersion{
 // LocalEnvConfig represents the configuration for the local environment
 type LocalEnvConfig struct {
 	Tools struct {
-		Podman struct {
+		// ContainerRuntime is "podman" or "docker"; when empty the first installed one is used
+		ContainerRuntime string `yaml:"containerRuntime,omitempty"`
+		Podman           struct {
 			Path    string `yaml:"path"`
 			Version string `yaml:"version"`
 		} `yaml:"podman"`
@@ -115,20 +130,42 @@ type LocalEnvConfig struct {
 			Dashboard     bool `yaml:"dashboard"`
 			DashboardPort int  `yaml:"dashboardPort"`
 			ZipkinPort    int  `yaml:"zipkinPort"`
+			// Resources is the directory start installs Components into, relative
+			// to localenv.yaml (default .dapr/resources)
+			Resources  string                         `yaml:"resources,omitempty"`
+			Components map[string]DaprComponentConfig `yaml:"components,omitempty"`
 		} `yaml:"dapr"`
 		Temporal struct {
 			Enabled   bool   `yaml:"enabled"`
 			Namespace string `yaml:"namespace"`
-			UIPort    int    `yaml:"uiPort"`
-			GRPCPort  int    `yaml:"grpcPort"`
+			// Namespaces are created and kept in sync by start, alongside Namespace
+			Namespaces []TemporalNamespaceConfig `yaml:"namespaces,omitempty"`
+			UIPort     int                       `yaml:"uiPort"`
+			GRPCPort   int                       `yaml:"grpcPort"`
+			// Persist keeps workflow history in DBFile, or in temporal.db under the
+			// profile's data directory when DBFile is empty
+			Persist bool   `yaml:"persist,omitempty"`
+			DBFile  string `yaml:"dbFile,omitempty"`
+			// LogLevel and DynamicConfig are passed to start-dev as --log-level and --dynamic-config-value
+			LogLevel      string                 `yaml:"logLevel,omitempty"`
+			DynamicConfig map[string]interface{} `yaml:"dynamicConfig,omitempty"`
 		} `yaml:"temporal"`
 		OpenSearch struct {
 			Enabled       bool   `yaml:"enabled"`
 			Version       string `yaml:"version"`
 			Port          int    `yaml:"port"`
 			DashboardPort int    `yaml:"dashboardPort"`
+			// Ephemeral keeps the data in the container instead of a named volume, losing it on restart
+			Ephemeral bool `yaml:"ephemeral,omitempty"`
 		} `yaml:"openSearch"`
+		// Custom declares additional container-backed services by name
+		Custom map[string]CustomComponentConfig `yaml:"custom,omitempty"`
 	} `yaml:"components"`
+	// Apps declares the applications started with Dapr sidecars by `localenv run`
+	Apps map[string]AppConfig `yaml:"apps,omitempty"`
+	// Profiles are named overlays selected with --profile. Each holds the
+	// subset of the settings above that differs from the top level.
+	Profiles map[string]yamlv3.Node `yaml:"profiles,omitempty"`
 }
 
 // initCmd represents the init command
@@ -174,14 +211,24 @@ This command should be run once before using other localenv commands.`,
 		// Set default OpenSearch configuration
 		config.Components.OpenSearch.Port = 9200
 		config.Components.OpenSearch.DashboardPort = 5601
-		config.Components.OpenSearch.Version = "2.17.1"
+		config.Components.OpenSearch.Version = defaultOpenSearchVersion
 
 		// Check for required tools and record their paths
 		fmt.Println("\n=== Validating Required Tools ===")
 
-		// Check Podman
-		podmanPath, podmanErr, podmanVersion := validateToolWithVersionDetection("podman", "--version", verbose)
-		if podmanErr != nil {
+		// Check Podman, or use Docker when only Docker is installed
+		var podmanErr error
+		if !isCommandAvailable("podman") && isCommandAvailable("docker") {
+			config.Tools.ContainerRuntime = "docker"
+			dockerPath, dockerErr, dockerVersion := validateToolWithVersionDetection("docker", "--version", verbose)
+			if dockerErr != nil {
+				fmt.Printf("❌ Docker: %v\n", dockerErr)
+			} else {
+				fmt.Printf("✅ Docker: Found at %s (Version: %s)\n", dockerPath, dockerVersion)
+				fmt.Println("   Podman is not installed, containers will run with Docker")
+			}
+		} else if podmanPath, err, podmanVersion := validateToolWithVersionDetection("podman", "--version", verbose); err != nil {
+			podmanErr = err
 			fmt.Printf("❌ Podman: %v\n", podmanErr)
 			fmt.Println("   Please install Podman from: https://podman.io/getting-started/installation")
 		} else {
@@ -233,7 +280,7 @@ This command should be run once before using other localenv commands.`,
 			dockerAvailable := isCommandAvailable("docker")
 
 			// If Podman is available but Docker is not, add a note about using --container-runtime
-			if podmanErr == nil && !dockerAvailable {
+			if config.Tools.ContainerRuntime == "" && podmanErr == nil && !dockerAvailable {
 				fmt.Println("   Note: Dapr will be initialized with '--container-runtime podman' since Docker is not available")
 				fmt.Println("   This will start Redis, Zipkin, placement, and scheduler containers using Podman")
 			}
@@ -275,24 +322,18 @@ This command should be run once before using other localenv commands.`,
 			config.Tools.TemporalCli.Version = temporalVersion
 		}
 
-		// Check Podman (for OpenSearch)
-		podmanPath, podmanErr, _ = validateToolWithVersionDetection("podman", "--version", verbose)
-		if podmanErr != nil {
-			fmt.Printf("❌ Podman: %v\n", podmanErr)
+		// Check the container runtime (for OpenSearch)
+		runtime := selectContainerRuntime(config)
+		if !runtime.Available() {
+			fmt.Println("❌ Podman or Docker is required for running OpenSearch")
 			fmt.Println("   Please install Podman from: https://podman.io/getting-started/installation")
-			fmt.Println("   Note: Podman is required for running OpenSearch")
+			config.Components.OpenSearch.Enabled = false
+		} else if err := runtime.Ping(context.Background()); err != nil {
+			fmt.Printf("⚠️  %s is installed but may not be running\n", runtime.Label())
+			fmt.Printf("   Start %s and try again\n", runtime.Label())
 			config.Components.OpenSearch.Enabled = false
 		} else {
-			fmt.Printf("✅ Podman: Found at %s\n", podmanPath)
-			config.Tools.Podman.Path = podmanPath
-
-			// Check if Podman is running
-			cmd := exec.Command(podmanPath, "ps")
-			if err := cmd.Run(); err != nil {
-				fmt.Println("⚠️  Podman is installed but may not be running")
-				fmt.Println("   Start Podman and try again")
-				config.Components.OpenSearch.Enabled = false
-			}
+			fmt.Printf("✅ %s: Can run OpenSearch containers\n", runtime.Label())
 		}
 
 		fmt.Println("=== Components ===")
@@ -358,23 +399,13 @@ func validateToolWithVersionDetection(name, versionFlag string, verbose bool) (s
 	}
 
 	// Check if the tool works by running version command
-	cmd := exec.Command(path, versionFlag)
-	output, err := cmd.CombinedOutput()
+	currentVersion, err := detectToolVersion(path, name, versionFlag, verbose)
 	if err != nil {
-		return path, fmt.Errorf("found but failed to run: %v", err), ""
+		return path, err, ""
 	}
 
-	outputStr := string(output)
-	if verbose {
-		fmt.Printf("   %s version output: %s\n", name, strings.TrimSpace(outputStr))
-	}
-
-	// Extract version
-	var currentVersion string
 	versionInfo, ok := requiredVersions[name]
 	if ok {
-		currentVersion = extractVersion(outputStr, versionInfo.VersionRegex)
-
 		if currentVersion != "" {
 			if compareVersions(currentVersion, versionInfo.MinVersion) < 0 {
 				fmt.Printf("⚠️ %s version %s is below recommended minimum %s\n", versionInfo.Name, currentVersion, versionInfo.MinVersion)
@@ -391,14 +422,9 @@ func validateToolWithVersionDetection(name, versionFlag string, verbose bool) (s
 						fmt.Printf("✅ %s successfully updated\n", versionInfo.Name)
 
 						// Get new version after update
-						cmd = exec.Command(path, versionFlag)
-						newOutput, err := cmd.CombinedOutput()
-						if err == nil {
-							newVersion := extractVersion(string(newOutput), versionInfo.VersionRegex)
-							if newVersion != "" {
-								currentVersion = newVersion
-								fmt.Printf("   New version: %s\n", currentVersion)
-							}
+						if newVersion, err := detectToolVersion(path, name, versionFlag, false); err == nil && newVersion != "" {
+							currentVersion = newVersion
+							fmt.Printf("   New version: %s\n", currentVersion)
 						}
 					}
 				}
@@ -411,6 +437,25 @@ func validateToolWithVersionDetection(name, versionFlag string, verbose bool) (s
 	return path, nil, currentVersion
 }
 
+// detectToolVersion runs the tool's version command and extracts its version
+// without prompting. The version is empty when it can't be parsed.
+func detectToolVersion(path, name, versionFlag string, verbose bool) (string, error) {
+	output, err := exec.Command(path, versionFlag).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("found but failed to run: %v", err)
+	}
+
+	outputStr := string(output)
+	if verbose {
+		fmt.Printf("   %s version output: %s\n", name, strings.TrimSpace(outputStr))
+	}
+
+	if versionInfo, ok := requiredVersions[name]; ok {
+		return extractVersion(outputStr, versionInfo.VersionRegex), nil
+	}
+	return "", nil
+}
+
 // Modify the existing validateTool to use the new function
 func validateTool(name, versionFlag string, verbose bool) (string, error) {
 	path, err, _ := validateToolWithVersionDetection(name, versionFlag, verbose)
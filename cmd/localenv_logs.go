@@ -0,0 +1,378 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs [component...]",
+	Short: "View logs for local development components",
+	Long: `View the logs of locally running components and apps. Without arguments
+the logs of every enabled component and of the apps started with
+'localenv run' are shown, prefixed with their source and interleaved by time.
+
+Temporal server logs and other JSON logs are pretty-printed with colored
+levels; --component, --workflow-id and --json apply to their fields.
+Lines that can't be parsed are shown as they are.
+
+Log files are rotated by size and age (see the logs.* keys of the global
+configuration); --previous includes the rotated segments.
+
+Examples:
+  devhelper-cli localenv logs temporal                   # View Temporal server logs
+  devhelper-cli localenv logs temporal -f                # Follow Temporal server logs
+  devhelper-cli localenv logs -f                         # Follow every component
+  devhelper-cli localenv logs dapr opensearch --since 10m
+  devhelper-cli localenv logs --level warn --grep timeout
+  devhelper-cli localenv logs temporal --component history --workflow-id order-42
+  devhelper-cli localenv logs temporal --previous -n 0   # Include rotated segments
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		lines, _ := cmd.Flags().GetInt("lines")
+		since, _ := cmd.Flags().GetString("since")
+		grep, _ := cmd.Flags().GetString("grep")
+		level, _ := cmd.Flags().GetString("level")
+		component, _ := cmd.Flags().GetString("component")
+		workflowID, _ := cmd.Flags().GetString("workflow-id")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		previous, _ := cmd.Flags().GetBool("previous")
+		configPath, _ := cmd.Flags().GetString("config")
+
+		if previous && follow {
+			fmt.Println("❌ --previous can't be combined with --follow")
+			os.Exit(1)
+		}
+
+		filter, err := newLogFilter(since, grep, level, component, workflowID)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+
+		env := &LocalEnv{ConfigPath: configPath, Verbose: verbose, State: loadLocalEnvState()}
+		env.Config, env.ConfigLoaded, _ = loadLocalEnvConfig(configPath)
+		containerRuntime = selectContainerRuntime(env.Config)
+
+		sources := []LogSource{}
+		if len(args) == 0 {
+			sources = environmentLogSources(env)
+			if len(sources) == 0 {
+				fmt.Println("❌ No component logs found.")
+				fmt.Println("   Try starting the environment first using 'devhelper-cli localenv start'")
+				os.Exit(1)
+			}
+		}
+		for _, name := range args {
+			driver, ok := findEnvironmentComponent(env.Config, name)
+			if !ok {
+				fmt.Printf("❌ Unknown component: %s\n", name)
+				fmt.Printf("   Supported components: %s\n", strings.Join(componentNames(env.Config), ", "))
+				os.Exit(1)
+			}
+			driverSources := driver.LogSources(env)
+			if len(driverSources) == 0 {
+				fmt.Printf("❌ Logs are not captured for component '%s'\n", name)
+				os.Exit(1)
+			}
+			for _, source := range driverSources {
+				if source.File == "" {
+					continue
+				}
+				if _, err := os.Stat(source.File); err != nil {
+					fmt.Printf("❌ Log file not found for component '%s': %v\n", name, err)
+					fmt.Printf("   Try starting %s first using 'devhelper-cli localenv start'\n", name)
+					os.Exit(1)
+				}
+			}
+			sources = append(sources, driverSources...)
+		}
+
+		if follow {
+			fmt.Printf("Following logs for %s. Press Ctrl+C to stop...\n", strings.Join(logSourceNames(sources), ", "))
+		}
+
+		printer := newLogPrinter(os.Stdout, logSourceNames(sources), useColor(), jsonOutput)
+		opts := LogOptions{Follow: follow, Lines: lines, Since: filter.Since, Timestamps: true, Previous: previous}
+		if err := showLogs(sources, opts, filter, printer); err != nil {
+			fmt.Printf("Error reading logs: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// newLogFilter builds a filter from the logs command flags
+func newLogFilter(since, grep, level, component, workflowID string) (logFilter, error) {
+	filter := logFilter{Component: component, WorkflowID: workflowID}
+
+	var err error
+	if filter.Since, err = parseSince(since, time.Now()); err != nil {
+		return filter, err
+	}
+	if grep != "" {
+		if filter.Grep, err = regexp.Compile(grep); err != nil {
+			return filter, fmt.Errorf("--grep %q is not a valid regular expression: %w", grep, err)
+		}
+	}
+	if level != "" {
+		filter.Level = normalizeLogLevel(level)
+		if filter.Level == "" {
+			return filter, fmt.Errorf("--level %q is not one of %s", level, strings.Join(logLevels, ", "))
+		}
+	}
+	return filter, nil
+}
+
+// environmentLogSources returns the log sources of the enabled components
+// and of the apps that have written logs
+func environmentLogSources(env *LocalEnv) []LogSource {
+	sources := []LogSource{}
+	for _, driver := range environmentComponents(env.Config) {
+		if !driver.Enabled(env) || !driver.Installed() {
+			continue
+		}
+		for _, source := range driver.LogSources(env) {
+			if source.Container != "" && !containerExists(env.ctx(), source.Container) {
+				continue
+			}
+			if source.File != "" {
+				if _, err := os.Stat(source.File); err != nil {
+					continue
+				}
+			}
+			sources = append(sources, source)
+		}
+	}
+	for _, driver := range environmentApps(env.Config) {
+		for _, source := range driver.LogSources(env) {
+			if _, err := os.Stat(source.File); err == nil {
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources
+}
+
+// logSourceNames returns the names of sources
+func logSourceNames(sources []LogSource) []string {
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, source.Name)
+	}
+	return names
+}
+
+// readLogSource reads the lines of a log file or container, passing each to line
+func readLogSource(source LogSource, opts LogOptions, line func(string)) error {
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	go func() {
+		var err error
+		switch {
+		case source.Container != "":
+			err = containerRuntime.Logs(context.Background(), source.Container, opts, writer, writer)
+		case opts.Previous:
+			err = copyLogSegments(source.File, writer)
+		default:
+			err = tailLogFile(source.File, opts, writer)
+		}
+		writer.CloseWithError(err)
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", source.Name, err)
+	}
+	return nil
+}
+
+// showLogs prints the logs of sources. When following, entries are printed as
+// they arrive; otherwise the last opts.Lines matching entries of each source
+// are merged by time.
+func showLogs(sources []LogSource, opts LogOptions, filter logFilter, printer *logPrinter) error {
+	// Filtering needs the whole log to find the last matching lines
+	fetch := opts
+	if !opts.Follow && (filter.active() || opts.Previous) {
+		fetch.Lines = 0
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	collected := make([][]logEntry, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parser := &logSourceParser{source: source.Name, container: source.Container != "" && opts.Timestamps}
+			err := readLogSource(source, fetch, func(line string) {
+				entry := parser.parse(line)
+				if !filter.match(entry) {
+					return
+				}
+				if opts.Follow {
+					printer.print(entry)
+					return
+				}
+				collected[i] = append(collected[i], entry)
+				if opts.Lines > 0 && len(collected[i]) > opts.Lines {
+					collected[i] = collected[i][1:]
+				}
+			})
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	merged := slices.Concat(collected...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Before(merged[j].Time)
+	})
+	for _, entry := range merged {
+		printer.print(entry)
+	}
+	return errors.Join(errs...)
+}
+
+// tailLogFile writes the last lines of a log file to out, all of them when
+// opts.Lines is 0, optionally following it
+func tailLogFile(logPath string, opts LogOptions, out io.Writer) error {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	offset, err := lastLinesOffset(file, info.Size(), opts.Lines)
+	if err == nil {
+		_, err = io.Copy(out, io.NewSectionReader(file, offset, info.Size()-offset))
+	}
+	if err != nil || !opts.Follow {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Seek(info.Size(), io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	return followLogFile(file, logPath, out, nil)
+}
+
+// copyLogSegments writes the rotated segments of a log file, oldest first,
+// followed by the file itself
+func copyLogSegments(logPath string, out io.Writer) error {
+	for _, segment := range append(rotatedLogFiles(logPath), logPath) {
+		reader, err := openLogSegment(segment)
+		if errors.Is(err, os.ErrNotExist) && segment == logPath {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", segment, err)
+		}
+	}
+	return nil
+}
+
+// readLastNLines reads the last n lines of a file, all of them when n is 0
+func readLastNLines(filePath string, n int) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset, err := lastLinesOffset(file, info.Size(), n)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+	if err != nil {
+		return nil, err
+	}
+	return splitLogLines(data), nil
+}
+
+// displayLastNLines prints the last N lines of a file
+func displayLastNLines(filePath string, n int) {
+	lines, err := readLastNLines(filePath, n)
+	if err != nil {
+		fmt.Printf("Error reading log file: %v\n", err)
+		return
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	localenvCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().BoolP("follow", "f", false, "Follow the logs (like tail -f)")
+	logsCmd.Flags().IntP("lines", "n", 50, "Number of lines to display per source")
+	logsCmd.Flags().String("since", "", "Only show logs newer than a duration (e.g. 10m) or time (e.g. 2024-05-01T10:00:00)")
+	logsCmd.Flags().String("grep", "", "Only show lines matching a regular expression")
+	logsCmd.Flags().String("level", "", "Only show entries at or above a level (debug, info, warn, error)")
+	logsCmd.Flags().String("component", "", "Only show structured entries of a Temporal service or component (e.g. history)")
+	logsCmd.Flags().String("workflow-id", "", "Only show structured entries of a workflow")
+	logsCmd.Flags().Bool("json", false, "Print entries as JSON objects")
+	logsCmd.Flags().Bool("previous", false, "Include rotated log segments (use -n 0 for the whole history)")
+	logsCmd.Flags().StringP("config", "c", "", "Path to localenv configuration file")
+}
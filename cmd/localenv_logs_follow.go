@@ -0,0 +1,151 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// logReadChunk is how much of a log file is read at a time when searching
+// backwards for its last lines
+const logReadChunk = 64 * 1024
+
+// logFollowPoll is how often a followed log file is checked when no file
+// event arrives, e.g. on file systems without change notifications
+var logFollowPoll = time.Second
+
+// lastLinesOffset returns the offset where the last n lines of the first
+// size bytes of file start, reading backwards from size. A trailing newline
+// doesn't start another line, and n <= 0 selects the whole file.
+func lastLinesOffset(file io.ReaderAt, size int64, n int) (int64, error) {
+	if n <= 0 || size == 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, logReadChunk)
+	end := size
+	// The newline ending the last line doesn't count
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return 0, err
+	}
+	if last[0] == '\n' {
+		end--
+	}
+
+	for end > 0 {
+		start := max(end-logReadChunk, 0)
+		chunk := buf[:end-start]
+		if _, err := file.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				continue
+			}
+			if n--; n == 0 {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// followLogFile writes what is appended to the log at path to out, from the
+// current position of file, until done is closed. A truncated log is read
+// again from its start, and when the log is rotated the old file is finished
+// before the new one is followed. file is closed on return.
+func followLogFile(file *os.File, path string, out io.Writer, done <-chan struct{}) error {
+	defer func() { file.Close() }()
+
+	// The directory is watched so the log recreated by a rotation is noticed;
+	// polling covers file systems where watching doesn't work
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if watcher, err := fsnotify.NewWatcher(); err == nil {
+		defer watcher.Close()
+		if watcher.Add(filepath.Dir(path)) == nil {
+			events, watchErrors = watcher.Events, watcher.Errors
+		}
+	}
+	ticker := time.NewTicker(logFollowPoll)
+	defer ticker.Stop()
+
+	for {
+		if _, err := io.Copy(out, file); err != nil {
+			return err
+		}
+
+		position, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		current, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		latest, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// Rotated and not recreated yet
+		case !os.SameFile(current, latest):
+			if next, err := os.Open(path); err == nil {
+				file.Close()
+				file = next
+				continue
+			}
+		case latest.Size() < position:
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case <-done:
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case _, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// splitLogLines splits data into lines, dropping the newline ending the last one
+func splitLogLines(data []byte) []string {
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return []string{}
+	}
+	lines := []string{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		lines = append(lines, string(bytes.TrimSuffix(line, []byte("\r"))))
+	}
+	return lines
+}
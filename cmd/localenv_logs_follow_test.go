@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestLogFollower tests reading the end of log files and following them
+func TestLogFollower(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Last lines should be found across read chunks", func(t *testing.T) {
+		path := filepath.Join(dir, "big.log")
+		var content strings.Builder
+		for i := 1; i <= 20000; i++ {
+			fmt.Fprintf(&content, "line %d\n", i)
+		}
+		os.WriteFile(path, []byte(content.String()), 0644)
+
+		lines, err := readLastNLines(path, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"line 19998", "line 19999", "line 20000"}, lines)
+
+		lines, err = readLastNLines(path, 0)
+		assert.NoError(t, err)
+		assert.Len(t, lines, 20000)
+
+		var out bytes.Buffer
+		assert.NoError(t, tailLogFile(path, LogOptions{Lines: 10000}, &out))
+		assert.True(t, strings.HasPrefix(out.String(), "line 10001\n"))
+	})
+
+	t.Run("Last lines should handle short files and missing newlines", func(t *testing.T) {
+		path := filepath.Join(dir, "short.log")
+		os.WriteFile(path, []byte("one\ntwo\nthree"), 0644)
+
+		lines, err := readLastNLines(path, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"two", "three"}, lines)
+
+		lines, err = readLastNLines(path, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"one", "two", "three"}, lines)
+
+		os.WriteFile(path, nil, 0644)
+		lines, err = readLastNLines(path, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, lines)
+
+		_, err = readLastNLines(filepath.Join(dir, "missing.log"), 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("Following should survive truncation and rotation", func(t *testing.T) {
+		previousPoll := logFollowPoll
+		logFollowPoll = 50 * time.Millisecond
+		defer func() { logFollowPoll = previousPoll }()
+
+		path := filepath.Join(dir, "app-orders.log")
+		os.WriteFile(path, []byte("before\n"), 0644)
+		file, err := os.Open(path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		file.Seek(0, io.SeekEnd)
+
+		out := &lockedBuffer{}
+		done := make(chan struct{})
+		finished := make(chan error)
+		go func() {
+			finished <- followLogFile(file, path, out, done)
+		}()
+		waitForOutput := func(want string) {
+			assert.Eventually(t, func() bool { return strings.HasSuffix(out.String(), want) }, 5*time.Second, 10*time.Millisecond, "got %q", out.String())
+		}
+
+		appendLog := func(line string) {
+			log, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			log.WriteString(line)
+			log.Close()
+		}
+		appendLog("appended\n")
+		waitForOutput("appended\n")
+
+		os.WriteFile(path, []byte("truncated\n"), 0644)
+		waitForOutput("truncated\n")
+
+		// The rest of the old file is read before the new file
+		appendLog("last of old\n")
+		os.Rename(path, path+".1")
+		appendLog("first of new\n")
+		waitForOutput("last of old\nfirst of new\n")
+
+		close(done)
+		assert.NoError(t, <-finished)
+		assert.NotContains(t, out.String(), "before")
+	})
+}
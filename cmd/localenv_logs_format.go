@@ -0,0 +1,380 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logLevels are the levels accepted by --level, from least to most severe
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+var (
+	// logfmtLevelPattern finds the level of logfmt lines such as Dapr's level=info
+	logfmtLevelPattern = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)=["']?(\w+)`)
+	// plainLevelPattern finds levels written as words, such as OpenSearch's [INFO ]
+	plainLevelPattern = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC)\b`)
+	// logfmtTimePattern finds the timestamp of logfmt lines
+	logfmtTimePattern = regexp.MustCompile(`\b(?:time|ts)="?(\d{4}-\d{2}-\d{2}[T ][0-9:.,]+(?:Z|[+-]\d{2}:?\d{2})?)`)
+	// leadingTimePattern finds a timestamp at the start of a line
+	leadingTimePattern = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)`)
+)
+
+// logTimeLayouts are the timestamp formats recognized in log lines
+var logTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// logEntry is a parsed log line
+type logEntry struct {
+	Source  string
+	Time    time.Time
+	Level   string // One of logLevels, or empty when unknown
+	Message string
+	// Fields holds the fields of JSON lines such as Temporal's, nil otherwise
+	Fields map[string]interface{}
+	Raw    string
+}
+
+// normalizeLogLevel maps the level names used by different loggers onto logLevels
+func normalizeLogLevel(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "trace", "debug", "dbg":
+		return "debug"
+	case "info", "inf", "information", "notice":
+		return "info"
+	case "warn", "warning", "wrn":
+		return "warn"
+	case "error", "err", "fatal", "panic", "dpanic", "crit", "critical":
+		return "error"
+	}
+	return ""
+}
+
+// logLevelRank returns the position of a level in logLevels, or -1
+func logLevelRank(level string) int {
+	return slices.Index(logLevels, level)
+}
+
+// parseLogTime parses a timestamp field, which is a string in one of
+// logTimeLayouts or a number of seconds since the epoch as zap writes by default
+func parseLogTime(value interface{}) time.Time {
+	switch value := value.(type) {
+	case string:
+		value = strings.Replace(value, ",", ".", 1)
+		for _, layout := range logTimeLayouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed
+			}
+		}
+	case json.Number:
+		if seconds, err := value.Float64(); err == nil && seconds > 0 {
+			if seconds > 1e12 {
+				seconds /= 1000 // Milliseconds
+			}
+			return time.Unix(0, int64(seconds*float64(time.Second)))
+		}
+	}
+	return time.Time{}
+}
+
+// logField returns the first of keys present in fields as a string
+func logField(fields map[string]interface{}, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return fmt.Sprint(value), true
+		}
+	}
+	return "", false
+}
+
+// parseLogLine parses a line of a log source. JSON lines keep their fields;
+// other lines only get the level and timestamp that can be recognized.
+func parseLogLine(source, line string) logEntry {
+	entry := logEntry{Source: source, Message: line, Raw: line}
+
+	if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "{") {
+		fields := map[string]interface{}{}
+		decoder := json.NewDecoder(strings.NewReader(trimmed))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err == nil {
+			entry.Fields = fields
+			level, _ := logField(fields, "level", "lvl", "severity")
+			entry.Level = normalizeLogLevel(level)
+			if message, ok := logField(fields, "msg", "message"); ok {
+				entry.Message = message
+			}
+			for _, key := range []string{"ts", "time", "timestamp", "@timestamp"} {
+				if entry.Time = parseLogTime(fields[key]); !entry.Time.IsZero() {
+					break
+				}
+			}
+			return entry
+		}
+	}
+
+	if match := logfmtLevelPattern.FindStringSubmatch(line); match != nil {
+		entry.Level = normalizeLogLevel(match[1])
+	}
+	if match := plainLevelPattern.FindStringSubmatch(line); entry.Level == "" && match != nil {
+		entry.Level = normalizeLogLevel(match[1])
+	}
+	if match := leadingTimePattern.FindStringSubmatch(line); match != nil {
+		entry.Time = parseLogTime(match[1])
+	} else if match := logfmtTimePattern.FindStringSubmatch(line); match != nil {
+		entry.Time = parseLogTime(match[1])
+	}
+	return entry
+}
+
+// logSourceParser parses the lines of one source. Lines without a level or
+// timestamp, such as stack traces, take them from the line before.
+type logSourceParser struct {
+	source string
+	// container lines are prefixed with the timestamp added by the runtime
+	container bool
+	last      logEntry
+}
+
+func (p *logSourceParser) parse(line string) logEntry {
+	var received time.Time
+	if p.container {
+		if stamp, rest, ok := strings.Cut(line, " "); ok {
+			if parsed, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+				received, line = parsed, rest
+			}
+		}
+	}
+
+	entry := parseLogLine(p.source, line)
+	if entry.Time.IsZero() {
+		entry.Time = received
+	}
+	if entry.Time.IsZero() {
+		entry.Time = p.last.Time
+	}
+	if entry.Level == "" && entry.Fields == nil {
+		entry.Level = p.last.Level
+	}
+	p.last = entry
+	return entry
+}
+
+// logFilter selects the log entries to show
+type logFilter struct {
+	Since time.Time
+	Grep  *regexp.Regexp
+	// Level is the least severe level shown
+	Level string
+	// Component and WorkflowID match fields of structured logs such as Temporal's
+	Component  string
+	WorkflowID string
+}
+
+// active reports whether the filter can drop entries
+func (f logFilter) active() bool {
+	return !f.Since.IsZero() || f.Grep != nil || f.Level != "" || f.Component != "" || f.WorkflowID != ""
+}
+
+// match reports whether an entry passes the filter. Entries whose time is
+// unknown pass --since, entries whose level is unknown don't pass --level.
+func (f logFilter) match(entry logEntry) bool {
+	if !f.Since.IsZero() && !entry.Time.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if f.Level != "" && logLevelRank(entry.Level) < logLevelRank(f.Level) {
+		return false
+	}
+	if f.Component != "" {
+		service, _ := logField(entry.Fields, "service")
+		component, _ := logField(entry.Fields, "component")
+		if !strings.EqualFold(service, f.Component) && !strings.EqualFold(component, f.Component) {
+			return false
+		}
+	}
+	if f.WorkflowID != "" {
+		workflowID, _ := logField(entry.Fields, "wf-id", "workflow-id", "workflowId", "WorkflowID", "workflow_id")
+		if workflowID != f.WorkflowID {
+			return false
+		}
+	}
+	if f.Grep != nil && !f.Grep.MatchString(entry.Raw) {
+		return false
+	}
+	return true
+}
+
+// parseSince parses --since, which is a duration such as 10m or a timestamp
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("--since %q is not a duration like 10m or a time like 2024-05-01T10:00:00", value)
+}
+
+const colorReset = "\033[0m"
+
+// logSourceColors are the colors of the source prefixes, used in turn
+var logSourceColors = []string{"\033[36m", "\033[33m", "\033[32m", "\033[35m", "\033[34m", "\033[96m", "\033[93m", "\033[92m", "\033[95m", "\033[94m"}
+
+// logLevelColors are the colors of the levels of structured entries
+var logLevelColors = map[string]string{
+	"debug": "\033[90m",
+	"info":  "\033[32m",
+	"warn":  "\033[33m",
+	"error": "\033[31m",
+}
+
+// logHiddenFields are the fields of structured entries not repeated after the message
+var logHiddenFields = []string{"level", "lvl", "severity", "ts", "time", "timestamp", "@timestamp", "msg", "message", "logging-call-at"}
+
+// useColor reports whether output to stdout can be colored
+func useColor() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// logPrinter writes log entries, prefixing them with their source when
+// several sources are shown. It is safe for concurrent use.
+type logPrinter struct {
+	out    io.Writer
+	color  bool
+	json   bool
+	prefix bool
+	width  int
+	colors map[string]string
+
+	mu sync.Mutex
+}
+
+// newLogPrinter returns a printer for the named sources
+func newLogPrinter(out io.Writer, sources []string, color, jsonOutput bool) *logPrinter {
+	printer := &logPrinter{out: out, color: color, json: jsonOutput, prefix: len(sources) > 1, colors: map[string]string{}}
+	for i, source := range sources {
+		printer.width = max(printer.width, len(source))
+		printer.colors[source] = logSourceColors[i%len(logSourceColors)]
+	}
+	return printer
+}
+
+// paint colors text when color is enabled
+func (p *logPrinter) paint(color, text string) string {
+	if !p.color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// format renders an entry as a line without the trailing newline
+func (p *logPrinter) format(entry logEntry) string {
+	if p.json {
+		fields := map[string]interface{}{}
+		if entry.Fields != nil {
+			for key, value := range entry.Fields {
+				fields[key] = value
+			}
+		} else {
+			fields["msg"] = entry.Raw
+			if entry.Level != "" {
+				fields["level"] = entry.Level
+			}
+			if !entry.Time.IsZero() {
+				fields["ts"] = entry.Time.Format(time.RFC3339Nano)
+			}
+		}
+		fields["source"] = entry.Source
+		data, _ := json.Marshal(fields)
+		return string(data)
+	}
+
+	line := entry.Raw
+	if entry.Fields != nil {
+		parts := []string{}
+		if !entry.Time.IsZero() {
+			parts = append(parts, entry.Time.Local().Format("2006-01-02 15:04:05.000"))
+		}
+		level := strings.ToUpper(entry.Level)
+		if level == "" {
+			level = "-"
+		}
+		parts = append(parts, p.paint(logLevelColors[entry.Level], fmt.Sprintf("%-5s", level)), entry.Message)
+
+		keys := []string{}
+		for key := range entry.Fields {
+			if !slices.Contains(logHiddenFields, key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			parts = append(parts, key+"="+formatLogValue(entry.Fields[key]))
+		}
+		line = strings.Join(parts, " ")
+	}
+
+	if !p.prefix {
+		return line
+	}
+	return p.paint(p.colors[entry.Source], fmt.Sprintf("%-*s |", p.width, entry.Source)) + " " + line
+}
+
+// formatLogValue renders a structured field value, quoting strings with spaces
+func formatLogValue(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		if strings.ContainsAny(value, " \t\"=") {
+			return fmt.Sprintf("%q", value)
+		}
+		return value
+	case json.Number:
+		return value.String()
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// print writes an entry
+func (p *logPrinter) print(entry logEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.out, p.format(entry))
+}
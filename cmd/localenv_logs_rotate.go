@@ -0,0 +1,393 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Global configuration keys of log rotation
+const (
+	logMaxSizeKey    = "logs.maxSize"
+	logMaxAgeKey     = "logs.maxAge"
+	logMaxBackupsKey = "logs.maxBackups"
+	logCompressKey   = "logs.compress"
+)
+
+// logRotation configures when log files are rotated and how many rotated
+// segments are kept
+type logRotation struct {
+	// MaxSize rotates a log once it would grow beyond this many bytes, 0 disables it
+	MaxSize int64
+	// MaxAge rotates a log once its segment is older than this, 0 disables it
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated segments kept
+	MaxBackups int
+	// Compress gzips rotated segments
+	Compress bool
+}
+
+// globalLogRotation returns the log rotation settings of the global configuration
+func globalLogRotation() logRotation {
+	rotation := logRotation{
+		MaxSize:    viper.GetInt64(logMaxSizeKey) * 1024 * 1024,
+		MaxAge:     viper.GetDuration(logMaxAgeKey),
+		MaxBackups: viper.GetInt(logMaxBackupsKey),
+		Compress:   viper.GetBool(logCompressKey),
+	}
+	rotation.MaxSize = max(rotation.MaxSize, 0)
+	rotation.MaxAge = max(rotation.MaxAge, 0)
+	rotation.MaxBackups = max(rotation.MaxBackups, 0)
+	return rotation
+}
+
+// rotatingWriter appends to a log file, rotating it by size and age
+type rotatingWriter struct {
+	path     string
+	rotation logRotation
+	now      func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+// openRotatingWriter opens the log file at path for appending. A log that
+// hasn't been written to for longer than rotation.MaxAge is rotated first.
+func openRotatingWriter(path string, rotation logRotation) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, rotation: rotation, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 &&
+		rotation.MaxAge > 0 && w.now().Sub(info.ModTime()) >= rotation.MaxAge {
+		if err := rotateLogFile(path, rotation); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the current segment
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	w.started = w.now()
+	return nil
+}
+
+// due reports whether writing n more bytes requires a new segment
+func (w *rotatingWriter) due(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.rotation.MaxSize > 0 && w.size+int64(n) > w.rotation.MaxSize {
+		return true
+	}
+	return w.rotation.MaxAge > 0 && w.now().Sub(w.started) >= w.rotation.MaxAge
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.due(len(p)) {
+		w.file.Close()
+		w.file = nil
+		if err := rotateLogFile(w.path, w.rotation); err != nil {
+			return 0, err
+		}
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotatedSegment returns the path of the n-th rotated segment of a log
+func rotatedSegment(path string, n int, compressed bool) string {
+	segment := path + "." + strconv.Itoa(n)
+	if compressed {
+		segment += ".gz"
+	}
+	return segment
+}
+
+// rotatedLogFiles returns the rotated segments of a log, oldest first
+func rotatedLogFiles(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+	generations := map[int]string{}
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(match, path+"."), ".gz")
+		if n, err := strconv.Atoi(suffix); err == nil && n > 0 {
+			generations[n] = match
+		}
+	}
+
+	numbers := []int{}
+	for n := range generations {
+		numbers = append(numbers, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+
+	segments := []string{}
+	for _, n := range numbers {
+		segments = append(segments, generations[n])
+	}
+	return segments
+}
+
+// rotateLogFile moves a log to its first rotated segment, shifting older
+// segments and removing those beyond rotation.MaxBackups
+func rotateLogFile(path string, rotation logRotation) error {
+	for _, segment := range rotatedLogFiles(path) {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(segment, path+"."), ".gz")
+		n, _ := strconv.Atoi(suffix)
+		if n >= rotation.MaxBackups {
+			if err := os.Remove(segment); err != nil {
+				return fmt.Errorf("failed to remove old log segment: %w", err)
+			}
+			continue
+		}
+		// Oldest first, so the next generation is already free
+		compressed := strings.HasSuffix(segment, ".gz")
+		if err := os.Rename(segment, rotatedSegment(path, n+1, compressed)); err != nil {
+			return fmt.Errorf("failed to rotate log segment: %w", err)
+		}
+	}
+
+	if rotation.MaxBackups == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+		return nil
+	}
+	first := rotatedSegment(path, 1, false)
+	if err := os.Rename(path, first); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if rotation.Compress {
+		return compressLogFile(first)
+	}
+	return nil
+}
+
+// compressLogFile replaces a log segment with its gzipped copy
+func compressLogFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to compress log segment: %w", err)
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to compress log segment: %w", err)
+	}
+	writer := gzip.NewWriter(target)
+	_, err = io.Copy(writer, source)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return fmt.Errorf("failed to compress log segment: %w", err)
+	}
+	return os.Remove(path)
+}
+
+// openLogSegment opens a log file or rotated segment for reading
+func openLogSegment(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+// removeLogFile removes a log together with its rotated segments
+func removeLogFile(path string) error {
+	err := os.Remove(path)
+	for _, segment := range rotatedLogFiles(path) {
+		os.Remove(segment)
+	}
+	return err
+}
+
+// openComponentLog returns a file detached components write their output to.
+// It is the write end of a pipe read by a `localenv log-writer` process, which
+// rotates the log at path and exits when the component closes its output.
+// If that process can't be started, the log is opened directly and only
+// rotated when it is stale.
+func openComponentLog(path string) (*os.File, error) {
+	rotation := globalLogRotation()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	file, err := startLogWriter(path, rotation)
+	if err == nil {
+		return file, nil
+	}
+	fmt.Printf("⚠️ Warning: Log rotation is not available for %s: %v\n", filepath.Base(path), err)
+
+	writer, err := openRotatingWriter(path, rotation)
+	if err != nil {
+		return nil, err
+	}
+	return writer.file, nil
+}
+
+// startLogWriter starts a detached `localenv log-writer` process appending
+// to path and returns the write end of its input
+func startLogWriter(path string, rotation logRotation) (*os.File, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	args := []string{"localenv", "log-writer", path,
+		"--max-size", strconv.FormatInt(rotation.MaxSize, 10),
+		"--max-age", rotation.MaxAge.String(),
+		"--max-backups", strconv.Itoa(rotation.MaxBackups),
+		"--compress=" + strconv.FormatBool(rotation.Compress),
+	}
+	writerCmd := exec.Command(executable, args...)
+	writerCmd.Stdin = reader
+	// Keep writing when the terminal that started the component goes away
+	detachProcess(writerCmd)
+	if err := writerCmd.Start(); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	go writerCmd.Wait()
+	return writer, nil
+}
+
+// logWriterCmd copies its input to a rotated log file. It is started by the
+// components that run in the background and is not meant to be used directly.
+var logWriterCmd = &cobra.Command{
+	Use:    "log-writer <file>",
+	Short:  "Write standard input to a rotated log file",
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		maxSize, _ := cmd.Flags().GetInt64("max-size")
+		maxAge, _ := cmd.Flags().GetDuration("max-age")
+		maxBackups, _ := cmd.Flags().GetInt("max-backups")
+		compress, _ := cmd.Flags().GetBool("compress")
+
+		// The component decides when to stop by closing its output
+		signal.Ignore(os.Interrupt, syscall.SIGHUP)
+
+		rotation := logRotation{MaxSize: maxSize, MaxAge: maxAge, MaxBackups: maxBackups, Compress: compress}
+		writer, err := openRotatingWriter(args[0], rotation)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			io.Copy(io.Discard, os.Stdin)
+			os.Exit(1)
+		}
+		defer writer.Close()
+
+		if _, err := io.Copy(writer, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			// Drain the input so the component isn't blocked or killed by SIGPIPE
+			io.Copy(io.Discard, os.Stdin)
+		}
+	},
+}
+
+func init() {
+	localenvCmd.AddCommand(logWriterCmd)
+
+	logWriterCmd.Flags().Int64("max-size", 0, "Rotate once the log would grow beyond this many bytes")
+	logWriterCmd.Flags().Duration("max-age", 0, "Rotate once the current segment is older than this")
+	logWriterCmd.Flags().Int("max-backups", 0, "Number of rotated segments to keep")
+	logWriterCmd.Flags().Bool("compress", false, "Gzip rotated segments")
+
+	viper.SetDefault(logMaxSizeKey, 100)
+	viper.SetDefault(logMaxAgeKey, "168h")
+	viper.SetDefault(logMaxBackupsKey, 5)
+	viper.SetDefault(logCompressKey, true)
+}
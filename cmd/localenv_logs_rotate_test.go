@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLogRotation tests rotating, retaining and reading log segments
+func TestLogRotation(t *testing.T) {
+	readSegment := func(path string) string {
+		reader, err := openLogSegment(path)
+		if err != nil {
+			return err.Error()
+		}
+		defer reader.Close()
+		data, _ := io.ReadAll(reader)
+		return string(data)
+	}
+
+	t.Run("Logs should rotate by size and keep N compressed generations", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "temporal-server.log")
+		writer, err := openRotatingWriter(path, logRotation{MaxSize: 10, MaxBackups: 2, Compress: true})
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+			_, err := writer.Write([]byte(line))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, writer.Close())
+
+		assert.Equal(t, []string{path + ".2.gz", path + ".1.gz"}, rotatedLogFiles(path))
+		assert.Equal(t, "second\n", readSegment(path+".2.gz"))
+		assert.Equal(t, "third\n", readSegment(path+".1.gz"))
+		assert.Equal(t, "fourth\n", readSegment(path))
+
+		var out bytes.Buffer
+		assert.NoError(t, copyLogSegments(path, &out))
+		assert.Equal(t, "second\nthird\nfourth\n", out.String())
+	})
+
+	t.Run("Logs should rotate by age", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app-orders.log")
+		writer, err := openRotatingWriter(path, logRotation{MaxAge: time.Hour, MaxBackups: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+		now := time.Now()
+		writer.now = func() time.Time { return now }
+		writer.started = now
+
+		writer.Write([]byte("old\n"))
+		now = now.Add(2 * time.Hour)
+		writer.Write([]byte("new\n"))
+		writer.Close()
+
+		assert.Equal(t, []string{path + ".1"}, rotatedLogFiles(path))
+		assert.Equal(t, "old\n", readSegment(path+".1"))
+		assert.Equal(t, "new\n", readSegment(path))
+	})
+
+	t.Run("Stale logs should be rotated when opened", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dapr-dashboard.log")
+		os.WriteFile(path, []byte("yesterday\n"), 0644)
+		old := time.Now().Add(-48 * time.Hour)
+		os.Chtimes(path, old, old)
+
+		writer, err := openRotatingWriter(path, logRotation{MaxAge: 24 * time.Hour, MaxBackups: 3})
+		if !assert.NoError(t, err) {
+			return
+		}
+		writer.Write([]byte("today\n"))
+		writer.Close()
+
+		assert.Equal(t, "yesterday\n", readSegment(path+".1"))
+		assert.Equal(t, "today\n", readSegment(path))
+	})
+
+	t.Run("Lowering maxBackups should remove older generations", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "temporal-server.log")
+		for _, name := range []string{path, path + ".1.gz", path + ".2", path + ".3.gz"} {
+			os.WriteFile(name, []byte(filepath.Base(name)), 0644)
+		}
+
+		assert.NoError(t, rotateLogFile(path, logRotation{MaxBackups: 2}))
+		assert.Equal(t, []string{path + ".2.gz", path + ".1"}, rotatedLogFiles(path))
+		assert.NoFileExists(t, path)
+
+		assert.Error(t, removeLogFile(path+"-missing"))
+		os.WriteFile(path, []byte("current"), 0644)
+		assert.NoError(t, removeLogFile(path))
+		assert.Empty(t, rotatedLogFiles(path))
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("Retention should come from the global configuration", func(t *testing.T) {
+		rotation := globalLogRotation()
+		assert.Equal(t, int64(100*1024*1024), rotation.MaxSize)
+		assert.Equal(t, 168*time.Hour, rotation.MaxAge)
+		assert.Equal(t, 5, rotation.MaxBackups)
+		assert.True(t, rotation.Compress)
+
+		t.Setenv("DEVHELPER_LOGS_MAXBACKUPS", "2")
+		viper.SetEnvPrefix("DEVHELPER")
+		viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		viper.AutomaticEnv()
+		assert.Equal(t, 2, globalLogRotation().MaxBackups)
+
+		value, err := lookupConfigValue(logMaxBackupsKey, "localenv.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, sourceEnv, value.Source)
+	})
+
+	t.Run("The logs command should accept --previous", func(t *testing.T) {
+		assert.NotNil(t, logsCmd.Flags().Lookup("previous"))
+	})
+}
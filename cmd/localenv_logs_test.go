@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -14,13 +19,13 @@ func TestLogsCommand(t *testing.T) {
 	t.Run("Logs command structure should be valid", func(t *testing.T) {
 		// Make sure logsCmd exists and has the right properties
 		assert.NotNil(t, logsCmd, "logs command should exist")
-		assert.Equal(t, "logs [component]", logsCmd.Use, "Command use should be 'logs [component]'")
+		assert.Equal(t, "logs [component...]", logsCmd.Use, "Command use should be 'logs [component...]'")
 		assert.Contains(t, logsCmd.Short, "View logs", "Command should mention viewing logs")
 
 		// Check that it's registered with the parent command
 		found := false
 		for _, cmd := range localenvCmd.Commands() {
-			if cmd.Use == "logs [component]" {
+			if cmd.Use == "logs [component...]" {
 				found = true
 				break
 			}
@@ -68,6 +73,117 @@ func TestLogsCommand(t *testing.T) {
 	})
 }
 
+// TestLogAggregation tests parsing, filtering and interleaving component logs
+func TestLogAggregation(t *testing.T) {
+	const temporalLog = `{"level":"info","ts":"2024-05-01T10:00:01.000Z","msg":"Service started","service":"frontend","logging-call-at":"service.go:42"}
+{"level":"debug","ts":"2024-05-01T10:00:02.000Z","msg":"Poll","service":"matching"}
+{"level":"error","ts":"2024-05-01T10:00:04.000Z","msg":"Workflow task failed","service":"history","wf-id":"order-42","error":"boom now"}
+panic: not json
+`
+
+	t.Run("Structured lines should keep their fields", func(t *testing.T) {
+		entry := parseLogLine("temporal", `{"level":"warn","ts":1714557600.5,"msg":"Slow","service":"history"}`)
+		assert.Equal(t, "warn", entry.Level)
+		assert.Equal(t, "Slow", entry.Message)
+		assert.Equal(t, time.Unix(1714557600, 500000000), entry.Time)
+		assert.Equal(t, "history", entry.Fields["service"])
+
+		entry = parseLogLine("dapr_placement", `time="2024-05-01T10:00:03.5Z" level=warning msg="Leader lost"`)
+		assert.Equal(t, "warn", entry.Level)
+		assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 3, 500000000, time.UTC), entry.Time)
+
+		entry = parseLogLine("opensearch-node", `[2024-05-01T10:00:00,123][INFO ][o.o.n.Node] started`)
+		assert.Equal(t, "info", entry.Level)
+		assert.Nil(t, entry.Fields)
+	})
+
+	t.Run("Continuation lines should inherit the previous entry", func(t *testing.T) {
+		parser := &logSourceParser{source: "opensearch-node", container: true}
+		parser.parse("2024-05-01T10:00:05.000000000Z [2024-05-01T10:00:05,000][ERROR][o.o.b.Bootstrap] failed")
+		entry := parser.parse("2024-05-01T10:00:05.000000001Z \tat org.opensearch.Bootstrap.init")
+		assert.Equal(t, "error", entry.Level)
+		assert.Equal(t, "\tat org.opensearch.Bootstrap.init", entry.Raw)
+	})
+
+	t.Run("Filters should select entries", func(t *testing.T) {
+		_, err := newLogFilter("", "", "verbose", "", "")
+		assert.EqualError(t, err, `--level "verbose" is not one of debug, info, warn, error`)
+		_, err = newLogFilter("yesterday", "", "", "", "")
+		assert.Error(t, err)
+
+		filter, err := newLogFilter("", "fail", "warn", "History", "order-42")
+		assert.NoError(t, err)
+		entries := []logEntry{}
+		for _, line := range strings.Split(strings.TrimSpace(temporalLog), "\n") {
+			if entry := parseLogLine("temporal", line); filter.match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "Workflow task failed", entries[0].Message)
+		}
+
+		since, err := parseSince("10m", time.Date(2024, 5, 1, 10, 10, 3, 0, time.UTC))
+		assert.NoError(t, err)
+		filter = logFilter{Since: since}
+		assert.False(t, filter.match(parseLogLine("temporal", strings.Split(temporalLog, "\n")[1])))
+		assert.True(t, filter.match(parseLogLine("temporal", strings.Split(temporalLog, "\n")[2])))
+	})
+
+	t.Run("Structured entries should be pretty-printed", func(t *testing.T) {
+		var out bytes.Buffer
+		printer := newLogPrinter(&out, []string{"temporal"}, false, false)
+		entry := parseLogLine("temporal", strings.Split(temporalLog, "\n")[2])
+		entry.Time = time.Time{}
+		printer.print(entry)
+		printer.print(parseLogLine("temporal", "panic: not json"))
+		assert.Equal(t, "ERROR Workflow task failed error=\"boom now\" service=history wf-id=order-42\npanic: not json\n", out.String())
+
+		out.Reset()
+		printer = newLogPrinter(&out, []string{"temporal"}, false, true)
+		printer.print(parseLogLine("temporal", strings.Split(temporalLog, "\n")[1]))
+		assert.Equal(t, `{"level":"debug","msg":"Poll","service":"matching","source":"temporal","ts":"2024-05-01T10:00:02.000Z"}`+"\n", out.String())
+	})
+
+	t.Run("Sources should be interleaved by time", func(t *testing.T) {
+		origRuntime := containerRuntime
+		defer func() { containerRuntime = origRuntime }()
+		containerRuntime = &timestampedRuntime{lines: map[string]string{
+			"dapr_redis": "2024-05-01T10:00:03.000000000Z level=info msg=\"Ready to accept connections\"\n",
+		}}
+
+		logFile := filepath.Join(t.TempDir(), "temporal-server.log")
+		os.WriteFile(logFile, []byte(temporalLog), 0644)
+		sources := []LogSource{{Name: "temporal", File: logFile}, {Name: "dapr_redis", Container: "dapr_redis"}}
+
+		var out bytes.Buffer
+		printer := newLogPrinter(&out, logSourceNames(sources), false, false)
+		err := showLogs(sources, LogOptions{Lines: 50, Timestamps: true}, logFilter{Level: "info"}, printer)
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if !assert.Len(t, lines, 4) {
+			return
+		}
+		assert.Contains(t, lines[0], "temporal   | ")
+		assert.Contains(t, lines[0], "Service started")
+		assert.Equal(t, `dapr_redis | level=info msg="Ready to accept connections"`, lines[1])
+		assert.Contains(t, lines[2], "Workflow task failed")
+		assert.Equal(t, "temporal   | panic: not json", lines[3], "unparsed lines should follow the entry before them")
+	})
+}
+
+// timestampedRuntime is a container runtime returning canned logs
+type timestampedRuntime struct {
+	fakeRuntime
+	lines map[string]string
+}
+
+func (r *timestampedRuntime) Logs(_ context.Context, container string, opts LogOptions, stdout, stderr io.Writer) error {
+	_, err := io.WriteString(stdout, r.lines[container])
+	return err
+}
+
 // More detailed functional tests would need to mock the fs operations
 // and command execution, which is complex for a CLI application.
 // Consider adding integration tests that actually run commands.
@@ -0,0 +1,96 @@
+//go:build !windows
+
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// detachProcess starts the command in its own process group, so the whole
+// group can be stopped later and Ctrl+C in the terminal doesn't reach it
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processGroupID returns the process group of pid, or 0 if it can't be determined
+func processGroupID(pid int) int {
+	pgid, err := syscall.Getpgid(pid)
+	if err != nil {
+		return 0
+	}
+	return pgid
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess sends SIGTERM to the process group (or the process if
+// pgid is 0) and waits for the process to exit. With force, processes that
+// are still running after the grace period are killed with SIGKILL. When
+// started is set, nothing is signalled once pid no longer belongs to the
+// process that started then.
+func terminateProcess(ctx context.Context, pid, pgid int, started string, force bool) error {
+	target := pid
+	if pgid > 0 {
+		target = -pgid
+	}
+
+	if started != "" && !sameProcess(pid, started) {
+		return nil
+	}
+	if err := syscall.Kill(target, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	if waitForExit(ctx, pid, 5*time.Second) {
+		return nil
+	}
+
+	if !force {
+		return errors.New("process did not exit after SIGTERM (use --force to kill it)")
+	}
+	if started != "" && !sameProcess(pid, started) {
+		return nil
+	}
+	if err := syscall.Kill(target, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	if waitForExit(ctx, pid, 2*time.Second) {
+		return nil
+	}
+	return errors.New("process did not exit after SIGKILL")
+}
+
+// waitForExit polls until the process is gone, timeout elapses or ctx is done
+func waitForExit(ctx context.Context, pid int, timeout time.Duration) bool {
+	return waitFor(ctx, timeout, 100*time.Millisecond, func() error {
+		if processAlive(pid) {
+			return errors.New("process is still running")
+		}
+		return nil
+	}) == nil
+}
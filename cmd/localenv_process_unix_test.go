@@ -0,0 +1,74 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcessGroups tests stopping processes recorded in the state file
+func TestProcessGroups(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("Tracked process groups should be terminated", func(t *testing.T) {
+		sleepCmd := exec.Command("sleep", "30")
+		detachProcess(sleepCmd)
+		if err := sleepCmd.Start(); err != nil {
+			t.Skipf("sleep is not available: %v", err)
+		}
+		// Reap the process so it doesn't linger as a zombie once killed
+		go sleepCmd.Wait()
+
+		pid := sleepCmd.Process.Pid
+		assert.Equal(t, pid, processGroupID(pid), "process should lead its own group")
+		assert.True(t, processAlive(pid))
+
+		env := &LocalEnv{State: loadLocalEnvState()}
+		assert.NoError(t, env.State.recordProcess("Temporal", pid, processGroupID(pid)))
+		assert.Equal(t, []int{pid}, env.State.runningPIDs("Temporal"))
+
+		assert.NoError(t, stopTrackedComponent(env, "Temporal"))
+		assert.False(t, processAlive(pid), "process should be stopped")
+
+		_, ok := loadLocalEnvState().component("Temporal")
+		assert.False(t, ok, "stopped component should be removed from the state")
+	})
+
+	t.Run("Reused PIDs should not be signalled", func(t *testing.T) {
+		sleepCmd := exec.Command("sleep", "30")
+		detachProcess(sleepCmd)
+		if err := sleepCmd.Start(); err != nil {
+			t.Skipf("sleep is not available: %v", err)
+		}
+		defer sleepCmd.Process.Kill()
+		go sleepCmd.Wait()
+		pid := sleepCmd.Process.Pid
+		if _, err := prober.ProcessStart(pid); err != nil {
+			t.Skipf("start times are not available: %v", err)
+		}
+
+		// The recorded process started at another time, as after a reboot
+		env := &LocalEnv{State: loadLocalEnvState(), Force: true}
+		assert.NoError(t, env.State.recordProcess("Temporal", pid, pid))
+		env.State.Components["temporal"].Starts[pid] = "an earlier start"
+
+		assert.Empty(t, env.State.runningPIDs("Temporal"), "another process should not be reported as the component")
+		assert.ErrorIs(t, stopTrackedComponent(env, "Temporal"), errComponentNotRunning)
+		assert.True(t, processAlive(pid), "another process should not be stopped")
+		assert.NoError(t, terminateProcess(context.Background(), pid, pid, "an earlier start", true))
+		assert.True(t, processAlive(pid), "another process should not be killed")
+
+		_, ok := loadLocalEnvState().component("Temporal")
+		assert.False(t, ok, "the stale entry should be dropped")
+	})
+
+	t.Run("Dead processes should be reported as not running", func(t *testing.T) {
+		env := &LocalEnv{State: loadLocalEnvState()}
+		assert.NoError(t, env.State.recordProcess("Temporal", 999999, 0))
+		assert.ErrorIs(t, stopTrackedComponent(env, "Temporal"), errComponentNotRunning)
+	})
+}
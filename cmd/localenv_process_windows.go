@@ -0,0 +1,64 @@
+//go:build windows
+
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts the command in a new process group, so Ctrl+C in the
+// terminal doesn't reach it
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processGroupID is not tracked on Windows
+func processGroupID(pid int) int {
+	return 0
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
+
+// terminateProcess kills the process. Windows has no graceful equivalent
+// of SIGTERM for console processes, so force is implied. When started is
+// set, nothing is killed once pid no longer belongs to the process that
+// started then.
+func terminateProcess(_ context.Context, pid, pgid int, started string, force bool) error {
+	if started != "" && !sameProcess(pid, started) {
+		return nil
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return process.Kill()
+}
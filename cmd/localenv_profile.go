@@ -0,0 +1,162 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// defaultProfile names the top-level configuration in messages
+const defaultProfile = "default"
+
+// localenvProfile is the profile selected with --profile. Empty selects the
+// top-level configuration in localenv.yaml.
+var localenvProfile string
+
+// Profile names end up in container names and paths
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// validateProfileName checks that a profile name is safe to use in
+// container names and file paths
+func validateProfileName(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == defaultProfile || !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits and dashes, and not %q", name, defaultProfile)
+	}
+	return nil
+}
+
+// applyProfile overlays the named profile onto the top-level configuration.
+// Settings the profile doesn't mention keep their top-level values.
+func applyProfile(config *LocalEnvConfig, profile string) error {
+	if profile == "" {
+		return nil
+	}
+
+	overlay, ok := config.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined in the configuration (available: %v)", profile, profileNames(*config))
+	}
+	// Map values aren't addressable, so decoding replaces the custom components,
+	// Dapr components and apps the profile mentions instead of merging them.
+	// Merge them explicitly.
+	custom := maps.Clone(config.Components.Custom)
+	dapr := maps.Clone(config.Components.Dapr.Components)
+	apps := maps.Clone(config.Apps)
+
+	if err := overlay.Decode(config); err != nil {
+		return fmt.Errorf("failed to parse profile %q: %w", profile, err)
+	}
+
+	err := mergeProfileEntries(&overlay, "components.custom", custom, config.Components.Custom)
+	if err == nil {
+		err = mergeProfileEntries(&overlay, "components.dapr.components", dapr, config.Components.Dapr.Components)
+	}
+	if err == nil {
+		err = mergeProfileEntries(&overlay, "apps", apps, config.Apps)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse profile %q: %w", profile, err)
+	}
+	return nil
+}
+
+// mergeProfileEntries decodes the entries a profile sets under path on top of
+// their base values and stores the result in entries
+func mergeProfileEntries[T any](overlay *yamlv3.Node, path string, base, entries map[string]T) error {
+	node := findConfigNode(overlay, path)
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		merged, ok := base[name]
+		if !ok {
+			continue
+		}
+		if err := node.Content[i+1].Decode(&merged); err != nil {
+			return err
+		}
+		entries[name] = merged
+	}
+	return nil
+}
+
+// profileNames returns the sorted names of the profiles in config
+func profileNames(config LocalEnvConfig) []string {
+	names := []string{}
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileLabel returns the active profile name for messages
+func profileLabel() string {
+	if localenvProfile == "" {
+		return defaultProfile
+	}
+	return localenvProfile
+}
+
+// profileResourceName suffixes a container or network name with the active
+// profile, so several profiles can run side by side
+func profileResourceName(name string) string {
+	if localenvProfile == "" {
+		return name
+	}
+	return name + "-" + localenvProfile
+}
+
+// baseConfigDir returns the devhelper-cli configuration directory
+func baseConfigDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "devhelper-cli")
+}
+
+// configDir returns the directory holding the cache and state files of the
+// active profile
+func configDir() string {
+	return profileDir(baseConfigDir(), localenvProfile)
+}
+
+// baseDataDir returns the devhelper-cli data directory
+func baseDataDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".local", "share", "devhelper-cli")
+}
+
+// dataDir returns the directory holding the persistent data of the active profile
+func dataDir() string {
+	return profileDir(baseDataDir(), localenvProfile)
+}
+
+// profileDir returns the directory of profile under base. The top-level
+// configuration uses base itself, for compatibility with older versions.
+func profileDir(base, profile string) string {
+	if profile == "" {
+		return base
+	}
+	return filepath.Join(base, "profiles", profile)
+}
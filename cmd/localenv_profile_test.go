@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const profileConfig = `components:
+  temporal:
+    enabled: true
+    uiPort: 8233
+  openSearch:
+    enabled: true
+    version: "2.11.0"
+    port: 9200
+    dashboardPort: 5601
+profiles:
+  search-heavy:
+    components:
+      openSearch:
+        version: "2.17.0"
+        port: 9201
+`
+
+// TestProfiles tests selecting a named profile from localenv.yaml
+func TestProfiles(t *testing.T) {
+	defer func() { localenvProfile = "" }()
+	configPath := filepath.Join(t.TempDir(), "localenv.yaml")
+	os.WriteFile(configPath, []byte(profileConfig), 0644)
+
+	t.Run("Profile names should be validated", func(t *testing.T) {
+		assert.NoError(t, validateProfileName(""))
+		assert.NoError(t, validateProfileName("search-heavy"))
+		assert.Error(t, validateProfileName("../escape"))
+		assert.Error(t, validateProfileName("Upper"))
+		assert.Error(t, validateProfileName(defaultProfile))
+	})
+
+	t.Run("Top-level configuration should ignore profiles", func(t *testing.T) {
+		localenvProfile = ""
+		config, loaded, err := loadLocalEnvConfig(configPath)
+		assert.NoError(t, err)
+		assert.True(t, loaded)
+		assert.Equal(t, "2.11.0", config.Components.OpenSearch.Version)
+		assert.Equal(t, []string{"search-heavy"}, profileNames(config))
+	})
+
+	t.Run("Profile should overlay the top-level configuration", func(t *testing.T) {
+		localenvProfile = "search-heavy"
+		config, loaded, err := loadLocalEnvConfig(configPath)
+		assert.NoError(t, err)
+		assert.True(t, loaded)
+		assert.Equal(t, "2.17.0", config.Components.OpenSearch.Version)
+		assert.Equal(t, 9201, config.Components.OpenSearch.Port)
+		assert.Equal(t, 5601, config.Components.OpenSearch.DashboardPort, "unset profile values should be inherited")
+		assert.True(t, config.Components.Temporal.Enabled, "unset profile values should be inherited")
+	})
+
+	t.Run("Unknown profile should fail", func(t *testing.T) {
+		localenvProfile = "missing"
+		_, _, err := loadLocalEnvConfig(configPath)
+		assert.ErrorContains(t, err, "search-heavy", "error should list the available profiles")
+
+		_, _, err = loadLocalEnvConfig(filepath.Join(t.TempDir(), "localenv.yaml"))
+		assert.Error(t, err, "a profile needs a configuration file")
+	})
+
+	t.Run("Resources should be separated per profile", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		localenvProfile = ""
+		assert.Equal(t, "opensearch-node", openSearchContainerName())
+		assert.Equal(t, filepath.Join(home, ".config", "devhelper-cli", "state.yaml"), stateFilePath())
+
+		localenvProfile = "search-heavy"
+		assert.Equal(t, "opensearch-node-search-heavy", openSearchContainerName())
+		assert.Equal(t, "opensearch-dashboard-search-heavy", openSearchDashboardContainerName())
+		assert.Equal(t, "opensearch-network-search-heavy", openSearchNetworkName())
+		assert.Equal(t, filepath.Join(home, ".config", "devhelper-cli", "profiles", "search-heavy", "config-cache.yaml"), configCachePath())
+		assert.Equal(t, filepath.Join(home, ".logs", "devhelper-cli", "profiles", "search-heavy"), logsDir())
+	})
+
+	t.Run("Shared components should be tracked across profiles", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+
+		localenvProfile = ""
+		assert.NoError(t, loadLocalEnvState().recordContainer("Dapr", "abc"))
+		assert.Empty(t, otherProfilesUsing("Dapr"), "the active profile should not be listed")
+
+		localenvProfile = "search-heavy"
+		assert.Equal(t, []string{defaultProfile}, otherProfilesUsing("Dapr"))
+		assert.NoError(t, loadLocalEnvState().recordContainer("Dapr", "abc"))
+
+		localenvProfile = ""
+		assert.Equal(t, []string{"search-heavy"}, otherProfilesUsing("Dapr"))
+		assert.Empty(t, otherProfilesUsing("Temporal"))
+	})
+}
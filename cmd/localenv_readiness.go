@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lirtsman/devhelper-cli/internal/probe"
+)
+
+// Readiness declares how to tell that a started component is ready
+type Readiness struct {
+	// Probe is checked until it passes, or nil when the component is ready
+	// as soon as Start returns
+	Probe probe.Probe
+	// Options sets the deadline and backoff of the checks
+	Options probe.Options
+}
+
+// errExitedDuringStartup is returned when a started process exits before it is ready
+var errExitedDuringStartup = errors.New("exited during startup")
+
+// waitReady waits for the component to pass its readiness probe. Guards,
+// such as processRunning, are checked first to stop waiting early. Failed
+// checks are printed in verbose mode.
+func waitReady(env *LocalEnv, driver ComponentDriver, guards ...probe.Probe) error {
+	readiness := driver.Readiness(env)
+	if readiness.Probe == nil {
+		return nil
+	}
+	opts := readiness.Options
+	if env.Verbose {
+		opts.OnFailure = func(attempt int, err error) {
+			fmt.Printf("%s readiness check %d failed: %v\n", driver.Name(), attempt, err)
+		}
+	}
+	return probe.Wait(env.ctx(), probe.All(append(guards, readiness.Probe)...), opts)
+}
+
+// processRunning returns a probe that fails permanently once the process
+// reaped into exited has exited
+func processRunning(label string, exited <-chan error) probe.Probe {
+	var exitErr error
+	done := false
+	return probe.Func(label+" process", func(context.Context) error {
+		if !done {
+			select {
+			case exitErr = <-exited:
+				done = true
+			default:
+				return nil
+			}
+		}
+		if exitErr != nil {
+			return probe.Permanent(fmt.Errorf("%w: %v", errExitedDuringStartup, exitErr))
+		}
+		return probe.Permanent(errExitedDuringStartup)
+	})
+}
+
+// containerProbe returns a probe that passes once the container is running
+// and healthy, using the selected container runtime
+func containerProbe(name string) probe.Container {
+	return probe.Container{Name: name, Inspect: func(ctx context.Context, name string) (probe.ContainerState, error) {
+		container, err := containerRuntime.Inspect(ctx, name)
+		return probe.ContainerState{
+			State:    container.State,
+			Running:  container.Running,
+			Health:   container.Health,
+			ExitCode: container.ExitCode,
+		}, err
+	}}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReadiness tests the readiness probes components declare
+func TestReadiness(t *testing.T) {
+	origRuntime := containerRuntime
+	defer func() { containerRuntime = origRuntime }()
+	fake := newFakeRuntime()
+	containerRuntime = fake
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	driver := customComponentDriver{name: "svc", config: CustomComponentConfig{HealthCheck: CustomHealthCheck{TCP: address, Timeout: 1}}}
+	env := &LocalEnv{Skip: map[string]bool{}}
+
+	t.Run("Custom components should declare their container and health check", func(t *testing.T) {
+		readiness := driver.Readiness(env)
+		assert.Equal(t, "container "+driver.containerName()+" and tcp://"+address, readiness.Probe.String())
+		assert.Equal(t, time.Second, readiness.Options.Timeout)
+
+		noCheck := customComponentDriver{name: "svc"}
+		assert.Equal(t, "container "+noCheck.containerName(), noCheck.Readiness(env).Probe.String())
+		assert.Equal(t, defaultCustomHealthTimeout*time.Second, noCheck.Readiness(env).Options.Timeout)
+	})
+
+	t.Run("Waiting should stop when the process exits", func(t *testing.T) {
+		fake.Run(context.Background(), ContainerSpec{Name: driver.containerName()})
+		exited := make(chan error, 1)
+		exited <- errors.New("exit status 1")
+
+		start := time.Now()
+		err := waitReady(env, driver, processRunning("svc", exited))
+		assert.ErrorIs(t, err, errExitedDuringStartup)
+		assert.ErrorContains(t, err, "exit status 1")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Waiting should stop when start is interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		err := waitReady(&LocalEnv{Context: ctx}, driver)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, waitForContainer(ctx, "missing", "svc"))
+	})
+
+	t.Run("Waiting should fail at the deadline", func(t *testing.T) {
+		assert.ErrorContains(t, waitReady(env, driver), "tcp://"+address)
+	})
+
+	t.Run("Exited containers should fail readiness", func(t *testing.T) {
+		fake.containers[driver.containerName()] = ContainerInfo{Name: driver.containerName(), State: "exited", ExitCode: 3}
+		assert.ErrorContains(t, waitReady(env, driver), "container exited with code 3")
+		assert.False(t, waitForContainer(context.Background(), driver.containerName(), "svc"))
+	})
+
+	t.Run("waitFor should return the last error of the check", func(t *testing.T) {
+		attempts := 0
+		err := waitFor(context.Background(), 50*time.Millisecond, 10*time.Millisecond, func() error {
+			attempts++
+			return errors.New("port 8080 is already in use")
+		})
+		assert.EqualError(t, err, "port 8080 is already in use")
+		assert.Greater(t, attempts, 1)
+	})
+}
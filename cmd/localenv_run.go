@@ -0,0 +1,257 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run [app...]",
+	Short: "Run apps declared in localenv.yaml with Dapr sidecars",
+	Long: `Run the applications declared in the apps section of localenv.yaml
+under 'dapr run'. Without arguments every app is started; apps listed in
+dependsOn are started first.
+
+The components an app depends on must already be running, start them with
+'devhelper-cli localenv start'. App output is written to
+~/.logs/devhelper-cli/app-<name>.log, use 'localenv logs <app>' to view it
+and 'localenv stop' to stop the apps together with the environment.
+
+With --watch the command stays in the foreground and watches the source tree
+of each app. When files change, the app's build command is run and only the
+app and its Dapr sidecar are restarted; components are left untouched.
+Press Ctrl+C to stop watching and stop the apps.
+
+Examples:
+  devhelper-cli localenv run                # Run every app
+  devhelper-cli localenv run orders         # Run the orders app and the apps it depends on
+  devhelper-cli localenv run orders --watch # Restart orders whenever its sources change
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		configPath, _ := cmd.Flags().GetString("config")
+		watch, _ := cmd.Flags().GetBool("watch")
+
+		// If no config path is provided, look for localenv.yaml in current directory
+		if configPath == "" {
+			configPath = "localenv.yaml"
+		}
+
+		config, configLoaded, err := loadLocalEnvConfig(configPath)
+		containerRuntime = selectContainerRuntime(config)
+		if err != nil {
+			printConfigError(configPath, err)
+			os.Exit(1)
+		}
+		if !configLoaded || len(config.Apps) == 0 {
+			fmt.Printf("❌ No apps are declared in %s\n", configPath)
+			fmt.Println("   Add an apps section to run applications with Dapr sidecars.")
+			os.Exit(1)
+		}
+
+		names, err := selectApps(config, args)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if !isCommandAvailable("dapr") {
+			fmt.Println("❌ Required component 'dapr' is not installed or not in PATH.")
+			os.Exit(1)
+		}
+
+		env := &LocalEnv{
+			Config:       config,
+			ConfigPath:   configPath,
+			ConfigLoaded: configLoaded,
+			Verbose:      verbose,
+			State:        loadLocalEnvState(),
+		}
+
+		// Components are managed by start, so run only checks that they are up
+		missing := []string{}
+		for _, dependency := range appComponentDependencies(config, names) {
+			driver, ok := findEnvironmentComponent(config, dependency)
+			if !ok || !driver.Enabled(env) || driver.Health(env) != nil {
+				missing = append(missing, dependency)
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Printf("❌ The apps depend on components that are not running: %s\n", strings.Join(missing, ", "))
+			fmt.Println("   Run 'devhelper-cli localenv start' first.")
+			os.Exit(1)
+		}
+
+		// Only the dependencies between apps order the start
+		apps := []appDriver{}
+		drivers := []ComponentDriver{}
+		for _, name := range names {
+			app := config.Apps[name]
+			deps := []string{}
+			for _, dependency := range app.DependsOn {
+				if _, ok := config.Apps[dependency]; ok {
+					deps = append(deps, dependency)
+				}
+			}
+			driver := appDriver{name: name, config: app, deps: deps}
+			apps = append(apps, driver)
+			drivers = append(drivers, driver)
+		}
+
+		// Apps that are already running are kept, see appDriver.Start
+		for _, driver := range apps {
+			if len(env.State.runningPIDs(driver.Name())) > 0 && driver.Health(env) == nil {
+				continue
+			}
+			if err := driver.build(env); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		results, err := startComponents(env, drivers)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		failed := false
+		for _, driver := range drivers {
+			err := results[driver.Name()]
+			switch {
+			case err == nil:
+			case errors.Is(err, errDependencyFailed):
+				fmt.Printf("❌ %s was not started because %v.\n", driver.Name(), err)
+				failed = true
+			default:
+				failed = true
+			}
+		}
+		if failed {
+			fmt.Println("\nSome apps failed to start. Check their logs with 'devhelper-cli localenv logs <app>'.")
+			os.Exit(1)
+		}
+
+		fmt.Println("\n✅ Apps are running:")
+		for _, name := range names {
+			fmt.Printf("- %s: %s\n", name, appLogFile(name))
+		}
+		if !watch {
+			fmt.Println("\nFollow the output with 'devhelper-cli localenv logs <app> -f' and stop the apps with 'devhelper-cli localenv stop'.")
+			return
+		}
+
+		fmt.Println("\n👀 Watching for changes. Press Ctrl+C to stop...")
+		done := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(done)
+		}()
+
+		err = watchApps(env, apps, func(driver appDriver) { restartApp(env, driver) }, done)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+
+		fmt.Println("\nStopping apps...")
+		for i := len(apps) - 1; i >= 0; i-- {
+			if err := apps[i].Stop(env); err != nil && !errors.Is(err, errComponentNotRunning) {
+				fmt.Printf("❌ Failed to stop %s: %v\n", apps[i].Name(), err)
+			}
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+// selectApps returns the named apps and the apps they depend on, sorted by
+// name. No names selects every app.
+func selectApps(config LocalEnvConfig, names []string) ([]string, error) {
+	if len(names) == 0 {
+		for name := range config.Apps {
+			names = append(names, name)
+		}
+	}
+
+	selected := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+		for _, dependency := range config.Apps[name].DependsOn {
+			if _, ok := config.Apps[dependency]; ok {
+				visit(dependency)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := config.Apps[name]; !ok {
+			available := []string{}
+			for app := range config.Apps {
+				available = append(available, app)
+			}
+			sort.Strings(available)
+			return nil, fmt.Errorf("unknown app %q (available: %s)", name, strings.Join(available, ", "))
+		}
+		visit(name)
+	}
+
+	apps := []string{}
+	for name := range selected {
+		apps = append(apps, name)
+	}
+	sort.Strings(apps)
+	return apps, nil
+}
+
+// appComponentDependencies returns the components the apps depend on, sorted by name
+func appComponentDependencies(config LocalEnvConfig, apps []string) []string {
+	seen := map[string]bool{}
+	components := []string{}
+	for _, name := range apps {
+		for _, dependency := range config.Apps[name].DependsOn {
+			if _, isApp := config.Apps[dependency]; isApp || seen[dependency] {
+				continue
+			}
+			seen[dependency] = true
+			components = append(components, dependency)
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+func init() {
+	localenvCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringP("config", "c", "", "Path to localenv configuration file")
+	runCmd.Flags().BoolP("watch", "w", false, "Restart apps when their sources change")
+}
@@ -0,0 +1,218 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchDebounce is how long watch mode waits for changes to settle before restarting an app
+const defaultWatchDebounce = 500 * time.Millisecond
+
+// defaultWatchIgnore are the patterns ignored in addition to the configured ones
+var defaultWatchIgnore = []string{".git", ".dapr", "node_modules", "*.log", "*.swp", "*~", ".DS_Store"}
+
+// AppWatchConfig configures how `localenv run --watch` detects changes to an app
+type AppWatchConfig struct {
+	// Paths are watched recursively, relative to the app directory (default: the app directory)
+	Paths []string `yaml:"paths,omitempty"`
+	// Ignore holds glob patterns matched against each element and the relative
+	// path of a changed file, such as bin or *_test.go
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Debounce is how long changes must settle before the app restarts (default 500ms)
+	Debounce string `yaml:"debounce,omitempty"`
+}
+
+// debounce returns the configured debounce, or the default
+func (w AppWatchConfig) debounce() time.Duration {
+	if debounce, err := time.ParseDuration(w.Debounce); err == nil && debounce > 0 {
+		return debounce
+	}
+	return defaultWatchDebounce
+}
+
+// watchRoots returns the directories watched for an app
+func (d appDriver) watchRoots(env *LocalEnv) []string {
+	if len(d.config.Watch.Paths) == 0 {
+		return []string{d.dir(env)}
+	}
+	roots := []string{}
+	for _, path := range d.config.Watch.Paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(d.dir(env), path)
+		}
+		roots = append(roots, path)
+	}
+	return roots
+}
+
+// watchIgnored reports whether a path below root matches one of the ignore
+// patterns, either by its relative path or by any of its elements
+func watchIgnored(patterns []string, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		for _, element := range strings.Split(rel, "/") {
+			if matched, _ := filepath.Match(pattern, element); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appWatch is an app in watch mode
+type appWatch struct {
+	driver appDriver
+	roots  []string
+	ignore []string
+	timer  *time.Timer
+}
+
+// owns reports whether a changed path belongs to the app and isn't ignored
+func (w *appWatch) owns(path string) bool {
+	for _, root := range w.roots {
+		if path != root && !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			continue
+		}
+		return !watchIgnored(w.ignore, root, path)
+	}
+	return false
+}
+
+// addWatchDirs adds root and the directories below it that aren't ignored to watcher
+func addWatchDirs(watcher *fsnotify.Watcher, root, dir string, ignore []string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if watchIgnored(ignore, root, path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// watchApps watches the source trees of apps and calls restart for an app
+// once its files stop changing for the debounce period. Restarts run one at
+// a time on the calling goroutine. It returns when done is closed.
+func watchApps(env *LocalEnv, drivers []appDriver, restart func(appDriver), done <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	watches := []*appWatch{}
+	for _, driver := range drivers {
+		watch := &appWatch{
+			driver: driver,
+			roots:  driver.watchRoots(env),
+			ignore: append(append([]string{}, defaultWatchIgnore...), driver.config.Watch.Ignore...),
+		}
+		for _, root := range watch.roots {
+			if err := addWatchDirs(watcher, root, root, watch.ignore); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", root, err)
+			}
+		}
+		watches = append(watches, watch)
+	}
+
+	// Timers signal here when an app's changes have settled
+	settled := make(chan *appWatch)
+	for {
+		select {
+		case <-done:
+			for _, watch := range watches {
+				if watch.timer != nil {
+					watch.timer.Stop()
+				}
+			}
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("⚠️ File watcher error: %v\n", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			for _, watch := range watches {
+				if !watch.owns(event.Name) {
+					continue
+				}
+				// New directories aren't watched automatically
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						for _, root := range watch.roots {
+							if strings.HasPrefix(event.Name, root) {
+								addWatchDirs(watcher, root, event.Name, watch.ignore)
+							}
+						}
+					}
+				}
+				if env.Verbose {
+					fmt.Printf("Change detected in %s: %s\n", watch.driver.Name(), event.Name)
+				}
+				if watch.timer != nil {
+					watch.timer.Stop()
+				}
+				watch.timer = time.AfterFunc(watch.driver.config.Watch.debounce(), func() {
+					select {
+					case settled <- watch:
+					case <-done:
+					}
+				})
+			}
+		case watch := <-settled:
+			restart(watch.driver)
+		}
+	}
+}
+
+// restartApp rebuilds an app and restarts it with its sidecar. When the build
+// fails the running app is kept.
+func restartApp(env *LocalEnv, driver appDriver) {
+	fmt.Printf("\n🔄 Changes detected in %s, restarting...\n", driver.Name())
+	if err := driver.build(env); err != nil {
+		fmt.Printf("❌ %v. Keeping the running version, fix the error and save again.\n", err)
+		return
+	}
+	if err := stopTrackedComponent(env, driver.Name()); err != nil && !errors.Is(err, errComponentNotRunning) {
+		fmt.Printf("❌ Failed to stop %s: %v\n", driver.Name(), err)
+		return
+	}
+	if err := driver.Start(env); err != nil {
+		fmt.Printf("❌ %s failed to restart: %v. Watching for further changes...\n", driver.Name(), err)
+		return
+	}
+	fmt.Printf("👀 Watching %s for changes...\n", driver.Name())
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWatchApps tests restarting apps when their sources change
+func TestWatchApps(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "orders", "internal"), 0755)
+	os.MkdirAll(filepath.Join(dir, "orders", "bin"), 0755)
+	os.MkdirAll(filepath.Join(dir, "payments"), 0755)
+
+	env := &LocalEnv{ConfigPath: filepath.Join(dir, "localenv.yaml")}
+	orders := appDriver{name: "orders", config: AppConfig{
+		Dir:   "orders",
+		Watch: AppWatchConfig{Ignore: []string{"bin", "*_test.go"}, Debounce: "100ms"},
+	}}
+	payments := appDriver{name: "payments", config: AppConfig{Dir: "payments", Watch: AppWatchConfig{Debounce: "100ms"}}}
+
+	t.Run("Ignore patterns should match paths and their elements", func(t *testing.T) {
+		root := filepath.Join(dir, "orders")
+		patterns := append(append([]string{}, defaultWatchIgnore...), "bin", "*_test.go")
+		assert.True(t, watchIgnored(patterns, root, filepath.Join(root, "bin", "orders")))
+		assert.True(t, watchIgnored(patterns, root, filepath.Join(root, "internal", "order_test.go")))
+		assert.True(t, watchIgnored(patterns, root, filepath.Join(root, ".git", "index")))
+		assert.False(t, watchIgnored(patterns, root, filepath.Join(root, "internal", "order.go")))
+		assert.False(t, watchIgnored(patterns, root, root))
+	})
+
+	t.Run("Debounce should default to 500ms", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, orders.config.Watch.debounce())
+		assert.Equal(t, defaultWatchDebounce, AppWatchConfig{}.debounce())
+	})
+
+	t.Run("Changes should restart only the changed app once", func(t *testing.T) {
+		restarted := make(chan string, 10)
+		done := make(chan struct{})
+		finished := make(chan error)
+		go func() {
+			finished <- watchApps(env, []appDriver{orders, payments}, func(driver appDriver) {
+				restarted <- driver.Name()
+			}, done)
+		}()
+		// Give the watcher time to register the directories
+		time.Sleep(200 * time.Millisecond)
+
+		os.WriteFile(filepath.Join(dir, "orders", "bin", "orders"), []byte("binary"), 0644)
+		os.WriteFile(filepath.Join(dir, "orders", "internal", "order_test.go"), []byte("package internal"), 0644)
+		for i := 0; i < 3; i++ {
+			os.WriteFile(filepath.Join(dir, "orders", "internal", "order.go"), []byte("package internal"), 0644)
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		select {
+		case name := <-restarted:
+			assert.Equal(t, "orders", name)
+		case <-time.After(5 * time.Second):
+			t.Error("orders was not restarted")
+		}
+		select {
+		case name := <-restarted:
+			t.Errorf("unexpected restart of %s", name)
+		case <-time.After(400 * time.Millisecond):
+		}
+
+		close(done)
+		assert.NoError(t, <-finished)
+	})
+
+	t.Run("Invalid watch settings should be reported", func(t *testing.T) {
+		data := "apps:\n  orders:\n    command: [./orders]\n    build: []\n    watch:\n      debounce: soon\n      ignore: [\"[bin\"]\n"
+		_, err := parseLocalEnvConfig(env.ConfigPath, []byte(data), "")
+
+		var errs ConfigErrors
+		assert.True(t, errors.As(err, &errs))
+		if !assert.Len(t, errs, 3) {
+			return
+		}
+		assert.Contains(t, errs[0].Message, "apps.orders.build must not be empty")
+		assert.Contains(t, errs[1].Message, `apps.orders.watch.debounce "soon" is not a duration like 500ms`)
+		assert.Contains(t, errs[2].Message, `apps.orders.watch.ignore pattern "[bin" is invalid`)
+	})
+}
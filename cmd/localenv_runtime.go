@@ -0,0 +1,386 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerRuntime runs and inspects the containers of localenv components
+type ContainerRuntime interface {
+	// Name returns the runtime's command, which Dapr also uses as --container-runtime
+	Name() string
+	// Label returns the runtime's name for messages
+	Label() string
+	// Available reports whether the runtime is installed
+	Available() bool
+	// Ping checks that the runtime can run containers
+	Ping(ctx context.Context) error
+
+	// The methods below stop and return ctx's error once ctx is done, e.g.
+	// when start is interrupted during an image pull
+
+	// Run starts a detached container and returns its ID
+	Run(ctx context.Context, spec ContainerSpec) (string, error)
+	// Remove stops and removes a container by name or ID
+	Remove(ctx context.Context, container string) error
+	// List returns the containers whose name contains filter, including stopped ones with all
+	List(ctx context.Context, filter string, all bool) ([]ContainerInfo, error)
+	// Inspect returns a container by name or ID
+	Inspect(ctx context.Context, container string) (ContainerInfo, error)
+	// Logs writes the container's logs to stdout and stderr, all of them when opts.Lines is 0
+	Logs(ctx context.Context, container string, opts LogOptions, stdout, stderr io.Writer) error
+	// Exec runs a command in a running container and returns its combined output
+	Exec(ctx context.Context, container string, command ...string) ([]byte, error)
+	// Wait waits for a container to exit and returns its exit code
+	Wait(ctx context.Context, container string) (int, error)
+
+	// CreateNetwork creates a network unless it already exists
+	CreateNetwork(ctx context.Context, name string) error
+	// NetworkExists reports whether a network exists
+	NetworkExists(ctx context.Context, name string) bool
+	// RemoveNetwork removes a network
+	RemoveNetwork(ctx context.Context, name string) error
+
+	// VolumeExists reports whether a named volume exists
+	VolumeExists(ctx context.Context, name string) bool
+	// RemoveVolume removes a named volume and its data
+	RemoveVolume(ctx context.Context, name string) error
+}
+
+// ContainerSpec describes a container started by ContainerRuntime.Run
+type ContainerSpec struct {
+	Name           string
+	Image          string
+	Command        []string
+	Ports          []string // "hostPort:containerPort"
+	Env            []string // "KEY=value"
+	Volumes        []string // "volume:/path" or "/host/path:/path"
+	Network        string
+	NetworkAliases []string
+	Restart        string
+	HealthCheck    *ContainerHealthCheck
+	Labels         map[string]string
+}
+
+// ContainerHealthCheck is a health check the runtime runs inside the container
+type ContainerHealthCheck struct {
+	Command  string // Shell command that exits with 0 when healthy
+	Interval time.Duration
+	Timeout  time.Duration
+	Retries  int
+}
+
+// ContainerInfo describes a container known to the runtime
+type ContainerInfo struct {
+	ID      string
+	Name    string
+	State   string
+	Running bool
+
+	// Only set by Inspect
+	Health   string // "starting", "healthy" or "unhealthy", empty without a health check
+	ExitCode int    // Exit code of the last run once the container has exited
+	Env      []string
+	Labels   map[string]string
+}
+
+// containerInspect is the part of the inspect output, shared by the CLIs
+// and the API, that localenv uses
+type containerInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Env    []string          `json:"Env"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+func (c containerInspect) info() ContainerInfo {
+	info := ContainerInfo{
+		ID:       c.ID,
+		Name:     strings.TrimPrefix(c.Name, "/"), // Docker prefixes names with a slash
+		State:    c.State.Status,
+		Running:  c.State.Running,
+		ExitCode: c.State.ExitCode,
+		Env:      c.Config.Env,
+		Labels:   c.Config.Labels,
+	}
+	if c.State.Health != nil {
+		info.Health = c.State.Health.Status
+	}
+	return info
+}
+
+// cliRuntime drives a Docker-compatible container CLI
+type cliRuntime struct {
+	command string
+	label   string
+}
+
+var (
+	podmanRuntime = cliRuntime{command: "podman", label: "Podman"}
+	dockerRuntime = cliRuntime{command: "docker", label: "Docker"}
+)
+
+// containerRuntimes are the supported runtimes in auto-detection order
+var containerRuntimes = []cliRuntime{podmanRuntime, dockerRuntime}
+
+// containerRuntime runs the containers of localenv components. Commands set
+// it from the configuration with selectContainerRuntime; it is a variable
+// so tests can replace it.
+var containerRuntime ContainerRuntime = podmanRuntime
+
+// selectContainerRuntime returns the runtime set by tools.containerRuntime,
+// or the first installed one. Podman is used when neither is installed so
+// that error messages point to it as before. The runtime's API socket is
+// used when it answers, and its CLI otherwise.
+func selectContainerRuntime(config LocalEnvConfig) ContainerRuntime {
+	runtime := selectContainerCLI(config)
+	if api, ok := connectRuntimeAPI(runtime); ok {
+		return api
+	}
+	return runtime
+}
+
+// selectContainerCLI returns the runtime chosen by selectContainerRuntime
+// without connecting to its API
+func selectContainerCLI(config LocalEnvConfig) cliRuntime {
+	for _, runtime := range containerRuntimes {
+		if runtime.Name() == config.Tools.ContainerRuntime {
+			return runtime
+		}
+	}
+	for _, runtime := range containerRuntimes {
+		if runtime.Available() {
+			return runtime
+		}
+	}
+	return podmanRuntime
+}
+
+// containerRuntimeNames returns the names accepted by tools.containerRuntime
+func containerRuntimeNames() []string {
+	names := []string{}
+	for _, runtime := range containerRuntimes {
+		names = append(names, runtime.Name())
+	}
+	return names
+}
+
+func (r cliRuntime) Name() string { return r.command }
+
+func (r cliRuntime) Label() string { return r.label }
+
+func (r cliRuntime) Available() bool { return isCommandAvailable(r.command) }
+
+func (r cliRuntime) Ping(ctx context.Context) error {
+	_, err := r.output(ctx, "ps")
+	return err
+}
+
+// output runs the CLI and returns its stdout, adding stderr to the error
+func (r cliRuntime) output(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.command, args...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout, err
+}
+
+// runArgs returns the CLI arguments that start spec
+func (r cliRuntime) runArgs(spec ContainerSpec) []string {
+	args := []string{"run", "-d", "--name", spec.Name}
+	if spec.Network != "" {
+		args = append(args, "--network", spec.Network)
+	}
+	for _, alias := range spec.NetworkAliases {
+		args = append(args, "--network-alias", alias)
+	}
+	if spec.Restart != "" {
+		args = append(args, "--restart", spec.Restart)
+	}
+	for _, port := range spec.Ports {
+		args = append(args, "-p", port)
+	}
+	for _, env := range spec.Env {
+		args = append(args, "-e", env)
+	}
+	for _, volume := range spec.Volumes {
+		args = append(args, "-v", volume)
+	}
+	labels := []string{}
+	for key, value := range spec.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+	if check := spec.HealthCheck; check != nil {
+		args = append(args,
+			"--health-cmd", check.Command,
+			"--health-interval", check.Interval.String(),
+			"--health-timeout", check.Timeout.String(),
+			"--health-retries", strconv.Itoa(check.Retries),
+		)
+	}
+
+	args = append(args, spec.Image)
+	return append(args, spec.Command...)
+}
+
+func (r cliRuntime) Run(ctx context.Context, spec ContainerSpec) (string, error) {
+	stdout, err := r.output(ctx, r.runArgs(spec)...)
+	if err != nil {
+		return "", err
+	}
+
+	// run -d prints the container ID as the last line, after any pull progress
+	fields := strings.Fields(string(stdout))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[len(fields)-1], nil
+}
+
+func (r cliRuntime) Remove(ctx context.Context, container string) error {
+	_, err := r.output(ctx, "rm", "-f", container)
+	return err
+}
+
+func (r cliRuntime) List(ctx context.Context, filter string, all bool) ([]ContainerInfo, error) {
+	args := []string{"ps", "--no-trunc", "--format", "{{.ID}}\t{{.Names}}\t{{.State}}"}
+	if all {
+		args = append(args, "-a")
+	}
+	if filter != "" {
+		args = append(args, "--filter", "name="+filter)
+	}
+
+	output, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := []ContainerInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		containers = append(containers, ContainerInfo{
+			ID:      fields[0],
+			Name:    fields[1],
+			State:   fields[2],
+			Running: fields[2] == "running",
+		})
+	}
+	return containers, nil
+}
+
+func (r cliRuntime) Inspect(ctx context.Context, container string) (ContainerInfo, error) {
+	output, err := r.output(ctx, "inspect", "--type", "container", "--format", "{{json .}}", container)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+
+	var inspected containerInspect
+	if err := json.Unmarshal(output, &inspected); err != nil {
+		return ContainerInfo{}, fmt.Errorf("failed to parse %s inspect output: %w", r.command, err)
+	}
+	return inspected.info(), nil
+}
+
+func (r cliRuntime) Logs(ctx context.Context, container string, opts LogOptions, stdout, stderr io.Writer) error {
+	args := []string{"logs"}
+	if opts.Lines > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Lines))
+	}
+	if opts.Follow {
+		args = append(args, "-f")
+	}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since", opts.Since.Format(time.RFC3339))
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	args = append(args, container)
+
+	logsCmd := exec.CommandContext(ctx, r.command, args...)
+	logsCmd.Stdout = stdout
+	logsCmd.Stderr = stderr
+	return logsCmd.Run()
+}
+
+func (r cliRuntime) Exec(ctx context.Context, container string, command ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, r.command, append([]string{"exec", container}, command...)...).CombinedOutput()
+}
+
+func (r cliRuntime) Wait(ctx context.Context, container string) (int, error) {
+	output, err := r.output(ctx, "wait", container)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+func (r cliRuntime) CreateNetwork(ctx context.Context, name string) error {
+	if r.NetworkExists(ctx, name) {
+		return nil
+	}
+	_, err := r.output(ctx, "network", "create", name)
+	return err
+}
+
+func (r cliRuntime) NetworkExists(ctx context.Context, name string) bool {
+	return exec.CommandContext(ctx, r.command, "network", "inspect", name).Run() == nil
+}
+
+func (r cliRuntime) RemoveNetwork(ctx context.Context, name string) error {
+	_, err := r.output(ctx, "network", "rm", name)
+	return err
+}
+
+func (r cliRuntime) VolumeExists(ctx context.Context, name string) bool {
+	return exec.CommandContext(ctx, r.command, "volume", "inspect", name).Run() == nil
+}
+
+func (r cliRuntime) RemoveVolume(ctx context.Context, name string) error {
+	_, err := r.output(ctx, "volume", "rm", name)
+	return err
+}
@@ -0,0 +1,523 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiRequestTimeout bounds API calls that don't stream
+const apiRequestTimeout = 30 * time.Second
+
+// ContainerEvent is a lifecycle event of a container, such as "start" or "die"
+type ContainerEvent struct {
+	Container string
+	Action    string
+	ExitCode  int // Set for "die"
+}
+
+// containerEventSource is implemented by runtimes that can stream container events
+type containerEventSource interface {
+	// Events streams the events of a container until ctx is done
+	Events(ctx context.Context, container string) (<-chan ContainerEvent, error)
+}
+
+// apiRuntime talks to the Docker-compatible REST API that Docker and Podman
+// serve on a unix socket. Dapr and the messages still use the CLI's name.
+type apiRuntime struct {
+	cliRuntime
+	socket string
+	client *http.Client
+}
+
+// apiError is an error response from the API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (status %d)", e.Message, e.StatusCode)
+}
+
+// isAPINotFound reports whether err is a 404 from the API
+func isAPINotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// socketPaths returns the places the runtime's API socket may be, in order
+func (r cliRuntime) socketPaths() []string {
+	hostVar := "DOCKER_HOST"
+	if r.command == "podman" {
+		hostVar = "CONTAINER_HOST"
+	}
+	if host := os.Getenv(hostVar); host != "" {
+		if socket, ok := strings.CutPrefix(host, "unix://"); ok {
+			return []string{socket}
+		}
+		// Remote hosts are left to the CLI
+		return nil
+	}
+
+	home := os.Getenv("HOME")
+	if r.command == "docker" {
+		return []string{"/var/run/docker.sock", filepath.Join(home, ".docker", "run", "docker.sock")}
+	}
+
+	paths := []string{}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	paths = append(paths, "/run/podman/podman.sock")
+	if runtime.GOOS != "linux" && r.Available() {
+		// The socket forwarded from the Podman machine VM
+		output, err := exec.Command("podman", "machine", "inspect", "--format", "{{.ConnectionInfo.PodmanSocket.Path}}").Output()
+		if socket := strings.TrimSpace(string(output)); err == nil && socket != "" {
+			paths = append(paths, socket)
+		}
+	}
+	return paths
+}
+
+// connectRuntimeAPI returns a client for the runtime's API socket if one answers
+func connectRuntimeAPI(cli cliRuntime) (*apiRuntime, bool) {
+	for _, socket := range cli.socketPaths() {
+		if _, err := os.Stat(socket); err != nil {
+			continue
+		}
+		api := newAPIRuntime(cli, socket)
+		if api.Ping(context.Background()) == nil {
+			return api, true
+		}
+	}
+	return nil, false
+}
+
+// newAPIRuntime returns a client for the API served on socket
+func newAPIRuntime(cli cliRuntime, socket string) *apiRuntime {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &apiRuntime{cliRuntime: cli, socket: socket, client: &http.Client{Transport: transport}}
+}
+
+// Socket returns the path of the API socket
+func (r *apiRuntime) Socket() string { return r.socket }
+
+// The socket answered, so the runtime is there even if its CLI isn't in PATH
+func (r *apiRuntime) Available() bool { return true }
+
+// do sends a request and returns the response, turning error statuses into an apiError
+func (r *apiRuntime) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// The host is ignored as requests are sent over the socket
+	target := "http://localhost" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var message struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &message) != nil || message.Message == "" {
+			message.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &apiError{StatusCode: resp.StatusCode, Message: message.Message}
+	}
+	return resp, nil
+}
+
+// call sends a request that doesn't stream and decodes the response into out, if set
+func (r *apiRuntime) call(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+
+	resp, err := r.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (r *apiRuntime) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	resp, err := r.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// createBody returns the container create request for spec
+func createBody(spec ContainerSpec) map[string]interface{} {
+	exposed := map[string]interface{}{}
+	bindings := map[string][]map[string]string{}
+	for _, mapping := range spec.Ports {
+		hostPort, containerPort, found := strings.Cut(mapping, ":")
+		if !found {
+			containerPort = hostPort
+		}
+		key := containerPort + "/tcp"
+		exposed[key] = struct{}{}
+		bindings[key] = append(bindings[key], map[string]string{"HostPort": hostPort})
+	}
+
+	hostConfig := map[string]interface{}{
+		"PortBindings": bindings,
+		"Binds":        spec.Volumes,
+	}
+	if spec.Network != "" {
+		hostConfig["NetworkMode"] = spec.Network
+	}
+	if spec.Restart != "" {
+		hostConfig["RestartPolicy"] = map[string]string{"Name": spec.Restart}
+	}
+
+	body := map[string]interface{}{
+		"Image":        spec.Image,
+		"Env":          spec.Env,
+		"ExposedPorts": exposed,
+		"HostConfig":   hostConfig,
+	}
+	if len(spec.Command) > 0 {
+		body["Cmd"] = spec.Command
+	}
+	if len(spec.Labels) > 0 {
+		body["Labels"] = spec.Labels
+	}
+	if check := spec.HealthCheck; check != nil {
+		body["Healthcheck"] = map[string]interface{}{
+			"Test":     []string{"CMD-SHELL", check.Command},
+			"Interval": check.Interval.Nanoseconds(),
+			"Timeout":  check.Timeout.Nanoseconds(),
+			"Retries":  check.Retries,
+		}
+	}
+	if spec.Network != "" && len(spec.NetworkAliases) > 0 {
+		body["NetworkingConfig"] = map[string]interface{}{
+			"EndpointsConfig": map[string]interface{}{
+				spec.Network: map[string]interface{}{"Aliases": spec.NetworkAliases},
+			},
+		}
+	}
+	return body
+}
+
+func (r *apiRuntime) Run(ctx context.Context, spec ContainerSpec) (string, error) {
+	query := url.Values{"name": {spec.Name}}
+	var created struct {
+		ID string `json:"Id"`
+	}
+
+	err := r.call(ctx, http.MethodPost, "/containers/create", query, createBody(spec), &created)
+	if isAPINotFound(err) {
+		// Like run, pull a missing image and try again
+		if err := r.pull(ctx, spec.Image); err != nil {
+			return "", err
+		}
+		err = r.call(ctx, http.MethodPost, "/containers/create", query, createBody(spec), &created)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.call(ctx, http.MethodPost, "/containers/"+created.ID+"/start", nil, nil, nil); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// pull pulls an image, which can take longer than apiRequestTimeout, so
+// only ctx bounds it
+func (r *apiRuntime) pull(ctx context.Context, image string) error {
+	resp, err := r.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Progress is streamed as JSON messages, and failures are reported in them
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if message.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", image, message.Error)
+		}
+	}
+}
+
+func (r *apiRuntime) Remove(ctx context.Context, container string) error {
+	return r.call(ctx, http.MethodDelete, "/containers/"+url.PathEscape(container), url.Values{"force": {"true"}}, nil, nil)
+}
+
+func (r *apiRuntime) List(ctx context.Context, filter string, all bool) ([]ContainerInfo, error) {
+	query := url.Values{"all": {strconv.FormatBool(all)}}
+	if filter != "" {
+		filters, _ := json.Marshal(map[string][]string{"name": {filter}})
+		query.Set("filters", string(filters))
+	}
+
+	var listed []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+		State string   `json:"State"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/containers/json", query, nil, &listed); err != nil {
+		return nil, err
+	}
+
+	containers := []ContainerInfo{}
+	for _, container := range listed {
+		info := ContainerInfo{ID: container.ID, State: container.State, Running: container.State == "running"}
+		if len(container.Names) > 0 {
+			info.Name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		containers = append(containers, info)
+	}
+	return containers, nil
+}
+
+func (r *apiRuntime) Inspect(ctx context.Context, container string) (ContainerInfo, error) {
+	var inspected containerInspect
+	if err := r.call(ctx, http.MethodGet, "/containers/"+url.PathEscape(container)+"/json", nil, nil, &inspected); err != nil {
+		return ContainerInfo{}, err
+	}
+	return inspected.info(), nil
+}
+
+func (r *apiRuntime) Logs(ctx context.Context, container string, opts LogOptions, stdout, stderr io.Writer) error {
+	query := url.Values{
+		"stdout": {"true"},
+		"stderr": {"true"},
+		"follow": {strconv.FormatBool(opts.Follow)},
+	}
+	if opts.Lines > 0 {
+		query.Set("tail", strconv.Itoa(opts.Lines))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+	if opts.Timestamps {
+		query.Set("timestamps", "true")
+	}
+
+	resp, err := r.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(container)+"/logs", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return demuxStream(resp.Body, stdout, stderr)
+}
+
+// demuxStream copies the output of a container without a TTY, which the
+// API multiplexes into frames with an 8-byte header naming the stream.
+// Output that isn't framed, from a container with a TTY, is copied as is.
+func demuxStream(body io.Reader, stdout, stderr io.Writer) error {
+	reader := bufio.NewReader(body)
+	first, err := reader.Peek(1)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if first[0] > 2 {
+		_, err := io.Copy(stdout, reader)
+		return err
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		out := stdout
+		if header[0] == 2 {
+			out = stderr
+		}
+		if _, err := io.CopyN(out, reader, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *apiRuntime) Exec(ctx context.Context, container string, command ...string) ([]byte, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	body := map[string]interface{}{"AttachStdout": true, "AttachStderr": true, "Cmd": command}
+	if err := r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(container)+"/exec", nil, body, &created); err != nil {
+		return nil, err
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, apiRequestTimeout)
+	defer cancel()
+	resp, err := r.do(execCtx, http.MethodPost, "/exec/"+created.ID+"/start", nil, map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var output bytes.Buffer
+	if err := demuxStream(resp.Body, &output, &output); err != nil {
+		return output.Bytes(), err
+	}
+
+	var inspected struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &inspected); err != nil {
+		return output.Bytes(), err
+	}
+	if inspected.ExitCode != 0 {
+		return output.Bytes(), fmt.Errorf("exit status %d", inspected.ExitCode)
+	}
+	return output.Bytes(), nil
+}
+
+func (r *apiRuntime) Wait(ctx context.Context, container string) (int, error) {
+	resp, err := r.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(container)+"/wait", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result.StatusCode, err
+}
+
+func (r *apiRuntime) CreateNetwork(ctx context.Context, name string) error {
+	if r.NetworkExists(ctx, name) {
+		return nil
+	}
+	return r.call(ctx, http.MethodPost, "/networks/create", nil, map[string]string{"Name": name}, nil)
+}
+
+func (r *apiRuntime) NetworkExists(ctx context.Context, name string) bool {
+	return r.call(ctx, http.MethodGet, "/networks/"+url.PathEscape(name), nil, nil, nil) == nil
+}
+
+func (r *apiRuntime) RemoveNetwork(ctx context.Context, name string) error {
+	return r.call(ctx, http.MethodDelete, "/networks/"+url.PathEscape(name), nil, nil, nil)
+}
+
+func (r *apiRuntime) VolumeExists(ctx context.Context, name string) bool {
+	return r.call(ctx, http.MethodGet, "/volumes/"+url.PathEscape(name), nil, nil, nil) == nil
+}
+
+func (r *apiRuntime) RemoveVolume(ctx context.Context, name string) error {
+	return r.call(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(name), nil, nil, nil)
+}
+
+func (r *apiRuntime) Events(ctx context.Context, container string) (<-chan ContainerEvent, error) {
+	filters, _ := json.Marshal(map[string][]string{"type": {"container"}, "container": {container}})
+	resp, err := r.do(ctx, http.MethodGet, "/events", url.Values{"filters": {string(filters)}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ContainerEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var message struct {
+				Action string `json:"Action"`
+				Actor  struct {
+					Attributes map[string]string `json:"Attributes"`
+				} `json:"Actor"`
+			}
+			if err := decoder.Decode(&message); err != nil {
+				return
+			}
+
+			event := ContainerEvent{Container: message.Actor.Attributes["name"], Action: message.Action}
+			event.ExitCode, _ = strconv.Atoi(message.Actor.Attributes["exitCode"])
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
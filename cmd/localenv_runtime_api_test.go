@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPIContainer is a container held by fakeAPIServer
+type fakeAPIContainer struct {
+	ID       string
+	Name     string
+	State    string
+	ExitCode int
+	Health   string
+	Body     map[string]interface{}
+}
+
+// fakeAPIServer serves the parts of the Docker-compatible API used by
+// apiRuntime on a unix socket
+type fakeAPIServer struct {
+	mu         sync.Mutex
+	containers map[string]*fakeAPIContainer
+	networks   map[string]bool
+	images     map[string]bool
+	pulled     []string
+	events     []map[string]interface{}
+}
+
+// startFakeAPIServer serves a fake API on a socket in a temporary directory
+func startFakeAPIServer(t *testing.T) (*fakeAPIServer, string) {
+	fake := &fakeAPIServer{containers: map[string]*fakeAPIContainer{}, networks: map[string]bool{}, images: map[string]bool{}}
+
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(fake.handler())
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return fake, socket
+}
+
+// find looks up a container by name or ID
+func (f *fakeAPIServer) find(container string) *fakeAPIContainer {
+	for _, c := range f.containers {
+		if c.Name == container || c.ID == container {
+			return c
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPIServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	notFound := func(w http.ResponseWriter, message string) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+	container := func(w http.ResponseWriter, r *http.Request) *fakeAPIContainer {
+		c := f.find(r.PathValue("id"))
+		if c == nil {
+			notFound(w, "no such container")
+		}
+		return c
+	}
+
+	mux.HandleFunc("GET /_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("POST /images/create", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		image := r.URL.Query().Get("fromImage")
+		f.pulled = append(f.pulled, image)
+		f.images[image] = true
+		w.Write([]byte(`{"status":"Pulling"}` + "\n" + `{"status":"Done"}`))
+	})
+	mux.HandleFunc("POST /containers/create", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !f.images[body["Image"].(string)] {
+			notFound(w, "no such image")
+			return
+		}
+		c := &fakeAPIContainer{ID: "id-" + r.URL.Query().Get("name"), Name: r.URL.Query().Get("name"), State: "created", Body: body}
+		f.containers[c.Name] = c
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": c.ID})
+	})
+	mux.HandleFunc("POST /containers/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if c := container(w, r); c != nil {
+			c.State = "running"
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("DELETE /containers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if c := container(w, r); c != nil {
+			delete(f.containers, c.Name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var filters map[string][]string
+		json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		listed := []map[string]interface{}{}
+		for _, c := range f.containers {
+			if len(filters["name"]) > 0 && !strings.Contains(c.Name, filters["name"][0]) {
+				continue
+			}
+			if c.State == "running" || r.URL.Query().Get("all") == "true" {
+				listed = append(listed, map[string]interface{}{"Id": c.ID, "Names": []string{"/" + c.Name}, "State": c.State})
+			}
+		}
+		json.NewEncoder(w).Encode(listed)
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if c := container(w, r); c != nil {
+			state := map[string]interface{}{"Status": c.State, "Running": c.State == "running", "ExitCode": c.ExitCode}
+			if c.Health != "" {
+				state["Health"] = map[string]string{"Status": c.Health}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"Id": c.ID, "Name": "/" + c.Name, "State": state, "Config": map[string]interface{}{"Env": c.Body["Env"]}})
+		}
+	})
+	mux.HandleFunc("GET /containers/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
+		frame := func(stream byte, data string) {
+			header := make([]byte, 8)
+			header[0] = stream
+			binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+			w.Write(append(header, data...))
+		}
+		frame(1, "started\n")
+		frame(2, "warning\n")
+	})
+	mux.HandleFunc("POST /containers/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string][]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": strings.Join(body["Cmd"], "_")})
+	})
+	mux.HandleFunc("POST /exec/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	})
+	mux.HandleFunc("GET /exec/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		exitCode := 0
+		if r.PathValue("id") == "false" {
+			exitCode = 1
+		}
+		json.NewEncoder(w).Encode(map[string]int{"ExitCode": exitCode})
+	})
+	mux.HandleFunc("POST /networks/create", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.networks[body["Name"]] = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /networks/{name}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.networks[r.PathValue("name")] {
+			notFound(w, "no such network")
+		}
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		for _, event := range f.events {
+			json.NewEncoder(w).Encode(event)
+		}
+		f.mu.Unlock()
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	return mux
+}
+
+// TestContainerRuntimeAPI tests talking to the runtime through its API socket
+func TestContainerRuntimeAPI(t *testing.T) {
+	origRuntime := containerRuntime
+	defer func() { containerRuntime = origRuntime }()
+
+	fake, socket := startFakeAPIServer(t)
+	t.Setenv("CONTAINER_HOST", "unix://"+socket)
+
+	api, ok := connectRuntimeAPI(podmanRuntime)
+	if !assert.True(t, ok, "the socket should answer") {
+		return
+	}
+	containerRuntime = api
+	assert.Equal(t, "podman", api.Name(), "Dapr should be given the runtime's name")
+
+	t.Run("Missing images should be pulled before running", func(t *testing.T) {
+		env := &LocalEnv{}
+		env.Config.Components.OpenSearch.Port = 9201
+		env.Config.Components.OpenSearch.Version = "2.17.1"
+
+		id, err := api.Run(context.Background(), openSearchDriver{}.containerSpec(env))
+		assert.NoError(t, err)
+		assert.Equal(t, "id-opensearch-node", id)
+		assert.Equal(t, []string{"opensearchproject/opensearch:2.17.1"}, fake.pulled)
+
+		body := fake.containers["opensearch-node"].Body
+		hostConfig := body["HostConfig"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"9200/tcp": []interface{}{map[string]interface{}{"HostPort": "9201"}}}, hostConfig["PortBindings"])
+		assert.Equal(t, "opensearch-network", hostConfig["NetworkMode"])
+		assert.Equal(t, []interface{}{"CMD-SHELL", "curl -u admin:admin -f http://localhost:9200/_cluster/health || exit 1"}, body["Healthcheck"].(map[string]interface{})["Test"])
+		assert.True(t, isContainerRunning(context.Background(), "opensearch-node"))
+	})
+
+	t.Run("Container names should match exactly", func(t *testing.T) {
+		fake.containers = map[string]*fakeAPIContainer{"opensearch-node-old": {ID: "old", Name: "opensearch-node-old", State: "running"}}
+		assert.False(t, isContainerRunning(context.Background(), "opensearch-node"))
+		assert.False(t, containerExists(context.Background(), "opensearch-node"))
+		assert.True(t, containerExists(context.Background(), "opensearch-node-old"))
+
+		containers, err := api.List(context.Background(), "opensearch", false)
+		assert.NoError(t, err)
+		assert.Equal(t, []ContainerInfo{{ID: "old", Name: "opensearch-node-old", State: "running", Running: true}}, containers)
+
+		assert.NoError(t, api.Remove(context.Background(), "opensearch-node-old"))
+		assert.Error(t, api.Remove(context.Background(), "opensearch-node-old"))
+	})
+
+	t.Run("Inspect should report health and exit code", func(t *testing.T) {
+		fake.containers = map[string]*fakeAPIContainer{"db": {ID: "db", Name: "db", State: "exited", ExitCode: 137, Health: "unhealthy"}}
+
+		container, err := api.Inspect(context.Background(), "db")
+		assert.NoError(t, err)
+		assert.False(t, container.Running)
+		assert.Equal(t, 137, container.ExitCode)
+		assert.Equal(t, "unhealthy", container.Health)
+		assert.False(t, waitForContainer(context.Background(), "db", "db"), "an exited container should not be waited for")
+	})
+
+	t.Run("Containers that die should stop the wait", func(t *testing.T) {
+		fake.containers = map[string]*fakeAPIContainer{"db": {ID: "db", Name: "db", State: "created"}}
+		fake.events = []map[string]interface{}{
+			{"Type": "container", "Action": "die", "Actor": map[string]interface{}{"ID": "db", "Attributes": map[string]string{"name": "db", "exitCode": "1"}}},
+		}
+		defer func() { fake.events = nil }()
+
+		assert.False(t, waitForContainer(context.Background(), "db", "db"))
+	})
+
+	t.Run("Logs should be demultiplexed", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+		assert.NoError(t, api.Logs(context.Background(), "db", LogOptions{Lines: 10}, &stdout, &stderr))
+		assert.Equal(t, "started\n", stdout.String())
+		assert.Equal(t, "warning\n", stderr.String())
+	})
+
+	t.Run("Exec should report the exit code", func(t *testing.T) {
+		output, err := api.Exec(context.Background(), "db", "true")
+		assert.NoError(t, err)
+		assert.Equal(t, "true", string(output))
+
+		_, err = api.Exec(context.Background(), "db", "false")
+		assert.EqualError(t, err, "exit status 1")
+	})
+
+	t.Run("Networks should be created once", func(t *testing.T) {
+		assert.False(t, api.NetworkExists(context.Background(), "localenv-network"))
+		assert.NoError(t, api.CreateNetwork(context.Background(), "localenv-network"))
+		assert.True(t, api.NetworkExists(context.Background(), "localenv-network"))
+		assert.NoError(t, api.CreateNetwork(context.Background(), "localenv-network"))
+	})
+
+	t.Run("Unreachable sockets should fall back to the CLI", func(t *testing.T) {
+		t.Setenv("CONTAINER_HOST", "unix://"+filepath.Join(t.TempDir(), "missing.sock"))
+		_, ok := connectRuntimeAPI(podmanRuntime)
+		assert.False(t, ok)
+
+		t.Setenv("CONTAINER_HOST", "ssh://remote/run/podman/podman.sock")
+		assert.Empty(t, podmanRuntime.socketPaths(), "remote hosts should be left to the CLI")
+	})
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lirtsman/devhelper-cli/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRuntime is an in-memory ContainerRuntime for tests
+type fakeRuntime struct {
+	containers map[string]ContainerInfo
+	networks   map[string]bool
+	volumes    map[string]bool
+	specs      []ContainerSpec
+}
+
+func newFakeRuntime() *fakeRuntime {
+	return &fakeRuntime{containers: map[string]ContainerInfo{}, networks: map[string]bool{}, volumes: map[string]bool{}}
+}
+
+func (f *fakeRuntime) Name() string               { return "fake" }
+func (f *fakeRuntime) Label() string              { return "Fake" }
+func (f *fakeRuntime) Available() bool            { return true }
+func (f *fakeRuntime) Ping(context.Context) error { return nil }
+
+func (f *fakeRuntime) Run(_ context.Context, spec ContainerSpec) (string, error) {
+	if _, ok := f.containers[spec.Name]; ok {
+		return "", fmt.Errorf("container %s already exists", spec.Name)
+	}
+	f.specs = append(f.specs, spec)
+	id := fmt.Sprintf("id-%d", len(f.specs))
+	f.containers[spec.Name] = ContainerInfo{ID: id, Name: spec.Name, State: "running", Running: true, Env: spec.Env, Labels: spec.Labels}
+	return id, nil
+}
+
+// find looks up a container by name or ID
+func (f *fakeRuntime) find(container string) (string, bool) {
+	for name, info := range f.containers {
+		if name == container || info.ID == container {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (f *fakeRuntime) Remove(_ context.Context, container string) error {
+	name, ok := f.find(container)
+	if !ok {
+		return errors.New("no such container")
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+func (f *fakeRuntime) List(_ context.Context, filter string, all bool) ([]ContainerInfo, error) {
+	containers := []ContainerInfo{}
+	for name, info := range f.containers {
+		if strings.Contains(name, filter) && (all || info.Running) {
+			containers = append(containers, info)
+		}
+	}
+	return containers, nil
+}
+
+func (f *fakeRuntime) Inspect(_ context.Context, container string) (ContainerInfo, error) {
+	name, ok := f.find(container)
+	if !ok {
+		return ContainerInfo{}, errors.New("no such container")
+	}
+	return f.containers[name], nil
+}
+
+func (f *fakeRuntime) Logs(_ context.Context, container string, opts LogOptions, stdout, stderr io.Writer) error {
+	_, err := fmt.Fprintf(stdout, "logs of %s\n", container)
+	return err
+}
+
+func (f *fakeRuntime) Exec(_ context.Context, container string, command ...string) ([]byte, error) {
+	return nil, nil
+}
+
+func (f *fakeRuntime) Wait(_ context.Context, container string) (int, error) {
+	name, ok := f.find(container)
+	if !ok {
+		return 0, errors.New("no such container")
+	}
+	f.containers[name] = ContainerInfo{ID: f.containers[name].ID, Name: name, State: "exited"}
+	return 0, nil
+}
+
+func (f *fakeRuntime) CreateNetwork(_ context.Context, name string) error {
+	f.networks[name] = true
+	return nil
+}
+
+func (f *fakeRuntime) NetworkExists(_ context.Context, name string) bool { return f.networks[name] }
+
+func (f *fakeRuntime) RemoveNetwork(_ context.Context, name string) error {
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *fakeRuntime) VolumeExists(_ context.Context, name string) bool { return f.volumes[name] }
+
+func (f *fakeRuntime) RemoveVolume(_ context.Context, name string) error {
+	if !f.volumes[name] {
+		return errors.New("no such volume")
+	}
+	delete(f.volumes, name)
+	return nil
+}
+
+// TestContainerRuntime tests selecting and using the container runtime
+func TestContainerRuntime(t *testing.T) {
+	origCommandCheck := isCommandAvailable
+	origRuntime := containerRuntime
+	defer func() {
+		isCommandAvailable = origCommandCheck
+		containerRuntime = origRuntime
+	}()
+
+	t.Run("Configured runtime should be used", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{"podman": true})
+
+		config := LocalEnvConfig{}
+		config.Tools.ContainerRuntime = "docker"
+		assert.Equal(t, "docker", selectContainerRuntime(config).Name())
+	})
+
+	t.Run("Installed runtime should be detected", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{"docker": true})
+		assert.Equal(t, "docker", selectContainerRuntime(LocalEnvConfig{}).Name())
+
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{"podman": true, "docker": true})
+		assert.Equal(t, "podman", selectContainerRuntime(LocalEnvConfig{}).Name(), "Podman should be preferred")
+
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+		assert.Equal(t, "podman", selectContainerRuntime(LocalEnvConfig{}).Name(), "Podman should be the fallback")
+	})
+
+	t.Run("Unsupported runtime should be reported", func(t *testing.T) {
+		_, err := parseLocalEnvConfig("localenv.yaml", []byte("tools:\n  containerRuntime: lxc\n"), "")
+		assert.ErrorContains(t, err, `tools.containerRuntime "lxc" is not supported, use podman or docker`)
+	})
+
+	t.Run("CLI arguments should be built from the container spec", func(t *testing.T) {
+		env := &LocalEnv{}
+		env.Config.Components.OpenSearch.Port = 9200
+		env.Config.Components.OpenSearch.Version = "2.17.1"
+
+		args := cliRuntime{command: "docker"}.runArgs(openSearchDriver{}.containerSpec(env))
+		assert.Equal(t, []string{"run", "-d", "--name", "opensearch-node", "--network", "opensearch-network", "--restart", "unless-stopped", "-p", "9200:9200"}, args[:10])
+		assert.Contains(t, strings.Join(args, " "), "--health-interval 30s --health-timeout 10s --health-retries 5")
+		assert.Equal(t, "opensearchproject/opensearch:2.17.1", args[len(args)-1])
+	})
+
+	t.Run("Container components should go through the runtime", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		fake := newFakeRuntime()
+		containerRuntime = fake
+
+		env := &LocalEnv{State: loadLocalEnvState()}
+		id, err := runContainer(context.Background(), "OpenSearch", ContainerSpec{Name: openSearchContainerName(), Env: []string{"DISABLE_SECURITY_PLUGIN=true"}}, false)
+		assert.NoError(t, err)
+		assert.NoError(t, env.State.recordContainer("OpenSearch", id))
+		assert.True(t, isContainerRunning(context.Background(), openSearchContainerName()))
+
+		status := openSearchDriver{}.Status(env)
+		assert.True(t, status.Running)
+		assert.Contains(t, status.Details, "Security plugin disabled - no credentials required for API")
+
+		assert.NoError(t, openSearchDriver{}.Stop(env))
+		assert.False(t, containerExists(context.Background(), id), "recorded container should be removed")
+
+		fake.Run(context.Background(), ContainerSpec{Name: openSearchDashboardContainerName()})
+		assert.ErrorIs(t, openSearchDashboardDriver{}.Stop(env), errComponentNotRunning, "untracked containers should be kept")
+		env.Aggressive = true
+		assert.NoError(t, openSearchDashboardDriver{}.Stop(env))
+		assert.False(t, containerExists(context.Background(), openSearchDashboardContainerName()))
+	})
+}
@@ -0,0 +1,298 @@
+/*
+Copyright © 2023 Shield
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/lirtsman/devhelper-cli/internal/procnet"
+)
+
+// LocalEnvState records the processes and containers launched by
+// `localenv start`, so stop and status act on exactly those resources
+// instead of searching for them by name or port.
+type LocalEnvState struct {
+	Components map[string]*ComponentState `yaml:"components"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// ComponentState holds the resources launched for a single component
+type ComponentState struct {
+	PIDs []int `yaml:"pids,omitempty"`
+	PGID int   `yaml:"pgid,omitempty"` // Process group of the launched processes
+	// Starts holds when each process started, so a PID reused after a
+	// reboot or by another process isn't mistaken for it
+	Starts     map[int]string `yaml:"starts,omitempty"`
+	Containers []string       `yaml:"containers,omitempty"`
+	StartedAt  time.Time      `yaml:"startedAt"`
+}
+
+// stateFilePath returns the location of the state file of the active profile
+func stateFilePath() string {
+	return filepath.Join(configDir(), "state.yaml")
+}
+
+// loadLocalEnvState reads the state file. A missing or unreadable file
+// results in an empty state.
+func loadLocalEnvState() *LocalEnvState {
+	state := &LocalEnvState{path: stateFilePath()}
+
+	if data, err := os.ReadFile(state.path); err == nil {
+		yamlv3.Unmarshal(data, state)
+	}
+	if state.Components == nil {
+		state.Components = map[string]*ComponentState{}
+	}
+	return state
+}
+
+// save writes the state file. The caller must hold s.mu.
+func (s *LocalEnvState) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := yamlv3.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// entry returns the state of a component, creating it if needed.
+// The caller must hold s.mu.
+func (s *LocalEnvState) entry(component string) *ComponentState {
+	key := strings.ToLower(component)
+	if s.Components[key] == nil {
+		s.Components[key] = &ComponentState{StartedAt: time.Now()}
+	}
+	return s.Components[key]
+}
+
+// component returns a copy of the recorded state of a component
+func (s *LocalEnvState) component(component string) (ComponentState, bool) {
+	if s == nil {
+		return ComponentState{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded, ok := s.Components[strings.ToLower(component)]
+	if !ok {
+		return ComponentState{}, false
+	}
+	copied := *recorded
+	copied.PIDs = append([]int(nil), recorded.PIDs...)
+	copied.Containers = append([]string(nil), recorded.Containers...)
+	copied.Starts = map[int]string{}
+	for pid, started := range recorded.Starts {
+		copied.Starts[pid] = started
+	}
+	return copied, true
+}
+
+// startedSince reports whether the component was recorded as started at or after t
+func (s *LocalEnvState) startedSince(component string, t time.Time) bool {
+	recorded, ok := s.component(component)
+	return ok && !recorded.StartedAt.Before(t)
+}
+
+// recordProcess records a process launched for a component and saves the state
+func (s *LocalEnvState) recordProcess(component string, pid, pgid int) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(component)
+	entry.PIDs = append(entry.PIDs, pid)
+	if pgid > 0 {
+		entry.PGID = pgid
+	}
+	if started, err := prober.ProcessStart(pid); err == nil {
+		if entry.Starts == nil {
+			entry.Starts = map[int]string{}
+		}
+		entry.Starts[pid] = started
+	}
+	return s.save()
+}
+
+// recordContainer records a container launched for a component and saves the state
+func (s *LocalEnvState) recordContainer(component, containerID string) error {
+	if s == nil || containerID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(component)
+	entry.Containers = append(entry.Containers, containerID)
+	return s.save()
+}
+
+// forget removes a component from the state and saves it
+func (s *LocalEnvState) forget(component string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Components, strings.ToLower(component))
+	return s.save()
+}
+
+// runningPIDs returns the recorded PIDs of a component that still belong
+// to the processes that were launched
+func (s *LocalEnvState) runningPIDs(component string) []int {
+	recorded, ok := s.component(component)
+	if !ok {
+		return nil
+	}
+	return recorded.runningPIDs()
+}
+
+// runningPIDs returns the recorded PIDs that still belong to the processes
+// that were launched. PIDs recorded without a start time are left out where
+// start times can be read, as they can't be told apart from reused ones.
+func (c ComponentState) runningPIDs() []int {
+	pids := []int{}
+	for _, pid := range c.PIDs {
+		if sameProcess(pid, c.Starts[pid]) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// sameProcess reports whether pid is alive and started at started. Where
+// start times can't be read, only whether pid is alive is checked.
+func sameProcess(pid int, started string) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	current, err := prober.ProcessStart(pid)
+	if errors.Is(err, procnet.ErrUnsupported) {
+		return true
+	}
+	return err == nil && current == started
+}
+
+// stopTrackedComponent stops the processes and removes the containers
+// recorded for a component. It returns errComponentNotRunning when nothing
+// recorded is still running.
+func stopTrackedComponent(env *LocalEnv, component string) error {
+	recorded, ok := env.State.component(component)
+	if !ok {
+		return errComponentNotRunning
+	}
+
+	stopped := false
+	var stopErr error
+
+	// Recorded PIDs that now belong to other processes are dropped with the
+	// component below instead of being signalled
+	alive := recorded.runningPIDs()
+	if len(alive) > 0 {
+		if env.Verbose {
+			fmt.Printf("Stopping %s processes %v (process group %d)\n", component, alive, recorded.PGID)
+		}
+		for _, pid := range alive {
+			// Only signal the group while a launched process still belongs to it
+			pgid := recorded.PGID
+			if processGroupID(pid) != pgid {
+				pgid = 0
+			}
+			if err := terminateProcess(env.ctx(), pid, pgid, recorded.Starts[pid], env.Force); err != nil {
+				stopErr = fmt.Errorf("failed to stop process %d: %w", pid, err)
+				continue
+			}
+			stopped = true
+		}
+	}
+
+	for _, containerID := range recorded.Containers {
+		if !containerExists(env.ctx(), containerID) {
+			continue
+		}
+		if err := containerRuntime.Remove(env.ctx(), containerID); err != nil {
+			stopErr = fmt.Errorf("failed to remove container %s: %w", containerID, err)
+			continue
+		}
+		stopped = true
+	}
+
+	if stopErr != nil {
+		return stopErr
+	}
+
+	if err := env.State.forget(component); err != nil && env.Verbose {
+		fmt.Printf("⚠️ Failed to update state file: %v\n", err)
+	}
+	if !stopped {
+		return errComponentNotRunning
+	}
+	return nil
+}
+
+// otherProfilesUsing returns the profiles other than the active one whose
+// state records the component. It's used for machine-wide components such
+// as the Dapr runtime that profiles share.
+func otherProfilesUsing(component string) []string {
+	profiles := map[string]string{defaultProfile: filepath.Join(baseConfigDir(), "state.yaml")}
+	if dirs, err := os.ReadDir(filepath.Join(baseConfigDir(), "profiles")); err == nil {
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				profiles[dir.Name()] = filepath.Join(profileDir(baseConfigDir(), dir.Name()), "state.yaml")
+			}
+		}
+	}
+
+	users := []string{}
+	for profile, path := range profiles {
+		if path == stateFilePath() {
+			continue
+		}
+		state := &LocalEnvState{}
+		if data, err := os.ReadFile(path); err != nil || yamlv3.Unmarshal(data, state) != nil {
+			continue
+		}
+		if _, ok := state.Components[strings.ToLower(component)]; ok {
+			users = append(users, profile)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// printUntrackedHint explains why stop didn't find a component
+func printUntrackedHint(component string) {
+	fmt.Printf("ℹ️ No running %s started by 'localenv start' was found in %s\n", component, stateFilePath())
+	fmt.Println("   Use --aggressive to search for it by process name and port instead.")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLocalEnvState tests recording resources started by localenv
+func TestLocalEnvState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	t.Run("Missing state file should load as empty", func(t *testing.T) {
+		state := loadLocalEnvState()
+		assert.NotNil(t, state.Components)
+		assert.Empty(t, state.Components)
+
+		_, ok := state.component("Temporal")
+		assert.False(t, ok, "no component should be recorded")
+	})
+
+	t.Run("Recorded resources should be saved and reloaded", func(t *testing.T) {
+		state := loadLocalEnvState()
+		assert.NoError(t, state.recordProcess("Temporal", 1234, 1234))
+		assert.NoError(t, state.recordContainer("OpenSearch", "abc123"))
+
+		_, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".config", "devhelper-cli", "state.yaml"))
+		assert.NoError(t, err, "state file should be written")
+
+		reloaded := loadLocalEnvState()
+		temporal, ok := reloaded.component("temporal")
+		assert.True(t, ok, "component lookup should ignore case")
+		assert.Equal(t, []int{1234}, temporal.PIDs)
+		assert.Equal(t, 1234, temporal.PGID)
+		assert.False(t, temporal.StartedAt.IsZero(), "start time should be recorded")
+
+		opensearch, ok := reloaded.component("OpenSearch")
+		assert.True(t, ok)
+		assert.Equal(t, []string{"abc123"}, opensearch.Containers)
+	})
+
+	t.Run("Forgotten components should be removed", func(t *testing.T) {
+		state := loadLocalEnvState()
+		assert.NoError(t, state.forget("Temporal"))
+
+		_, ok := loadLocalEnvState().component("Temporal")
+		assert.False(t, ok, "Temporal should be forgotten")
+	})
+
+	t.Run("Nil state should be safe to use", func(t *testing.T) {
+		var state *LocalEnvState
+		assert.NoError(t, state.recordProcess("Temporal", 1, 1))
+		assert.NoError(t, state.forget("Temporal"))
+		assert.Empty(t, state.runningPIDs("Temporal"))
+	})
+
+	t.Run("Untracked components should not be stopped", func(t *testing.T) {
+		env := &LocalEnv{State: loadLocalEnvState()}
+		assert.ErrorIs(t, stopTrackedComponent(env, "DaprDashboard"), errComponentNotRunning)
+		assert.ErrorIs(t, daprDriver{}.Stop(env), errComponentNotRunning, "a Dapr installed outside of localenv should not be uninstalled")
+	})
+}
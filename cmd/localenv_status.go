@@ -16,11 +16,11 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +29,68 @@ import (
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
+// EnvironmentStatus is the machine-readable result of `localenv status`.
+// Bump statusSchemaVersion when fields are renamed or removed.
+type EnvironmentStatus struct {
+	SchemaVersion int               `json:"schemaVersion" yaml:"schemaVersion"`
+	ConfigFile    string            `json:"configFile" yaml:"configFile"`
+	Profile       string            `json:"profile" yaml:"profile"`
+	ConfigLoaded  bool              `json:"configLoaded" yaml:"configLoaded"`
+	Error         string            `json:"error,omitempty" yaml:"error,omitempty"` // Why the configuration could not be loaded
+	Healthy       bool              `json:"healthy" yaml:"healthy"`                 // All required tools work and all enabled components run
+	Tools         []ToolStatus      `json:"tools" yaml:"tools"`
+	Components    []ComponentStatus `json:"components" yaml:"components"`
+	// Apps are started on demand by `localenv run`, so they don't affect Healthy
+	Apps []ComponentStatus `json:"apps,omitempty" yaml:"apps,omitempty"`
+}
+
+// ToolStatus describes a command line tool the local environment depends on
+type ToolStatus struct {
+	Name      string `json:"name" yaml:"name"`
+	Command   string `json:"command" yaml:"command"`
+	Path      string `json:"path" yaml:"path"`
+	Required  bool   `json:"required" yaml:"required"`
+	Installed bool   `json:"installed" yaml:"installed"`
+	Working   bool   `json:"working" yaml:"working"`
+	Message   string `json:"message" yaml:"message"`
+	Details   string `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+const statusSchemaVersion = 1
+
+// statusTool describes how the status command checks a tool
+type statusTool struct {
+	Name       string
+	Command    string
+	CheckArgs  []string
+	Components []string // Tool is only required when one of these components is enabled
+	Shared     bool     // Tool is listed with its functionality instead of through its components
+}
+
+// statusTools returns the tools checked by the status command, starting
+// with the selected container runtime. No component needs Kind, so it is
+// reported but never required.
+func statusTools(config LocalEnvConfig) []statusTool {
+	return []statusTool{
+		{Name: containerRuntime.Label(), Command: containerRuntime.Name(), CheckArgs: []string{"ps"}, Components: containerComponents(config), Shared: true},
+		{Name: "Kind", Command: "kind", CheckArgs: []string{"get", "clusters"}, Shared: true},
+		{Name: "Dapr", Command: "dapr", CheckArgs: []string{"--version"}, Components: []string{"Dapr", "DaprDashboard"}},
+		{Name: "Temporal", Command: "temporal", CheckArgs: []string{"--version"}, Components: []string{"Temporal"}},
+	}
+}
+
+// containerComponents returns the components that run containers: the
+// containers created by `dapr init`, OpenSearch and the custom components
+func containerComponents(config LocalEnvConfig) []string {
+	components := []string{"Dapr", "OpenSearch", "OpenSearchDashboard"}
+	for _, driver := range environmentComponents(config) {
+		if _, ok := driver.(customComponentDriver); ok {
+			components = append(components, driver.Name())
+		}
+	}
+	return components
+}
+
 // statusCmd represents the status command for localenv
 var localenvStatusCmd = &cobra.Command{
 	Use:   "status",
@@ -39,12 +101,26 @@ This command will check if all required components for the local development
 environment are running, including:
 - Dapr runtime
 - Temporal server
-- Related dependencies`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Checking local environment status...")
+- Related dependencies
 
+Use --output json or --output yaml for machine-readable output. The command
+exits with a non-zero status when localenv.yaml can't be loaded or a required
+tool or enabled component is down.`,
+	Run: func(cmd *cobra.Command, args []string) {
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		configPath, _ := cmd.Flags().GetString("config")
+		output, _ := cmd.Flags().GetString("output")
+		aggressive, _ := cmd.Flags().GetBool("aggressive")
+
+		if output != "text" && output != "json" && output != "yaml" {
+			fmt.Fprintf(os.Stderr, "Error: unsupported output format %q (use text, json or yaml)\n", output)
+			os.Exit(1)
+		}
+		textOutput := output == "text"
+
+		if textOutput {
+			fmt.Println("Checking local environment status...")
+		}
 
 		// If no config path is provided, look for localenv.yaml in current directory
 		if configPath == "" {
@@ -52,485 +128,342 @@ environment are running, including:
 		}
 
 		// Load configuration if available
-		config := LocalEnvConfig{}
-		configLoaded := false
-
-		// Check if config file exists
-		if _, err := os.Stat(configPath); err == nil {
-			// Read and parse configuration
-			configData, err := os.ReadFile(configPath)
-			if err == nil {
-				err = yamlv3.Unmarshal(configData, &config)
-				if err == nil {
-					configLoaded = true
-					fmt.Printf("✅ Loaded configuration from %s\n", configPath)
-				} else if verbose {
-					fmt.Printf("⚠️ Failed to parse configuration: %v\n", err)
-				}
+		config, configLoaded, err := loadLocalEnvConfig(configPath)
+		if textOutput {
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+			} else if configLoaded {
+				fmt.Printf("✅ Loaded configuration from %s\n", configPath)
 			} else if verbose {
-				fmt.Printf("⚠️ Failed to read configuration: %v\n", err)
+				fmt.Printf("⚠️ Configuration file not found at %s\n", configPath)
+				fmt.Println("   Run 'devhelper-cli localenv init' to create a configuration")
 			}
-		} else if verbose {
-			fmt.Printf("⚠️ Configuration file not found at %s\n", configPath)
-			fmt.Println("   Run 'devhelper-cli localenv init' to create a configuration")
 		}
 
-		// Define status checks for each component
-		components := []struct {
-			Name          string
-			CheckCommand  string
-			CheckArgs     []string
-			StatusMessage string
-			Available     bool
-			Enabled       bool
-			WebUIURL      string
-			CheckUI       bool
-			IsBinary      bool
-		}{
-			{
-				Name:         "Podman",
-				CheckCommand: "podman",
-				CheckArgs:    []string{"ps", "--format", "{{.Names}} - {{.Status}}"},
-				Available:    isCommandAvailable("podman"),
-				Enabled:      true, // Always required
-				IsBinary:     true,
-			},
-			{
-				Name:         "Kind",
-				CheckCommand: "kind",
-				CheckArgs:    []string{"get", "clusters"},
-				Available:    isCommandAvailable("kind"),
-				Enabled:      true, // Always required
-				IsBinary:     true,
-			},
-			{
-				Name:         "Dapr",
-				CheckCommand: "dapr",
-				CheckArgs:    []string{"list"},
-				Available:    isCommandAvailable("dapr"),
-				Enabled:      getDaprStatusRequirement(configLoaded, config.Components.Dapr.Enabled),
-				WebUIURL:     getDaprWebUIURL(configLoaded, config),
-				CheckUI:      isDaprDashboardAvailable(),
-				IsBinary:     false,
-			},
-			{
-				Name:         "Temporal",
-				CheckCommand: "temporal",
-				CheckArgs:    getTemporalNamespaceArgs(configLoaded, config),
-				Available:    isCommandAvailable("temporal"),
-				Enabled:      getTemporalStatusRequirement(configLoaded, config.Components.Temporal.Enabled),
-				WebUIURL:     getTemporalUIURL(configLoaded, config),
-				CheckUI:      true,
-				IsBinary:     false,
-			},
+		containerRuntime = selectContainerRuntime(config)
+		env := &LocalEnv{
+			Config:       config,
+			ConfigPath:   configPath,
+			ConfigLoaded: configLoaded,
+			Verbose:      verbose,
+			Skip:         map[string]bool{},
+			State:        loadLocalEnvState(),
+			Aggressive:   aggressive,
 		}
 
-		fmt.Println("\n=== Local Environment Status ===")
-
-		// First check required tools
-		fmt.Println("\n== Required Tools ==")
-		allToolsInstalled := true
-		for _, comp := range components {
-			if !comp.Available {
-				fmt.Printf("❌ %s: Not installed\n", comp.Name)
-				allToolsInstalled = false
-			} else if comp.IsBinary {
-				fmt.Printf("✅ %s: Installed\n", comp.Name)
+		status := withConfigError(collectEnvironmentStatus(env, configPath), err)
+
+		switch output {
+		case "json":
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to encode status: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		case "yaml":
+			encoder := yamlv3.NewEncoder(os.Stdout)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(status); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to encode status: %v\n", err)
+				os.Exit(1)
 			}
+		default:
+			printEnvironmentStatus(status, verbose)
 		}
 
-		if !allToolsInstalled {
-			fmt.Println("\n❌ Some required tools are not installed.")
-			fmt.Println("Run 'devhelper-cli localenv init' to check required dependencies and create a configuration.")
-			return
+		if !status.Healthy {
+			os.Exit(1)
 		}
+	},
+}
 
-		// Check Podman functionality
-		fmt.Println("\n== Tool Functionality ==")
-		podmanWorking := checkToolFunctionality("podman", []string{"ps"}, verbose)
-		if podmanWorking {
-			fmt.Println("✅ Podman: Can run containers")
-		} else {
-			fmt.Println("❌ Podman: Not able to run containers")
-			fmt.Println("   Make sure Podman is installed correctly and has proper permissions.")
-		}
+// collectEnvironmentStatus checks every tool and enabled component
+func collectEnvironmentStatus(env *LocalEnv, configPath string) EnvironmentStatus {
+	status := EnvironmentStatus{
+		SchemaVersion: statusSchemaVersion,
+		ConfigFile:    configPath,
+		Profile:       profileLabel(),
+		ConfigLoaded:  env.ConfigLoaded,
+		Healthy:       true,
+		Tools:         []ToolStatus{},
+		Components:    []ComponentStatus{},
+	}
 
-		// Check Kind functionality
-		kindWorking := checkToolFunctionality("kind", []string{"get", "clusters"}, verbose)
-		if kindWorking {
-			// Execute kind get clusters and check if any clusters are listed
-			cmd := exec.Command("kind", "get", "clusters")
-			output, err := cmd.Output()
-			outputStr := strings.TrimSpace(string(output))
-
-			if err == nil && outputStr != "" {
-				fmt.Println("✅ Kind: Clusters configured")
-			} else {
-				fmt.Println("✅ Kind: Tool working, but no clusters configured")
-				fmt.Println("   Note: Kubernetes functionality is not required for local development.")
-			}
-		} else {
-			fmt.Println("❌ Kind: No clusters available")
-			fmt.Println("   Note: Kubernetes functionality is not required for local development.")
-		}
+	enabled := map[string]bool{}
+	drivers := environmentComponents(env.Config)
+	for _, driver := range drivers {
+		enabled[driver.Name()] = driver.Enabled(env)
+	}
 
-		if !podmanWorking || !kindWorking {
-			fmt.Println("\n❌ Required tools are not working properly.")
-			fmt.Println("Fix the issues above before continuing.")
-			return
+	for _, tool := range statusTools(env.Config) {
+		toolStatus := checkStatusTool(tool, enabled)
+		if toolStatus.Required && !toolStatus.Working {
+			status.Healthy = false
 		}
+		status.Tools = append(status.Tools, toolStatus)
+	}
 
-		// Check enabled services
-		fmt.Println("\n== Enabled Components ==")
-		allRunning := true
-		anyEnabled := false
+	for _, driver := range drivers {
+		var componentStatus ComponentStatus
+		switch {
+		case !enabled[driver.Name()]:
+			componentStatus = ComponentStatus{Name: driver.Name(), Message: "Disabled"}
+		case !driver.Installed():
+			componentStatus = ComponentStatus{Name: driver.Name(), Enabled: true, Message: "Not installed"}
+		default:
+			componentStatus = driver.Status(env)
+		}
 
-		for _, comp := range components {
-			if !comp.Enabled || comp.IsBinary {
-				continue
-			}
+		if componentStatus.Enabled && !componentStatus.Running {
+			status.Healthy = false
+		}
+		status.Components = append(status.Components, normalizeComponentStatus(componentStatus))
+	}
 
-			anyEnabled = true
+	for _, driver := range environmentApps(env.Config) {
+		appStatus := ComponentStatus{Name: driver.Name(), Enabled: true, Message: "Not installed"}
+		if driver.Installed() {
+			appStatus = driver.Status(env)
+		}
+		status.Apps = append(status.Apps, normalizeComponentStatus(appStatus))
+	}
 
-			// Special handling for Dapr
-			if comp.Name == "Dapr" {
-				// Check if Dapr binaries exist
-				_, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".dapr", "bin", "daprd"))
-				if err != nil {
-					fmt.Printf("❌ %s: Not initialized\n", comp.Name)
-					fmt.Println("   Run 'devhelper-cli localenv start' to initialize Dapr")
-					allRunning = false
-					continue
-				}
+	return status
+}
 
-				// Check if we can run dapr list
-				cmd := exec.Command("dapr", "list")
-				output, err := cmd.CombinedOutput()
-				if err != nil {
-					fmt.Printf("❌ %s: Not running properly\n", comp.Name)
-					if verbose && len(output) > 0 {
-						fmt.Printf("   Details: %s\n", strings.TrimSpace(string(output)))
-					}
-					allRunning = false
-					continue
-				}
+// withConfigError records why localenv.yaml could not be loaded, so the
+// environment isn't reported as healthy for a configuration that can't be used
+func withConfigError(status EnvironmentStatus, err error) EnvironmentStatus {
+	if err != nil {
+		status.Error = err.Error()
+		status.Healthy = false
+	}
+	return status
+}
 
-				fmt.Printf("✅ %s: Running\n", comp.Name)
-				if verbose {
-					fmt.Println("   No Dapr apps are currently running, but the runtime is available")
-				}
-				continue
-			}
+// checkStatusTool checks whether a tool is installed and working
+func checkStatusTool(tool statusTool, enabled map[string]bool) ToolStatus {
+	status := ToolStatus{
+		Name:    tool.Name,
+		Command: tool.Command,
+	}
+	for _, component := range tool.Components {
+		if enabled[component] {
+			status.Required = true
+		}
+	}
 
-			// Standard check for other components
-			cmd := exec.Command(comp.CheckCommand, comp.CheckArgs...)
-			output, err := cmd.CombinedOutput()
-			outputStr := strings.TrimSpace(string(output))
+	if !isCommandAvailable(tool.Command) {
+		status.Message = "Not installed"
+		return status
+	}
+	status.Installed = true
+	if path, err := exec.LookPath(tool.Command); err == nil {
+		status.Path = path
+	}
 
-			if err != nil {
-				// Special case for Temporal - sometimes workflow list returns an error even when Temporal is running
-				if comp.Name == "Temporal" {
-					// Try a simpler check - just use the UI availability as the primary indicator
-					client := http.Client{
-						Timeout: 2 * time.Second,
-					}
-					resp, err := client.Get(comp.WebUIURL)
-					if err == nil && resp.StatusCode < 400 {
-						resp.Body.Close()
-						fmt.Printf("✅ %s: Running\n", comp.Name)
-						fmt.Printf("   UI: %s (Accessible)\n", comp.WebUIURL)
-						continue
-					}
-				}
+	output, err := exec.Command(tool.Command, tool.CheckArgs...).CombinedOutput()
+	outputStr := strings.TrimSpace(string(output))
+	status.Working = err == nil
+
+	switch {
+	case tool.Command == containerRuntime.Name() && status.Working:
+		status.Message = "Can run containers"
+	case tool.Command == containerRuntime.Name():
+		status.Message = "Not able to run containers"
+	case tool.Command == "kind" && status.Working && outputStr != "":
+		status.Message = "Clusters configured"
+	case tool.Command == "kind" && status.Working:
+		status.Message = "Tool working, but no clusters configured"
+	case tool.Command == "kind":
+		status.Message = "No clusters available"
+	case status.Working:
+		status.Message = "Installed"
+	default:
+		status.Message = "Not working properly"
+	}
 
-				fmt.Printf("❌ %s: Not running\n", comp.Name)
-				if verbose && outputStr != "" {
-					fmt.Printf("   Details: %s\n", outputStr)
-				}
-				allRunning = false
-				continue
-			}
+	if !status.Working {
+		status.Details = outputStr
+	}
+	return status
+}
 
-			fmt.Printf("✅ %s: Running\n", comp.Name)
+// normalizeComponentStatus replaces nil slices so the JSON schema always
+// contains arrays rather than null
+func normalizeComponentStatus(status ComponentStatus) ComponentStatus {
+	if status.PIDs == nil {
+		status.PIDs = []int{}
+	}
+	if status.Containers == nil {
+		status.Containers = []string{}
+	}
+	if status.Ports == nil {
+		status.Ports = []int{}
+	}
+	if status.Endpoints == nil {
+		status.Endpoints = []ComponentEndpoint{}
+	}
+	if status.Details == nil {
+		status.Details = []string{}
+	}
+	return status
+}
 
-			// For Temporal, check if the UI is accessible
-			if comp.CheckUI && comp.WebUIURL != "" {
-				uiAccessible := false
-				client := http.Client{
-					Timeout: 2 * time.Second,
-				}
-				resp, err := client.Get(comp.WebUIURL)
-				if err == nil && resp.StatusCode < 400 {
-					uiAccessible = true
-					resp.Body.Close()
-				}
+// printEnvironmentStatus renders the environment status as human readable text
+func printEnvironmentStatus(status EnvironmentStatus, verbose bool) {
+	fmt.Println("\n=== Local Environment Status ===")
+	if status.Profile != defaultProfile {
+		fmt.Printf("Profile: %s\n", status.Profile)
+	}
 
-				if uiAccessible {
-					fmt.Printf("   UI: %s (Accessible)\n", comp.WebUIURL)
-				} else {
-					fmt.Printf("   UI: %s (Not accessible yet, may still be starting up)\n", comp.WebUIURL)
-				}
+	// First check required tools
+	fmt.Println("\n== Required Tools ==")
+	allToolsInstalled := true
+	for _, tool := range status.Tools {
+		switch {
+		case tool.Required && !tool.Installed:
+			fmt.Printf("❌ %s: Not installed\n", tool.Name)
+			allToolsInstalled = false
+		case !isSharedStatusTool(tool.Command):
+			// Component tools are covered by their components
+		case tool.Installed:
+			fmt.Printf("✅ %s: Installed\n", tool.Name)
+		default:
+			fmt.Printf("ℹ️ %s: Not installed (not needed by the enabled components)\n", tool.Name)
+		}
+	}
 
-				// Check if the configured namespace exists (if not default)
-				if comp.Name == "Temporal" && configLoaded && config.Components.Temporal.Enabled && config.Components.Temporal.Namespace != "" && config.Components.Temporal.Namespace != "default" {
-					namespaceCmd := exec.Command("temporal", "operator", "namespace", "describe", config.Components.Temporal.Namespace)
-					if err := namespaceCmd.Run(); err != nil {
-						fmt.Printf("   ⚠️ Namespace '%s' does not exist. It will be created when starting the environment.\n", config.Components.Temporal.Namespace)
-					} else {
-						fmt.Printf("   ✅ Temporal namespace '%s' exists\n", config.Components.Temporal.Namespace)
-					}
-				}
-			}
+	if !allToolsInstalled {
+		fmt.Println("\n❌ Some required tools are not installed.")
+		fmt.Println("Run 'devhelper-cli localenv init' to check required dependencies and create a configuration.")
+		return
+	}
 
-			if verbose && outputStr != "" {
-				// Format and print relevant output details
-				lines := strings.Split(outputStr, "\n")
-				if len(lines) > 5 && !verbose {
-					// Truncate output if it's too long and we're not in verbose mode
-					for i := 0; i < 3; i++ {
-						if i < len(lines) {
-							fmt.Printf("   %s\n", lines[i])
-						}
-					}
-					fmt.Printf("   ... %d more lines ...\n", len(lines)-3)
-				} else {
-					for _, line := range lines {
-						fmt.Printf("   %s\n", line)
-					}
-				}
-			}
+	fmt.Println("\n== Tool Functionality ==")
+	toolsWorking := true
+	for _, tool := range status.Tools {
+		if !isSharedStatusTool(tool.Command) || !tool.Installed {
+			continue
 		}
 
-		if !anyEnabled {
-			fmt.Println("ℹ️ No components are enabled in the configuration.")
-			fmt.Println("   Edit localenv.yaml to enable components or run 'devhelper-cli localenv init' to create a new config.")
-			return
+		switch {
+		case tool.Working:
+			fmt.Printf("✅ %s: %s\n", tool.Name, tool.Message)
+		case tool.Required:
+			fmt.Printf("❌ %s: %s\n", tool.Name, tool.Message)
+			toolsWorking = false
+		default:
+			fmt.Printf("⚠️ %s: %s\n", tool.Name, tool.Message)
+		}
+		if verbose && tool.Details != "" {
+			fmt.Printf("   Details: %s\n", tool.Details)
 		}
 
-		// Check OpenSearch
-		if configLoaded && config.Components.OpenSearch.Enabled {
-			// First check if OpenSearch service container is running
-			checkCmd := exec.Command("podman", "ps", "--filter", "name=opensearch-node", "--format", "{{.Names}}")
-			output, err := checkCmd.CombinedOutput()
-			opensearchRunning := err == nil && strings.Contains(string(output), "opensearch-node")
-
-			// Check if security is disabled
-			securityDisabled := false
-			if opensearchRunning {
-				inspectCmd := exec.Command("podman", "inspect", "--format", "{{range .Config.Env}}{{.}}{{println}}{{end}}", "opensearch-node")
-				inspectOutput, _ := inspectCmd.CombinedOutput()
-				if strings.Contains(string(inspectOutput), "DISABLE_SECURITY_PLUGIN=true") {
-					securityDisabled = true
-				}
-			}
-
-			if opensearchRunning {
-				fmt.Println("✅ OpenSearch: Running")
-
-				// Attempt to check the health of the OpenSearch service
-				url := fmt.Sprintf("http://localhost:%d", config.Components.OpenSearch.Port)
-
-				client := http.Client{
-					Timeout: 2 * time.Second,
-				}
-
-				var resp *http.Response
-				var httpErr error
-
-				// Send request with or without credentials based on security status
-				if securityDisabled {
-					// No credentials needed when security is disabled
-					resp, httpErr = client.Get(url)
-				} else {
-					// Use credentials when security is enabled
-					req, _ := http.NewRequest("GET", url, nil)
-					resp, httpErr = client.Do(req)
-				}
-
-				if httpErr == nil {
-					defer resp.Body.Close()
-					if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-						fmt.Printf("   API: http://localhost:%d (available)\n", config.Components.OpenSearch.Port)
-					} else {
-						fmt.Printf("   API: http://localhost:%d (unhealthy, status code: %d)\n", config.Components.OpenSearch.Port, resp.StatusCode)
-					}
-				} else {
-					fmt.Printf("   API: http://localhost:%d (unavailable, service may still be starting)\n", config.Components.OpenSearch.Port)
-					if verbose {
-						fmt.Printf("   Error: %v\n", httpErr)
-					}
-				}
-
-				// Check if Dashboard container is running
-				dashCmd := exec.Command("podman", "ps", "--filter", "name=opensearch-dashboard", "--format", "{{.Names}}")
-				dashOutput, dashErr := dashCmd.CombinedOutput()
-				dashboardRunning := dashErr == nil && strings.Contains(string(dashOutput), "opensearch-dashboard")
-
-				if dashboardRunning {
-					fmt.Println("✅ OpenSearch Dashboard: Running")
-					fmt.Printf("   Dashboard: http://localhost:%d (available)\n", config.Components.OpenSearch.DashboardPort)
-				} else {
-					fmt.Println("❌ OpenSearch Dashboard: Not running")
-					fmt.Println("   Run 'devhelper-cli localenv start' to start the OpenSearch Dashboard")
-				}
+		switch {
+		case tool.Command == containerRuntime.Name() && !tool.Working:
+			fmt.Printf("   Make sure %s is installed correctly and has proper permissions.\n", tool.Name)
+		case tool.Command == "kind" && tool.Message != "Clusters configured":
+			fmt.Println("   Note: Kubernetes functionality is not required for local development.")
+		}
+	}
 
-				fmt.Println("   Security plugin disabled - no credentials required for API")
+	if !toolsWorking {
+		fmt.Println("\n❌ Required tools are not working properly.")
+		fmt.Println("Fix the issues above before continuing.")
+		return
+	}
 
-			} else {
-				fmt.Println("❌ OpenSearch: Not running")
-				fmt.Println("   Run 'devhelper-cli localenv start' to start OpenSearch")
-			}
-		} else if configLoaded {
-			fmt.Println("⏹️ OpenSearch: Disabled in configuration")
-		} else {
-			fmt.Println("⚠️ OpenSearch: No configuration found")
-			fmt.Println("   Run 'devhelper-cli localenv init' to create a configuration")
+	// Check enabled services
+	fmt.Println("\n== Enabled Components ==")
+	anyEnabled := false
+	for _, component := range status.Components {
+		if component.Enabled {
+			anyEnabled = true
+			printComponentStatus(component, verbose)
 		}
+	}
 
-		fmt.Println("\n=== Summary ===")
-		if allRunning {
-			fmt.Println("✅ All components are running properly.")
+	if len(status.Apps) > 0 {
+		fmt.Println("\n== Apps ==")
+		for _, app := range status.Apps {
+			printComponentStatus(app, verbose)
+		}
+	}
 
-			// Show concise component information
-			if configLoaded && config.Components.Temporal.Enabled {
-				// Extract Temporal configuration values
-				uiPort := 8233
-				grpcPort := 7233
-				namespace := "default"
+	if status.Error != "" {
+		fmt.Println("\n❌ localenv.yaml could not be loaded, so the components above may not match it.")
+		fmt.Println("Run 'devhelper-cli localenv config validate' to see the problems.")
+		return
+	}
 
-				if config.Components.Temporal.UIPort != 0 {
-					uiPort = config.Components.Temporal.UIPort
-				}
-				if config.Components.Temporal.GRPCPort != 0 {
-					grpcPort = config.Components.Temporal.GRPCPort
-				}
-				if config.Components.Temporal.Namespace != "" {
-					namespace = config.Components.Temporal.Namespace
-				}
+	if !anyEnabled {
+		fmt.Println("ℹ️ No components are enabled in the configuration.")
+		fmt.Println("   Edit localenv.yaml to enable components or run 'devhelper-cli localenv init' to create a new config.")
+		return
+	}
 
-				fmt.Printf("\nTemporal UI: http://localhost:%d\n", uiPort)
-				fmt.Printf("Temporal Server: localhost:%d (namespace: %s)\n", grpcPort, namespace)
-			}
+	fmt.Println("\n=== Summary ===")
+	if !status.Healthy {
+		fmt.Println("⚠️  Some components are not running.")
+		fmt.Println("Run 'devhelper-cli localenv start' to start the environment.")
+		return
+	}
 
-			// Show Dapr connection information if enabled
-			if configLoaded && config.Components.Dapr.Enabled {
-				// Check for Dapr Dashboard
-				if isDaprDashboardAvailable() {
-					dashboardURL := getDaprDashboardURL(configLoaded, config)
-					if isDaprDashboardAccessible(dashboardURL) {
-						fmt.Printf("\nDapr Dashboard: %s\n", dashboardURL)
-					} else {
-						fmt.Printf("\nDapr Dashboard: %s (Not accessible)\n", dashboardURL)
-						fmt.Println("   If the dashboard is running but not accessible on this port,")
-						fmt.Println("   update the dashboardPort in localenv.yaml and restart the environment.")
-					}
-				}
+	fmt.Println("✅ All components are running properly.")
 
-				// Show Zipkin URL for tracing
-				zipkinURL := getZipkinURL(configLoaded, config)
-				fmt.Printf("Zipkin UI (tracing): %s\n", zipkinURL)
-
-				// Check if Dapr containers are running using podman
-				cmd := exec.Command("podman", "ps", "--format", "{{.Names}}", "--filter", "name=dapr_")
-				output, err := cmd.CombinedOutput()
-				if err == nil && len(output) > 0 {
-					fmt.Println("\nDapr Services:")
-					containers := strings.Split(strings.TrimSpace(string(output)), "\n")
-					for _, container := range containers {
-						if container == "" {
-							continue
-						}
-						fmt.Printf("- %s\n", container)
-					}
-
-					// Check if Dapr Dashboard process is running
-					dashboardPID := getDaprDashboardPID()
-					if dashboardPID != "" {
-						fmt.Println("- dapr_dashboard")
-					}
-				} else {
-					// If we couldn't find containers but the dashboard is running, still show it
-					dashboardPID := getDaprDashboardPID()
-					if dashboardPID != "" {
-						fmt.Println("\nDapr Services:")
-						fmt.Println("- dapr_dashboard")
-					}
-				}
-			}
+	// Show concise connection information for each component
+	for _, component := range status.Components {
+		if len(component.Endpoints) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", component.Name)
+		for _, endpoint := range component.Endpoints {
+			fmt.Printf("- %s: %s\n", endpoint.Name, endpoint.URL)
+		}
+	}
+}
 
-			// Show OpenSearch information if enabled and running
-			if configLoaded && config.Components.OpenSearch.Enabled {
-				// First check if OpenSearch service container is running
-				checkCmd := exec.Command("podman", "ps", "--filter", "name=opensearch-node", "--format", "{{.Names}}")
-				output, err := checkCmd.CombinedOutput()
-				opensearchRunning := err == nil && strings.Contains(string(output), "opensearch-node")
-
-				// Check if security is disabled
-				securityDisabled := false
-				if opensearchRunning {
-					inspectCmd := exec.Command("podman", "inspect", "--format", "{{range .Config.Env}}{{.}}{{println}}{{end}}", "opensearch-node")
-					inspectOutput, _ := inspectCmd.CombinedOutput()
-					if strings.Contains(string(inspectOutput), "DISABLE_SECURITY_PLUGIN=true") {
-						securityDisabled = true
-					}
-				}
+// isSharedStatusTool reports whether a tool is listed with its functionality
+// rather than through the components that need it
+func isSharedStatusTool(command string) bool {
+	for _, tool := range statusTools(LocalEnvConfig{}) {
+		if tool.Command == command {
+			return tool.Shared
+		}
+	}
+	return false
+}
 
-				// Then check if Dashboard container is running
-				dashCmd := exec.Command("podman", "ps", "--filter", "name=opensearch-dashboard", "--format", "{{.Names}}")
-				dashOutput, dashErr := dashCmd.CombinedOutput()
-				dashboardRunning := dashErr == nil && strings.Contains(string(dashOutput), "opensearch-dashboard")
-
-				if opensearchRunning {
-					// Try to check if the API is accessible
-					client := http.Client{
-						Timeout: 2 * time.Second,
-					}
-
-					var resp *http.Response
-					var httpErr error
-
-					// Send request with or without credentials based on security status
-					if securityDisabled {
-						// No credentials needed when security is disabled
-						resp, httpErr = client.Get(fmt.Sprintf("http://localhost:%d", config.Components.OpenSearch.Port))
-					} else {
-						// Use credentials when security is enabled
-						req, _ := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d", config.Components.OpenSearch.Port), nil)
-						resp, httpErr = client.Do(req)
-					}
-
-					apiAccessible := false
-					if httpErr == nil {
-						defer resp.Body.Close()
-						if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-							apiAccessible = true
-						}
-					}
-
-					// Display OpenSearch information
-					fmt.Println("\nOpenSearch:")
-					fmt.Printf("- API: http://localhost:%d", config.Components.OpenSearch.Port)
-					if apiAccessible {
-						fmt.Println(" (accessible)")
-					} else {
-						fmt.Println(" (not verified)")
-					}
-
-					if dashboardRunning {
-						fmt.Printf("- Dashboard: http://localhost:%d (running)\n", config.Components.OpenSearch.DashboardPort)
-					} else {
-						fmt.Printf("- Dashboard: http://localhost:%d (not running)\n", config.Components.OpenSearch.DashboardPort)
-					}
-
-					fmt.Println("- Security plugin disabled - no credentials required for API")
+// printComponentStatus renders a component status in the status command format
+func printComponentStatus(status ComponentStatus, verbose bool) {
+	if status.Running {
+		fmt.Printf("✅ %s: %s\n", status.Name, status.Message)
+	} else {
+		fmt.Printf("❌ %s: %s\n", status.Name, status.Message)
+	}
 
-				}
-			}
+	for _, endpoint := range status.Endpoints {
+		if endpoint.Accessible {
+			fmt.Printf("   %s: %s (Accessible)\n", endpoint.Name, endpoint.URL)
 		} else {
-			fmt.Println("⚠️  Some components are not running.")
-			fmt.Println("Run 'devhelper-cli localenv start' to start the environment.")
+			fmt.Printf("   %s: %s (Not accessible yet, may still be starting up)\n", endpoint.Name, endpoint.URL)
 		}
-	},
+	}
+	if len(status.Containers) > 0 {
+		fmt.Printf("   Containers: %s\n", strings.Join(status.Containers, ", "))
+	}
+	if verbose && len(status.PIDs) > 0 {
+		pids := []string{}
+		for _, pid := range status.PIDs {
+			pids = append(pids, strconv.Itoa(pid))
+		}
+		fmt.Printf("   PIDs: %s\n", strings.Join(pids, ", "))
+	}
+	for _, detail := range status.Details {
+		fmt.Printf("   %s\n", detail)
+	}
 }
 
 // Helper function to check if a tool is working properly
@@ -567,7 +500,8 @@ func getTemporalNamespaceArgs(configLoaded bool, config LocalEnvConfig) []string
 	if configLoaded && config.Components.Temporal.Namespace != "" {
 		namespace = config.Components.Temporal.Namespace
 	}
-	return []string{"operator", "namespace", "describe", namespace}
+	_, grpcPort := temporalPorts(&LocalEnv{Config: config})
+	return []string{"operator", "namespace", "describe", "--address", fmt.Sprintf("localhost:%d", grpcPort), namespace}
 }
 
 // Helper function to get Temporal UI URL
@@ -589,50 +523,41 @@ func isDaprDashboardAvailable() bool {
 	return err == nil
 }
 
-// Helper function to get Dapr Dashboard URL
-func getDaprDashboardURL(configLoaded bool, config LocalEnvConfig) string {
-	dashboardPort := 8080
-
+// Helper function to get the Dapr Dashboard port
+func getDaprDashboardPort(configLoaded bool, config LocalEnvConfig) int {
 	if configLoaded && config.Components.Dapr.DashboardPort != 0 {
-		dashboardPort = config.Components.Dapr.DashboardPort
+		return config.Components.Dapr.DashboardPort
 	}
+	return 8080
+}
 
+// Helper function to get Dapr Dashboard URL
+func getDaprDashboardURL(configLoaded bool, config LocalEnvConfig) string {
 	// Simply return the configured URL
-	return fmt.Sprintf("http://localhost:%d", dashboardPort)
+	return fmt.Sprintf("http://localhost:%d", getDaprDashboardPort(configLoaded, config))
 }
 
 // Helper function to check if Dapr Dashboard is running and get its PID
 func getDaprDashboardPID() string {
-	// First try to find the process using ps command
-	cmd := exec.Command("ps", "-ef")
-	output, err := cmd.CombinedOutput()
-	if err == nil {
-		outputLines := strings.Split(string(output), "\n")
-		for _, line := range outputLines {
-			if strings.Contains(line, "dapr dashboard") && !strings.Contains(line, "grep") {
-				fields := strings.Fields(line)
-				if len(fields) >= 2 {
-					return fields[1] // Return PID
-				}
-			}
-		}
+	// First look for the process by its command line
+	if processes, err := prober.FindProcesses("dapr dashboard"); err == nil && len(processes) > 0 {
+		return strconv.Itoa(processes[0].PID)
 	}
 
-	// If we can't find it with ps, try a different approach using lsof
-	// Look for the 'dashboard' process on common Dapr Dashboard ports
-	commonPorts := []string{"8080", "8081", "8082", "8083", "8084", "8085"}
-
-	for _, port := range commonPorts {
-		cmd = exec.Command("lsof", "-i", ":"+port, "-sTCP:LISTEN")
-		output, err = cmd.CombinedOutput()
-		if err == nil && len(output) > 0 {
-			outputLines := strings.Split(string(output), "\n")
-			for _, line := range outputLines {
-				if strings.HasPrefix(line, "dashboard") {
-					fields := strings.Fields(line)
-					if len(fields) >= 2 {
-						return fields[1] // Return PID
-					}
+	// Otherwise look for a 'dashboard' process on common Dapr Dashboard ports
+	dashboards, err := prober.FindProcesses("dashboard")
+	if err != nil {
+		return ""
+	}
+	for port := 8080; port <= 8085; port++ {
+		pids, err := prober.ListeningPIDs(port)
+		if err != nil {
+			continue
+		}
+		for _, pid := range pids {
+			for _, process := range dashboards {
+				if process.PID == pid {
+					return strconv.Itoa(pid)
 				}
 			}
 		}
@@ -643,9 +568,7 @@ func getDaprDashboardPID() string {
 
 // Helper function to check if a specific port is in use
 func isPortInUse(port int) bool {
-	cmd := exec.Command("lsof", "-i", ":"+strconv.Itoa(port), "-sTCP:LISTEN")
-	output, err := cmd.CombinedOutput()
-	return err == nil && len(output) > 0
+	return prober.PortInUse(port)
 }
 
 // Helper function to check if Dapr Dashboard is accessible
@@ -670,18 +593,22 @@ func getDaprWebUIURL(configLoaded bool, config LocalEnvConfig) string {
 	return getDaprDashboardURL(configLoaded, config)
 }
 
-// Helper function to get Zipkin URL
-func getZipkinURL(configLoaded bool, config LocalEnvConfig) string {
-	zipkinPort := 9411
-
+// Helper function to get the Zipkin port
+func getZipkinPort(configLoaded bool, config LocalEnvConfig) int {
 	if configLoaded && config.Components.Dapr.ZipkinPort != 0 {
-		zipkinPort = config.Components.Dapr.ZipkinPort
+		return config.Components.Dapr.ZipkinPort
 	}
+	return 9411
+}
 
-	return fmt.Sprintf("http://localhost:%d", zipkinPort)
+// Helper function to get Zipkin URL
+func getZipkinURL(configLoaded bool, config LocalEnvConfig) string {
+	return fmt.Sprintf("http://localhost:%d", getZipkinPort(configLoaded, config))
 }
 
 func init() {
 	localenvCmd.AddCommand(localenvStatusCmd)
 	localenvStatusCmd.Flags().StringP("config", "c", "", "Path to environment configuration file (default: localenv.yaml)")
+	localenvStatusCmd.Flags().StringP("output", "o", "text", "Output format: text, json or yaml")
+	localenvStatusCmd.Flags().Bool("aggressive", false, "Also look up component processes not started by localenv by name")
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/lirtsman/devhelper-cli/internal/test"
@@ -86,12 +88,13 @@ func TestStatusHelperFunctions(t *testing.T) {
 		// Test with default namespace (not loaded config)
 		config := LocalEnvConfig{}
 		result := getTemporalNamespaceArgs(false, config)
-		assert.Equal(t, []string{"operator", "namespace", "describe", "default"}, result, "Default namespace should be used")
+		assert.Equal(t, []string{"operator", "namespace", "describe", "--address", "localhost:7233", "default"}, result, "Default namespace should be used")
 
-		// Test with custom namespace in config
+		// Test with custom namespace and port in config
 		config.Components.Temporal.Namespace = "customns"
+		config.Components.Temporal.GRPCPort = 17233
 		result = getTemporalNamespaceArgs(true, config)
-		assert.Equal(t, []string{"operator", "namespace", "describe", "customns"}, result, "Custom namespace should be used")
+		assert.Equal(t, []string{"operator", "namespace", "describe", "--address", "localhost:17233", "customns"}, result, "Custom namespace and port should be used")
 	})
 
 	t.Run("getTemporalUIURL should construct URL correctly", func(t *testing.T) {
@@ -168,3 +171,94 @@ func TestStatusHelperFunctions(t *testing.T) {
 		assert.True(t, result, "With config and Dapr enabled, should be enabled")
 	})
 }
+
+// TestEnvironmentStatus tests the machine-readable status model
+func TestEnvironmentStatus(t *testing.T) {
+	origCommandCheck := isCommandAvailable
+	defer func() { isCommandAvailable = origCommandCheck }()
+
+	t.Run("Status command should have an output flag", func(t *testing.T) {
+		outputFlag := localenvStatusCmd.Flags().Lookup("output")
+		assert.NotNil(t, outputFlag, "output flag should exist")
+		assert.Equal(t, "o", outputFlag.Shorthand, "output flag should have shorthand o")
+		assert.Equal(t, "text", outputFlag.DefValue, "output flag should default to text")
+	})
+
+	t.Run("Missing tools should make the environment unhealthy", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{}}
+		env.Config.Components.Temporal.Enabled = true
+
+		status := collectEnvironmentStatus(env, "localenv.yaml")
+		assert.Equal(t, statusSchemaVersion, status.SchemaVersion)
+		assert.False(t, status.Healthy, "environment should not be healthy")
+
+		required := map[string]bool{}
+		for _, tool := range status.Tools {
+			assert.False(t, tool.Installed, "%s should not be installed", tool.Name)
+			required[tool.Name] = tool.Required
+		}
+		assert.Equal(t, map[string]bool{"Podman": false, "Kind": false, "Dapr": false, "Temporal": true}, required, "only the tools of enabled components should be required")
+
+		assert.Len(t, status.Components, len(registeredComponents()), "every component should be reported")
+		for _, component := range status.Components {
+			if component.Name == "Temporal" {
+				assert.True(t, component.Enabled)
+				assert.Equal(t, "Not installed", component.Message)
+			} else {
+				assert.False(t, component.Enabled, "%s should be disabled", component.Name)
+			}
+		}
+	})
+
+	t.Run("Container components should require the container runtime", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{}}
+		env.Config.Components.Custom = map[string]CustomComponentConfig{"cache": {Enabled: true, Image: "redis:7"}}
+
+		required := map[string]bool{}
+		for _, tool := range collectEnvironmentStatus(env, "localenv.yaml").Tools {
+			required[tool.Name] = tool.Required
+		}
+		assert.Equal(t, map[string]bool{"Podman": true, "Kind": false, "Dapr": false, "Temporal": false}, required)
+	})
+
+	t.Run("Configuration errors should be reported", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+
+		env := &LocalEnv{Skip: map[string]bool{}}
+		status := withConfigError(collectEnvironmentStatus(env, "localenv.yaml"), errors.New("localenv.yaml:3:5: unknown field"))
+		assert.False(t, status.Healthy, "an unusable configuration should not be healthy")
+
+		data, err := json.Marshal(status)
+		assert.NoError(t, err)
+		var decoded map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, "localenv.yaml:3:5: unknown field", decoded["error"])
+
+		ok := withConfigError(collectEnvironmentStatus(env, "localenv.yaml"), nil)
+		assert.Empty(t, ok.Error)
+	})
+
+	t.Run("JSON output should use a stable schema", func(t *testing.T) {
+		isCommandAvailable = test.CommandExistsMock(map[string]bool{})
+
+		env := &LocalEnv{ConfigLoaded: true, Skip: map[string]bool{}}
+		data, err := json.Marshal(collectEnvironmentStatus(env, "localenv.yaml"))
+		assert.NoError(t, err)
+
+		var decoded map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		for _, key := range []string{"schemaVersion", "configFile", "configLoaded", "healthy", "tools", "components"} {
+			assert.Contains(t, decoded, key)
+		}
+
+		component := decoded["components"].([]interface{})[0].(map[string]interface{})
+		for _, key := range []string{"name", "enabled", "running", "healthy", "message", "pids", "containers", "ports", "endpoints", "details"} {
+			assert.Contains(t, component, key)
+			assert.NotNil(t, component[key], "%s should not be null", key)
+		}
+	})
+}
@@ -83,6 +83,10 @@ func TestLocalenvStopCommand(t *testing.T) {
 		skipDaprDashboardFlag := stopCmd.Flags().Lookup("skip-dapr-dashboard")
 		assert.NotNil(t, skipDaprDashboardFlag, "skip-dapr-dashboard flag should exist")
 		assert.Equal(t, "false", skipDaprDashboardFlag.DefValue, "skip-dapr-dashboard flag should default to false")
+
+		aggressiveFlag := stopCmd.Flags().Lookup("aggressive")
+		assert.NotNil(t, aggressiveFlag, "aggressive flag should exist")
+		assert.Equal(t, "false", aggressiveFlag.DefValue, "aggressive flag should default to false")
 	})
 
 	t.Run("Stop command should honor skip-dapr-dashboard flag", func(t *testing.T) {
@@ -137,7 +137,7 @@ func TestLocalenvCommands(t *testing.T) {
 		for _, cmd := range localenvCmd.Commands() {
 			if _, ok := foundCmds[cmd.Use]; ok {
 				foundCmds[cmd.Use] = true
-			} else if cmd.Use == "logs [component]" {
+			} else if cmd.Use == "logs [component...]" {
 				// Special case for logs command which has a more complex Use pattern
 				foundCmds["logs"] = true
 			}
@@ -0,0 +1,140 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// maxBody is how much of a response body is matched against HTTP.Body
+const maxBody = 1 << 20
+
+// HTTP checks that a GET request to URL succeeds
+type HTTP struct {
+	URL string
+	// Status reports whether the response status means ready. By default
+	// any status below 400 does.
+	Status func(code int) bool
+	// Body, if set, must match the start of the response body
+	Body *regexp.Regexp
+}
+
+// AnyStatus accepts every response, for servers that are ready as soon as they answer
+func AnyStatus(int) bool { return true }
+
+// StatusRange accepts statuses from low to high inclusive
+func StatusRange(low, high int) func(int) bool {
+	return func(code int) bool { return code >= low && code <= high }
+}
+
+func (h HTTP) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return Permanent(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	status := h.Status
+	if status == nil {
+		status = StatusRange(100, 399)
+	}
+	if !status(resp.StatusCode) {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if h.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+		if err != nil {
+			return err
+		}
+		if !h.Body.Match(body) {
+			return fmt.Errorf("response doesn't match %s: %s", h.Body, bytes.TrimSpace(body))
+		}
+	}
+	return nil
+}
+
+func (h HTTP) String() string { return "GET " + h.URL }
+
+// TCP checks that a connection to Address ("host:port") can be opened
+type TCP struct {
+	Address string
+}
+
+func (t TCP) Check(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (t TCP) String() string { return "tcp://" + t.Address }
+
+// Command checks that a command exits with code 0
+type Command struct {
+	Name string
+	Args []string
+}
+
+func (c Command) Check(ctx context.Context) error {
+	output, err := exec.CommandContext(ctx, c.Name, c.Args...).CombinedOutput()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return Permanent(err)
+	case errors.As(err, &exitErr) && len(bytes.TrimSpace(output)) > 0:
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return err
+}
+
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// ContainerState is what the Container probe needs to know about a container
+type ContainerState struct {
+	State    string // "created", "running", "exited", ...
+	Running  bool
+	Health   string // "starting", "healthy" or "unhealthy", empty without a health check
+	ExitCode int
+}
+
+// Container checks that a container is running and, if it has a health
+// check, healthy. A container that exited fails permanently.
+type Container struct {
+	Name    string
+	Inspect func(ctx context.Context, name string) (ContainerState, error)
+	// IgnoreHealth only requires the container to run, for containers whose
+	// health check runs too rarely to wait on
+	IgnoreHealth bool
+}
+
+func (c Container) Check(ctx context.Context) error {
+	state, err := c.Inspect(ctx, c.Name)
+	switch {
+	case err != nil:
+		return err
+	case state.State == "exited" || state.State == "dead":
+		return Permanent(fmt.Errorf("container exited with code %d", state.ExitCode))
+	case !state.Running:
+		return fmt.Errorf("container is %s", state.State)
+	case !c.IgnoreHealth && state.Health != "" && state.Health != "healthy":
+		return fmt.Errorf("container is %s", state.Health)
+	}
+	return nil
+}
+
+func (c Container) String() string { return "container " + c.Name }
@@ -0,0 +1,149 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http2"
+)
+
+// grpcHealthPath is the method of the standard gRPC health checking protocol
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// grpcServing is the SERVING value of HealthCheckResponse.ServingStatus
+const grpcServing = 1
+
+// grpcStatusNames names the serving statuses of the health checking protocol
+var grpcStatusNames = map[uint64]string{0: "UNKNOWN", 1: "SERVING", 2: "NOT_SERVING", 3: "SERVICE_UNKNOWN"}
+
+// grpcClient speaks HTTP/2 without TLS (h2c), as local gRPC servers do
+var grpcClient = &http.Client{Transport: &http2.Transport{
+	AllowHTTP: true,
+	DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	},
+}}
+
+// GRPC checks that a gRPC server at Address ("host:port") reports Service
+// as SERVING through the standard health service. An empty Service checks
+// the server as a whole.
+type GRPC struct {
+	Address string
+	Service string
+}
+
+func (g GRPC) Check(ctx context.Context) error {
+	endpoint := url.URL{Scheme: "http", Host: g.Address, Path: grpcHealthPath}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(grpcFrame(g.request())))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := grpcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status code %d", resp.StatusCode)
+	}
+
+	// The body must be read to the end for the trailers to arrive
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return err
+	}
+	// Errors without a response body are sent in the headers
+	code, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if code != "0" {
+		return fmt.Errorf("grpc status %s: %s", code, message)
+	}
+
+	status, err := grpcServingStatus(body)
+	if err != nil {
+		return err
+	}
+	if status != grpcServing {
+		return fmt.Errorf("service is %s", grpcStatusNames[status])
+	}
+	return nil
+}
+
+func (g GRPC) String() string {
+	if g.Service == "" {
+		return "grpc health " + g.Address
+	}
+	return "grpc health " + g.Address + " " + g.Service
+}
+
+// request encodes the HealthCheckRequest message, whose only field is the service name
+func (g GRPC) request() []byte {
+	if g.Service == "" {
+		return nil
+	}
+	message := []byte{1<<3 | 2} // Field 1, length-delimited
+	message = binary.AppendUvarint(message, uint64(len(g.Service)))
+	return append(message, g.Service...)
+}
+
+// grpcFrame prefixes an uncompressed message with its length
+func grpcFrame(message []byte) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// grpcServingStatus decodes the status field of a framed HealthCheckResponse
+func grpcServingStatus(body []byte) (uint64, error) {
+	if len(body) < 5 || body[0] != 0 {
+		return 0, errors.New("malformed health check response")
+	}
+	size := uint64(binary.BigEndian.Uint32(body[1:5]))
+	if uint64(len(body)-5) < size {
+		return 0, errors.New("truncated health check response")
+	}
+	message := body[5 : 5+size]
+
+	var status uint64
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, errors.New("malformed health check response")
+		}
+		message = message[n:]
+		switch key & 7 {
+		case 0: // Varint
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, errors.New("malformed health check response")
+			}
+			if key>>3 == 1 {
+				status = value
+			}
+			message = message[n:]
+		case 2: // Length-delimited, skipped
+			size, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < size {
+				return 0, errors.New("malformed health check response")
+			}
+			message = message[n+int(size):]
+		default:
+			return 0, fmt.Errorf("unexpected wire type %d in health check response", key&7)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,177 @@
+// Package probe tells when a service is ready by checking it repeatedly.
+//
+// A Probe checks readiness once, for example by requesting an HTTP endpoint,
+// connecting to a TCP port, calling the gRPC health service, running a
+// command or inspecting a container. Wait repeats the check with exponential
+// backoff until it passes, the overall deadline expires or the context is
+// cancelled.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Probe checks once whether a service is ready
+type Probe interface {
+	// Check returns nil if the service is ready. Errors wrapped with
+	// Permanent stop Wait from trying again.
+	Check(ctx context.Context) error
+	// String describes what is checked, e.g. "GET http://localhost:9200"
+	String() string
+}
+
+// ErrNotReady is returned by Wait when the deadline expires before the probe passes
+var ErrNotReady = errors.New("not ready")
+
+// Options controls how Wait repeats a probe
+type Options struct {
+	// Timeout is the overall deadline, or 0 to wait until the context is done
+	Timeout time.Duration
+	// Interval is the delay after the first failed check (default 500ms)
+	Interval time.Duration
+	// MaxInterval caps the delay between checks (default 5s)
+	MaxInterval time.Duration
+	// Multiplier grows the delay after each failed check (default 2, 1 for a fixed interval)
+	Multiplier float64
+	// AttemptTimeout limits a single check (default 10s)
+	AttemptTimeout time.Duration
+	// OnFailure is called with the error of every failed check that will be retried
+	OnFailure func(attempt int, err error)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = 500 * time.Millisecond
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 5 * time.Second
+	}
+	o.MaxInterval = max(o.MaxInterval, o.Interval)
+	if o.Multiplier < 1 {
+		o.Multiplier = 2
+	}
+	if o.AttemptTimeout <= 0 {
+		o.AttemptTimeout = 10 * time.Second
+	}
+	return o
+}
+
+// Wait checks p until it passes. It returns an error wrapping ErrNotReady and
+// the last failure when the timeout expires, the permanent error that stopped
+// it, or the context error if ctx is done first.
+func Wait(ctx context.Context, p Probe, opts Options) error {
+	opts = opts.withDefaults()
+	deadline := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		deadline, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	delay := opts.Interval
+	for attempt := 1; ; attempt++ {
+		err := check(deadline, p, opts.AttemptTimeout)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return fmt.Errorf("%s: %w", p, permanent.err)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", p, ctx.Err())
+		}
+		if deadline.Err() != nil {
+			return fmt.Errorf("%s: %w after %s: %w", p, ErrNotReady, opts.Timeout, err)
+		}
+		if opts.OnFailure != nil {
+			opts.OnFailure(attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-deadline.Done():
+			timer.Stop()
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s: %w", p, ctx.Err())
+			}
+			return fmt.Errorf("%s: %w after %s: %w", p, ErrNotReady, opts.Timeout, err)
+		case <-timer.C:
+		}
+		delay = min(time.Duration(float64(delay)*opts.Multiplier), opts.MaxInterval)
+	}
+}
+
+// check runs a single check limited to timeout
+func check(ctx context.Context, p Probe, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return p.Check(ctx)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure that retrying won't fix, such as a
+// process or container that exited
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type funcProbe struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (f funcProbe) Check(ctx context.Context) error { return f.check(ctx) }
+
+func (f funcProbe) String() string { return f.name }
+
+// Func returns a probe running check, described by name
+func Func(name string, check func(ctx context.Context) error) Probe {
+	return funcProbe{name: name, check: check}
+}
+
+type allProbe []Probe
+
+func (a allProbe) Check(ctx context.Context) error {
+	for _, p := range a {
+		err := p.Check(ctx)
+		var permanent *permanentError
+		switch {
+		case errors.As(err, &permanent):
+			return Permanent(fmt.Errorf("%s: %w", p, permanent.err))
+		case err != nil:
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (a allProbe) String() string {
+	names := make([]string, len(a))
+	for i, p := range a {
+		names[i] = p.String()
+	}
+	return strings.Join(names, " and ")
+}
+
+// All returns a probe that passes when every probe passes, checking them in order
+func All(probes ...Probe) Probe {
+	if len(probes) == 1 {
+		return probes[0]
+	}
+	return allProbe(probes)
+}
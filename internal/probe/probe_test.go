@@ -0,0 +1,240 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// fastOptions retries quickly so tests don't wait for the default backoff
+var fastOptions = Options{Timeout: 2 * time.Second, Interval: time.Millisecond, MaxInterval: 10 * time.Millisecond}
+
+func TestWait(t *testing.T) {
+	t.Run("Wait should retry until the probe passes", func(t *testing.T) {
+		var attempts, failures int
+		p := Func("flaky", func(context.Context) error {
+			if attempts++; attempts < 3 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		opts := fastOptions
+		opts.OnFailure = func(attempt int, err error) { failures = attempt }
+
+		assert.NoError(t, Wait(context.Background(), p, opts))
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, 2, failures)
+	})
+
+	t.Run("Wait should give up at the deadline with the last failure", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := Wait(context.Background(), Func("never", func(context.Context) error { return cause }),
+			Options{Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond})
+		assert.ErrorIs(t, err, ErrNotReady)
+		assert.ErrorIs(t, err, cause)
+		assert.Contains(t, err.Error(), "never")
+	})
+
+	t.Run("Wait should stop when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		err := Wait(ctx, Func("never", func(context.Context) error { return errors.New("down") }), Options{Interval: 5 * time.Millisecond})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, ErrNotReady)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Permanent errors should stop retrying", func(t *testing.T) {
+		attempts := 0
+		exited := errors.New("exited with code 1")
+		p := All(
+			Func("process", func(context.Context) error {
+				attempts++
+				return Permanent(exited)
+			}),
+			Func("port", func(context.Context) error { return nil }),
+		)
+		err := Wait(context.Background(), p, fastOptions)
+		assert.ErrorIs(t, err, exited)
+		assert.Contains(t, err.Error(), "process: exited with code 1")
+		assert.Equal(t, 1, attempts)
+		assert.Nil(t, Permanent(nil))
+	})
+
+	t.Run("Backoff should grow up to the maximum interval", func(t *testing.T) {
+		var times []time.Time
+		p := Func("slow", func(context.Context) error {
+			times = append(times, time.Now())
+			if len(times) < 5 {
+				return errors.New("not yet")
+			}
+			return nil
+		})
+		require.NoError(t, Wait(context.Background(), p, Options{Interval: 10 * time.Millisecond, MaxInterval: 40 * time.Millisecond}))
+		assert.GreaterOrEqual(t, times[2].Sub(times[1]), 20*time.Millisecond)
+		assert.GreaterOrEqual(t, times[4].Sub(times[3]), 40*time.Millisecond)
+		assert.Less(t, times[4].Sub(times[3]), 500*time.Millisecond)
+	})
+
+	t.Run("Each attempt should be limited by the attempt timeout", func(t *testing.T) {
+		err := Wait(context.Background(), Func("hanging", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), Options{Timeout: time.Second, AttemptTimeout: 10 * time.Millisecond, Interval: time.Millisecond, OnFailure: func(attempt int, err error) {
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}})
+		assert.ErrorIs(t, err, ErrNotReady)
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		fmt.Fprint(w, `{"cluster_name":"opensearch","status":"yellow"}`)
+	}))
+	defer server.Close()
+
+	t.Run("HTTP should check the status code", func(t *testing.T) {
+		p := HTTP{URL: server.URL}
+		assert.ErrorContains(t, p.Check(context.Background()), "503")
+		status.Store(http.StatusOK)
+		assert.NoError(t, p.Check(context.Background()))
+
+		status.Store(http.StatusNotFound)
+		assert.Error(t, p.Check(context.Background()))
+		assert.NoError(t, HTTP{URL: server.URL, Status: AnyStatus}.Check(context.Background()))
+		assert.Error(t, HTTP{URL: server.URL, Status: StatusRange(200, 299)}.Check(context.Background()))
+	})
+
+	t.Run("HTTP should match the body", func(t *testing.T) {
+		status.Store(http.StatusOK)
+		assert.NoError(t, HTTP{URL: server.URL, Body: regexp.MustCompile(`"status":"(green|yellow)"`)}.Check(context.Background()))
+		assert.ErrorContains(t, HTTP{URL: server.URL, Body: regexp.MustCompile(`"status":"green"`)}.Check(context.Background()), "doesn't match")
+	})
+
+	t.Run("HTTP should fail when nothing listens", func(t *testing.T) {
+		assert.Error(t, HTTP{URL: "http://" + closedAddress(t)}.Check(context.Background()))
+	})
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	assert.NoError(t, TCP{Address: listener.Addr().String()}.Check(context.Background()))
+	assert.Error(t, TCP{Address: closedAddress(t)}.Check(context.Background()))
+	assert.Equal(t, "tcp://"+listener.Addr().String(), TCP{Address: listener.Addr().String()}.String())
+}
+
+func TestCommand(t *testing.T) {
+	assert.NoError(t, Command{Name: "sh", Args: []string{"-c", "exit 0"}}.Check(context.Background()))
+
+	err := Command{Name: "sh", Args: []string{"-c", "echo not ready; exit 3"}}.Check(context.Background())
+	assert.ErrorContains(t, err, "exit status 3: not ready")
+
+	err = Wait(context.Background(), Command{Name: "non-existent-command"}, fastOptions)
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.NotErrorIs(t, err, ErrNotReady, "a missing command should not be retried")
+}
+
+func TestContainer(t *testing.T) {
+	state := ContainerState{State: "created"}
+	p := Container{Name: "opensearch-node", Inspect: func(_ context.Context, name string) (ContainerState, error) {
+		if name != "opensearch-node" {
+			return ContainerState{}, errors.New("no such container")
+		}
+		return state, nil
+	}}
+
+	assert.ErrorContains(t, p.Check(context.Background()), "container is created")
+	state = ContainerState{State: "running", Running: true, Health: "starting"}
+	assert.ErrorContains(t, p.Check(context.Background()), "container is starting")
+	assert.NoError(t, Container{Name: p.Name, Inspect: p.Inspect, IgnoreHealth: true}.Check(context.Background()))
+	state.Health = "healthy"
+	assert.NoError(t, p.Check(context.Background()))
+	state = ContainerState{State: "running", Running: true}
+	assert.NoError(t, p.Check(context.Background()))
+
+	state = ContainerState{State: "exited", ExitCode: 137}
+	err := Wait(context.Background(), p, fastOptions)
+	assert.ErrorContains(t, err, "exited with code 137")
+	assert.NotErrorIs(t, err, ErrNotReady)
+
+	assert.Error(t, Container{Name: "missing", Inspect: p.Inspect}.Check(context.Background()))
+}
+
+func TestGRPC(t *testing.T) {
+	statuses := map[string]byte{"": grpcServing, "temporal.api.workflowservice.v1.WorkflowService": 2}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthPath || r.Header.Get("Content-Type") != "application/grpc" {
+			w.Header().Set("Grpc-Status", "12")
+			return
+		}
+		body := make([]byte, 1024)
+		n, _ := r.Body.Read(body)
+		request := body[5:n]
+		service := ""
+		if len(request) > 2 {
+			service = string(request[2:])
+		}
+		status, ok := statuses[service]
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write(grpcFrame([]byte{1 << 3, status}))
+		w.Header().Set("Grpc-Status", "0")
+	})
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	t.Run("A serving server should pass", func(t *testing.T) {
+		assert.NoError(t, GRPC{Address: address}.Check(context.Background()))
+	})
+
+	t.Run("A service that isn't serving should fail", func(t *testing.T) {
+		err := GRPC{Address: address, Service: "temporal.api.workflowservice.v1.WorkflowService"}.Check(context.Background())
+		assert.ErrorContains(t, err, "NOT_SERVING")
+	})
+
+	t.Run("Status errors should be reported", func(t *testing.T) {
+		err := GRPC{Address: address, Service: "unknown"}.Check(context.Background())
+		assert.ErrorContains(t, err, "grpc status 5: unknown service")
+	})
+
+	t.Run("A server that isn't listening should fail", func(t *testing.T) {
+		assert.Error(t, GRPC{Address: closedAddress(t)}.Check(context.Background()))
+	})
+}
+
+// closedAddress returns the address of a port nothing listens on
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
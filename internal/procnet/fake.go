@@ -0,0 +1,42 @@
+package procnet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fake is a Prober with a fixed set of listening ports and processes, for tests
+type Fake struct {
+	// Listening maps ports to the PIDs listening on them
+	Listening map[int][]int
+	Processes []Process
+	// Starts maps PIDs to their start times
+	Starts map[int]string
+}
+
+func (f *Fake) PortInUse(port int) bool {
+	_, ok := f.Listening[port]
+	return ok
+}
+
+func (f *Fake) ListeningPIDs(port int) ([]int, error) {
+	return append([]int{}, f.Listening[port]...), nil
+}
+
+func (f *Fake) FindProcesses(pattern string) ([]Process, error) {
+	processes := []Process{}
+	for _, process := range f.Processes {
+		if strings.Contains(process.Cmdline, pattern) {
+			processes = append(processes, process)
+		}
+	}
+	return processes, nil
+}
+
+func (f *Fake) ProcessStart(pid int) (string, error) {
+	started, ok := f.Starts[pid]
+	if !ok {
+		return "", fmt.Errorf("no process with PID %d", pid)
+	}
+	return started, nil
+}
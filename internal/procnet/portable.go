@@ -0,0 +1,85 @@
+package procnet
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// portable implements Prober on platforms without /proc. Port checks are
+// done natively; process lookups use ps and lsof when they are installed.
+type portable struct{}
+
+func (portable) PortInUse(port int) bool {
+	if !validPort(port) {
+		return false
+	}
+	return dialPort(port) || bindPort(port)
+}
+
+func (portable) ListeningPIDs(port int) ([]int, error) {
+	if !validPort(port) {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	if _, err := exec.LookPath("lsof"); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	// lsof exits non-zero when nothing matches
+	output, _ := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-t").Output()
+	pids := []int{}
+	for _, field := range strings.Fields(string(output)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+func (portable) FindProcesses(pattern string) ([]Process, error) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	output, err := exec.Command("ps", "-axo", "pid=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePS(string(output), pattern, os.Getpid()), nil
+}
+
+func (portable) ProcessStart(pid int) (string, error) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		return "", ErrUnsupported
+	}
+
+	output, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	started := strings.TrimSpace(string(output))
+	if err != nil || started == "" {
+		return "", fmt.Errorf("no process with PID %d", pid)
+	}
+	return started, nil
+}
+
+// parsePS parses `ps -o pid=,command=` output and returns the processes
+// whose command line contains pattern
+func parsePS(output, pattern string, self int) []Process {
+	processes := []Process{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil || pid == self {
+			continue
+		}
+		cmdline := strings.Join(fields[1:], " ")
+		if strings.Contains(cmdline, pattern) {
+			processes = append(processes, Process{PID: pid, Cmdline: cmdline})
+		}
+	}
+	return processes
+}
@@ -0,0 +1,185 @@
+package procnet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the socket state for LISTEN in /proc/net/tcp
+const tcpListen = "0A"
+
+// procFS implements Prober by reading a Linux /proc filesystem
+type procFS struct {
+	root string
+}
+
+func (p *procFS) PortInUse(port int) bool {
+	if !validPort(port) {
+		return false
+	}
+
+	inodes, err := p.listeningInodes(port)
+	if err == nil && len(inodes) > 0 {
+		return true
+	}
+
+	// Sockets in other network namespaces (e.g. rootless containers
+	// publishing ports) don't show up in our /proc/net/tcp
+	return dialPort(port) || bindPort(port)
+}
+
+func (p *procFS) ListeningPIDs(port int) ([]int, error) {
+	if !validPort(port) {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+
+	inodes, err := p.listeningInodes(port)
+	if err != nil || len(inodes) == 0 {
+		return nil, err
+	}
+
+	pids := []int{}
+	err = p.eachProcess(func(pid int) {
+		fds, err := os.ReadDir(filepath.Join(p.root, strconv.Itoa(pid), "fd"))
+		if err != nil {
+			// Processes of other users can't be inspected without privileges
+			return
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(p.root, strconv.Itoa(pid), "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+				pids = append(pids, pid)
+				return
+			}
+		}
+	})
+	return pids, err
+}
+
+func (p *procFS) FindProcesses(pattern string) ([]Process, error) {
+	self := os.Getpid()
+	processes := []Process{}
+
+	err := p.eachProcess(func(pid int) {
+		if pid == self {
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(p.root, strconv.Itoa(pid), "cmdline"))
+		if err != nil || len(data) == 0 {
+			return
+		}
+
+		// Arguments are NUL separated, usually with a trailing NUL
+		cmdline := strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+		if strings.Contains(cmdline, pattern) {
+			processes = append(processes, Process{PID: pid, Cmdline: cmdline})
+		}
+	})
+	return processes, err
+}
+
+func (p *procFS) ProcessStart(pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", err
+	}
+	started, err := parseStartTime(string(data))
+	if err != nil {
+		return "", err
+	}
+
+	// Start times count clock ticks since boot, so they repeat across reboots
+	if bootID, err := os.ReadFile(filepath.Join(p.root, "sys", "kernel", "random", "boot_id")); err == nil {
+		return strings.TrimSpace(string(bootID)) + "/" + started, nil
+	}
+	return started, nil
+}
+
+// parseStartTime returns the starttime field (the 22nd) of /proc/<pid>/stat.
+// Fields are counted after the command name, which may contain spaces and
+// parentheses.
+func parseStartTime(stat string) (string, error) {
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return "", fmt.Errorf("malformed stat %q", stat)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("malformed stat %q", stat)
+	}
+	return fields[19], nil
+}
+
+// eachProcess calls fn for every numeric entry in the /proc root
+func (p *procFS) eachProcess(fn func(pid int)) error {
+	entries, err := os.ReadDir(p.root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			fn(pid)
+		}
+	}
+	return nil
+}
+
+// listeningInodes returns the socket inodes listening on port, read from
+// /proc/net/tcp and /proc/net/tcp6
+func (p *procFS) listeningInodes(port int) (map[string]bool, error) {
+	inodes := map[string]bool{}
+	found := false
+
+	for _, name := range []string{"tcp", "tcp6"} {
+		file, err := os.Open(filepath.Join(p.root, "net", name))
+		if err != nil {
+			continue
+		}
+		for _, inode := range parseListeningInodes(file, port) {
+			inodes[inode] = true
+		}
+		file.Close()
+		found = true
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s/net/tcp: %w", p.root, os.ErrNotExist)
+	}
+	return inodes, nil
+}
+
+// parseListeningInodes parses a /proc/net/tcp table and returns the inodes
+// of sockets in LISTEN state on port
+func parseListeningInodes(r io.Reader, port int) []string {
+	inodes := []string{}
+	scanner := bufio.NewScanner(r)
+
+	// Skip the header line
+	scanner.Scan()
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+
+		colon := strings.LastIndex(fields[1], ":")
+		if colon < 0 {
+			continue
+		}
+		localPort, err := strconv.ParseInt(fields[1][colon+1:], 16, 32)
+		if err != nil || int(localPort) != port {
+			continue
+		}
+		inodes = append(inodes, fields[9])
+	}
+	return inodes
+}
@@ -0,0 +1,77 @@
+// Package procnet finds listening ports and running processes without
+// depending on lsof, ps or pgrep being installed.
+//
+// On Linux everything is read from /proc. Other platforms fall back to
+// dialing and binding for port checks, and to ps/lsof for process lookups
+// as they have no /proc.
+package procnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Process is a running process
+type Process struct {
+	PID     int
+	Cmdline string // Arguments joined with spaces
+}
+
+// Prober detects listening ports and the processes that own them
+type Prober interface {
+	// PortInUse reports whether something is listening on the TCP port
+	PortInUse(port int) bool
+	// ListeningPIDs returns the PIDs of processes listening on the TCP port
+	ListeningPIDs(port int) ([]int, error)
+	// FindProcesses returns processes whose command line contains pattern,
+	// excluding the calling process
+	FindProcesses(pattern string) ([]Process, error)
+	// ProcessStart returns when the process started, in a form that is only
+	// meant to be compared: a process reusing the PID has a different value
+	ProcessStart(pid int) (string, error)
+}
+
+// ErrUnsupported is returned when a lookup isn't possible on this platform
+var ErrUnsupported = errors.New("not supported on this platform")
+
+// New returns the Prober for the current platform
+func New() Prober {
+	if runtime.GOOS == "linux" {
+		if _, err := os.Stat("/proc/net/tcp"); err == nil {
+			return &procFS{root: "/proc"}
+		}
+	}
+	return &portable{}
+}
+
+// validPort reports whether port is a valid TCP port number
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// dialPort reports whether a TCP connection to the port on localhost succeeds
+func dialPort(port int) bool {
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(port)), 500*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
+
+// bindPort reports whether binding the port fails because it's taken.
+// Permission errors (e.g. privileged ports) don't count as in use.
+func bindPort(port int) bool {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return !errors.Is(err, os.ErrPermission)
+	}
+	listener.Close()
+	return false
+}
@@ -0,0 +1,190 @@
+package procnet
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tcpTable = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1111 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 2222 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:1C7A 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 3333 1 0000000000000000 100 0 0 10 0
+`
+
+const tcp6Table = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4444 1 0000000000000000 100 0 0 10 0
+`
+
+// fakeProc builds a /proc tree with the given process command lines and
+// socket inodes
+func fakeProc(t *testing.T, processes map[int]string, sockets map[int][]string) string {
+	t.Helper()
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "net"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "net", "tcp"), []byte(tcpTable), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "net", "tcp6"), []byte(tcp6Table), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "self"), 0755))
+
+	for pid, cmdline := range processes {
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+		args := strings.ReplaceAll(cmdline, " ", "\x00") + "\x00"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(args), 0644))
+
+		for i, inode := range sockets[pid] {
+			link := filepath.Join(dir, "fd", strconv.Itoa(i+3))
+			if err := os.Symlink("socket:["+inode+"]", link); err != nil {
+				t.Skipf("symlinks not supported: %v", err)
+			}
+		}
+	}
+	return root
+}
+
+func TestParseListeningInodes(t *testing.T) {
+	t.Run("Only LISTEN sockets on the port", func(t *testing.T) {
+		assert.Equal(t, []string{"1111"}, parseListeningInodes(strings.NewReader(tcpTable), 8080))
+		assert.Equal(t, []string{"3333"}, parseListeningInodes(strings.NewReader(tcpTable), 7290))
+	})
+
+	t.Run("IPv6 table", func(t *testing.T) {
+		assert.Equal(t, []string{"4444"}, parseListeningInodes(strings.NewReader(tcp6Table), 8080))
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		assert.Empty(t, parseListeningInodes(strings.NewReader(tcpTable), 9999))
+		assert.Empty(t, parseListeningInodes(strings.NewReader(""), 8080))
+	})
+}
+
+func TestProcFS(t *testing.T) {
+	root := fakeProc(t,
+		map[int]string{
+			100: "dapr dashboard -p 8080",
+			200: "temporal server start-dev --ui-port 8233",
+			300: "bash",
+		},
+		map[int][]string{
+			100: {"1111", "4444"},
+			200: {"3333"},
+		})
+	p := &procFS{root: root}
+
+	t.Run("ListeningPIDs", func(t *testing.T) {
+		pids, err := p.ListeningPIDs(8080)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{100}, pids)
+
+		pids, err = p.ListeningPIDs(7290)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{200}, pids)
+
+		pids, err = p.ListeningPIDs(9999)
+		assert.NoError(t, err)
+		assert.Empty(t, pids)
+
+		_, err = p.ListeningPIDs(-1)
+		assert.Error(t, err)
+	})
+
+	t.Run("FindProcesses", func(t *testing.T) {
+		processes, err := p.FindProcesses("temporal server start-dev")
+		assert.NoError(t, err)
+		assert.Equal(t, []Process{{PID: 200, Cmdline: "temporal server start-dev --ui-port 8233"}}, processes)
+
+		processes, err = p.FindProcesses("not running")
+		assert.NoError(t, err)
+		assert.Empty(t, processes)
+	})
+
+	t.Run("ProcessStart", func(t *testing.T) {
+		stat := "200 (temporal (dev) server) S 1 200 200 0 -1 4194560 1 0 0 0 3 1 0 0 20 0 12 0 987654 100000 50"
+		require.NoError(t, os.WriteFile(filepath.Join(root, "200", "stat"), []byte(stat), 0644))
+
+		started, err := p.ProcessStart(200)
+		assert.NoError(t, err)
+		assert.Equal(t, "987654", started, "the command name should not shift the fields")
+
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "sys", "kernel", "random"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "sys", "kernel", "random", "boot_id"), []byte("boot-1\n"), 0644))
+		started, err = p.ProcessStart(200)
+		assert.NoError(t, err)
+		assert.Equal(t, "boot-1/987654", started, "start times should be tied to the boot")
+
+		_, err = p.ProcessStart(400)
+		assert.Error(t, err, "missing processes should fail")
+		_, err = parseStartTime("300 (bash) S 1")
+		assert.Error(t, err)
+	})
+
+	t.Run("PortInUse from proc table", func(t *testing.T) {
+		assert.True(t, p.PortInUse(8080))
+		assert.False(t, p.PortInUse(-1))
+		assert.False(t, p.PortInUse(65536))
+	})
+}
+
+func TestParsePS(t *testing.T) {
+	output := `  100 dapr dashboard -p 8080
+  200 temporal server start-dev
+  300 ps -axo pid=,command=
+`
+	assert.Equal(t, []Process{{PID: 100, Cmdline: "dapr dashboard -p 8080"}}, parsePS(output, "dashboard", 300))
+	assert.Empty(t, parsePS(output, "ps -axo", 300))
+}
+
+func TestPortInUseNative(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	assert.True(t, portable{}.PortInUse(port))
+	assert.True(t, New().PortInUse(port))
+
+	listener.Close()
+	assert.False(t, portable{}.PortInUse(port))
+}
+
+func TestProcessStartNative(t *testing.T) {
+	started, err := New().ProcessStart(os.Getpid())
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("start times are not available on this platform")
+	}
+	assert.NoError(t, err)
+	assert.NotEmpty(t, started)
+
+	again, _ := New().ProcessStart(os.Getpid())
+	assert.Equal(t, started, again, "the start time of a process should not change")
+}
+
+func TestFake(t *testing.T) {
+	fake := &Fake{
+		Listening: map[int][]int{8080: {100}},
+		Processes: []Process{{PID: 100, Cmdline: "dapr dashboard"}},
+	}
+
+	assert.True(t, fake.PortInUse(8080))
+	assert.False(t, fake.PortInUse(8081))
+
+	pids, _ := fake.ListeningPIDs(8080)
+	assert.Equal(t, []int{100}, pids)
+
+	processes, _ := fake.FindProcesses("dashboard")
+	assert.Len(t, processes, 1)
+
+	fake.Starts = map[int]string{100: "t1"}
+	started, err := fake.ProcessStart(100)
+	assert.NoError(t, err)
+	assert.Equal(t, "t1", started)
+	_, err = fake.ProcessStart(200)
+	assert.Error(t, err)
+}